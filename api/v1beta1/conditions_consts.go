@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+const (
+	// HardwareSelectedCondition reports on whether Hardware has been selected for the machine. The last
+	// transition time records when the Hardware was selected.
+	HardwareSelectedCondition clusterv1.ConditionType = "HardwareSelected"
+
+	// WaitingForHardwareReason used when no Hardware is available for the machine. The machine is
+	// reconciled again once Hardware becomes available.
+	WaitingForHardwareReason = "WaitingForHardware"
+
+	// HardwareInServiceCondition reports on whether the Hardware of the machine is in service. It is
+	// false when the Hardware is annotated for maintenance and the last transition time records since
+	// when.
+	HardwareInServiceCondition clusterv1.ConditionType = "HardwareInService"
+
+	// HardwareMaintenanceReason used when the Hardware of the machine is in maintenance.
+	HardwareMaintenanceReason = "HardwareMaintenance"
+
+	// ImageAvailableCondition reports on whether the machine image is available for provisioning.
+	ImageAvailableCondition clusterv1.ConditionType = "ImageAvailable"
+
+	// ImageNotFoundReason used when none of the machine image URLs point to an existing image.
+	ImageNotFoundReason = "ImageNotFound"
+
+	// ImageLookupFailedReason used when checking the machine image availability failed.
+	ImageLookupFailedReason = "ImageLookupFailed"
+
+	// HostReachableCondition reports on whether the node running on the Hardware adopted by the machine,
+	// or booted from the disk after provisioning, is reachable.
+	HostReachableCondition clusterv1.ConditionType = "HostReachable"
+
+	// HostUnreachableReason used when the node running on the adopted Hardware is not reachable.
+	HostUnreachableReason = "HostUnreachable"
+
+	// NodeProviderIDMatchesCondition reports on whether the Node running on the Hardware adopted by the
+	// machine has the provider ID of the machine, which Cluster API uses for linking the Machine to the Node.
+	NodeProviderIDMatchesCondition clusterv1.ConditionType = "NodeProviderIDMatches"
+
+	// NodeNotFoundReason used when no Node of the workload cluster runs on the adopted Hardware.
+	NodeNotFoundReason = "NodeNotFound"
+
+	// ProviderIDMismatchReason used when the Node running on the adopted Hardware has a different provider ID.
+	ProviderIDMismatchReason = "ProviderIDMismatch"
+
+	// WaitingForBootReason used when the node is booting after provisioning. The last transition time
+	// records since when.
+	WaitingForBootReason = "WaitingForBoot"
+
+	// BootTimedOutReason used when the node did not become reachable within the boot timeout.
+	BootTimedOutReason = "BootTimedOut"
+
+	// NodeLabelsAndTaintsValidCondition reports on whether the Node labels and taints taken from the
+	// Hardware are valid. It is only set for machines taking labels or taints from the Hardware.
+	NodeLabelsAndTaintsValidCondition clusterv1.ConditionType = "NodeLabelsAndTaintsValid"
+
+	// InvalidNodeLabelsOrTaintsReason used when a Hardware label or annotation does not make a valid
+	// Node label or taint. The machine is reconciled again once the Hardware changes.
+	InvalidNodeLabelsOrTaintsReason = "InvalidNodeLabelsOrTaints"
+
+	// ReplicasReadyCondition reports on whether all replicas of the machine pool are provisioned.
+	ReplicasReadyCondition clusterv1.ConditionType = "ReplicasReady"
+
+	// WaitingForReplicasReason used when some replicas of the machine pool are not provisioned yet.
+	WaitingForReplicasReason = "WaitingForReplicas"
+)
@@ -17,6 +17,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -48,7 +49,10 @@ type TinkerbellClusterSpec struct {
 	// (if known), and the kubernetes version as defined by the packages produced by
 	// kubernetes/release: v1.13.0, v1.12.5-mybuild.1, or v1.17.3. For example, the default
 	// image format of {{.BaseRegistry}}/{{.OSDistro}}-{{.OSVersion}}:{{.KubernetesVersion}}.gz will
-	// attempt to pull the image from that location. See also: https://golang.org/pkg/text/template/
+	// attempt to pull the image from that location. Additionally {{.Arch}} and {{.Firmware}} are
+	// substituted with the CPU architecture of the selected hardware using GOARCH naming (e.g. amd64
+	// or arm64) and its firmware type (bios or uefi), which allows using per-architecture images.
+	// See also: https://golang.org/pkg/text/template/
 	// +optional
 	ImageLookupFormat string `json:"imageLookupFormat,omitempty"`
 
@@ -68,6 +72,56 @@ type TinkerbellClusterSpec struct {
 	// images. If not set it will default based on ImageLookupOSDistro.
 	// +optional
 	ImageLookupOSVersion string `json:"imageLookupOSVersion,omitempty"`
+
+	// ImageLookupVerify enables checking if the machine image is available before creating
+	// the provisioning workflow. HTTP(S) URLs are checked using HEAD request, other URLs are
+	// treated as OCI references and checked by looking up the image manifest in the registry.
+	// Verification is disabled by default and can be enabled or disabled per cluster.
+	// +optional
+	ImageLookupVerify bool `json:"imageLookupVerify,omitempty"`
+
+	// ImageLookupFallbackFormats is a list of URL naming formats, which are tried in order when
+	// the machine image rendered from ImageLookupFormat is not available. Supports the same
+	// substitutions as ImageLookupFormat and additionally {{.KubernetesMinorVersion}}, e.g. v1.20
+	// for kubernetes version v1.20.11, which allows falling back to an image built for a different
+	// patch version. Only used when ImageLookupVerify is enabled.
+	// +optional
+	ImageLookupFallbackFormats []string `json:"imageLookupFallbackFormats,omitempty"`
+
+	// ImageLookupCredentialsRef is a reference to a Secret in the TinkerbellCluster namespace with
+	// credentials used for verifying the image availability. The Secret can be either of type
+	// kubernetes.io/basic-auth with username and password keys or of type
+	// kubernetes.io/dockerconfigjson.
+	// +optional
+	ImageLookupCredentialsRef *corev1.LocalObjectReference `json:"imageLookupCredentialsRef,omitempty"`
+
+	// ImageLookupPlainHTTPRegistries lists OCI registries, e.g. registry.local:5000, which are
+	// accessed over plain HTTP instead of HTTPS when verifying the image availability.
+	// +optional
+	ImageLookupPlainHTTPRegistries []string `json:"imageLookupPlainHTTPRegistries,omitempty"`
+
+	// FailureDomainLabelKey is the key of the Hardware label, which value denotes the failure domain
+	// of the Hardware, e.g. topology.kubernetes.io/zone or a rack label. When set, failure domains
+	// are derived from the values of this label on all Hardware and published in the status, and
+	// machines with a failure domain assigned only select Hardware from that failure domain.
+	// +optional
+	FailureDomainLabelKey string `json:"failureDomainLabelKey,omitempty"`
+
+	// ControlPlaneFailureDomains limits the failure domains eligible for control plane machines.
+	// If empty, all failure domains are eligible.
+	// +optional
+	ControlPlaneFailureDomains []string `json:"controlPlaneFailureDomains,omitempty"`
+
+	// DefaultUser configures the default user of the installed OS of all cluster machines, unless a
+	// machine specifies a different DefaultUser. If not set, a tink user in wheel and adm groups with
+	// passwordless sudo and no SSH keys is created.
+	// +optional
+	DefaultUser *DefaultUser `json:"defaultUser,omitempty"`
+
+	// BMC configures power management of the cluster machines through their BMC, which is used for
+	// rebooting and reprovisioning machines in place. If not set, machines are not restarted by CAPT.
+	// +optional
+	BMC *BMC `json:"bmc,omitempty"`
 }
 
 // TinkerbellClusterStatus defines the observed state of TinkerbellCluster.
@@ -78,6 +132,11 @@ type TinkerbellClusterStatus struct {
 	// Ready denotes that the cluster (infrastructure) is ready.
 	// +optional
 	Ready bool `json:"ready"`
+
+	// FailureDomains is a list of failure domains derived from the Hardware labels selected by
+	// FailureDomainLabelKey.
+	// +optional
+	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
 }
 
 // +kubebuilder:subresource:status
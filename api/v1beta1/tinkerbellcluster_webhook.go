@@ -17,9 +17,13 @@ limitations under the License.
 package v1beta1
 
 import (
+	"net"
 	"strings"
+	"text/template"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -38,12 +42,16 @@ func (c *TinkerbellCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (c *TinkerbellCluster) ValidateCreate() error {
-	return nil
+	allErrs := validateTinkerbellClusterSpec(c.Spec, field.NewPath("spec"))
+
+	return aggregateObjErrors(c.GroupVersionKind().GroupKind(), c.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (c *TinkerbellCluster) ValidateUpdate(oldRaw runtime.Object) error {
-	return nil
+	allErrs := validateTinkerbellClusterSpec(c.Spec, field.NewPath("spec"))
+
+	return aggregateObjErrors(c.GroupVersionKind().GroupKind(), c.Name, allErrs)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
@@ -61,11 +69,84 @@ func defaultVersionForOSDistro(distro string) string {
 
 // Default implements webhookutil.defaulter so a webhook will be registered for the type.
 func (c *TinkerbellCluster) Default() {
-	if c.Spec.ImageLookupFormat == "" {
-		c.Spec.ImageLookupFormat = "{{.BaseRegistry}}/{{.OSDistro}}-{{.OSVersion}}:{{.KubernetesVersion}}.gz"
+	defaultTinkerbellClusterSpec(&c.Spec)
+}
+
+func defaultTinkerbellClusterSpec(spec *TinkerbellClusterSpec) {
+	if spec.ImageLookupFormat == "" {
+		spec.ImageLookupFormat = "{{.BaseRegistry}}/{{.OSDistro}}-{{.OSVersion}}:{{.KubernetesVersion}}.gz"
+	}
+
+	if spec.ImageLookupOSVersion == "" {
+		spec.ImageLookupOSVersion = defaultVersionForOSDistro(spec.ImageLookupOSDistro)
+	}
+}
+
+// validateTinkerbellClusterSpec validates fields of TinkerbellCluster spec, which are also commonly
+// set by ClusterClass patches.
+func validateTinkerbellClusterSpec(spec TinkerbellClusterSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if host := spec.ControlPlaneEndpoint.Host; host != "" && net.ParseIP(host) == nil {
+		for _, msg := range validation.IsDNS1123Subdomain(host) {
+			allErrs = append(allErrs, field.Invalid(path.Child("controlPlaneEndpoint", "host"), host, msg))
+		}
+	}
+
+	if port := spec.ControlPlaneEndpoint.Port; port != 0 {
+		for _, msg := range validation.IsValidPortNum(int(port)) {
+			allErrs = append(allErrs, field.Invalid(path.Child("controlPlaneEndpoint", "port"), port, msg))
+		}
+	}
+
+	if spec.ImageLookupFormat != "" {
+		if _, err := template.New("").Parse(spec.ImageLookupFormat); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("imageLookupFormat"), spec.ImageLookupFormat, err.Error()))
+		}
+	}
+
+	for i, format := range spec.ImageLookupFallbackFormats {
+		if _, err := template.New("").Parse(format); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("imageLookupFallbackFormats").Index(i), format, err.Error()))
+		}
+	}
+
+	if ref := spec.ImageLookupCredentialsRef; ref != nil && ref.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("imageLookupCredentialsRef", "name"), "must be set"))
+	}
+
+	if key := spec.FailureDomainLabelKey; key != "" {
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(path.Child("failureDomainLabelKey"), key, msg))
+		}
 	}
 
-	if c.Spec.ImageLookupOSVersion == "" {
-		c.Spec.ImageLookupOSVersion = defaultVersionForOSDistro(c.Spec.ImageLookupOSDistro)
+	if len(spec.ControlPlaneFailureDomains) > 0 && spec.FailureDomainLabelKey == "" {
+		allErrs = append(allErrs, field.Forbidden(path.Child("controlPlaneFailureDomains"),
+			"requires failureDomainLabelKey to be set"))
 	}
+
+	for i, failureDomain := range spec.ControlPlaneFailureDomains {
+		for _, msg := range validation.IsValidLabelValue(failureDomain) {
+			allErrs = append(allErrs, field.Invalid(path.Child("controlPlaneFailureDomains").Index(i), failureDomain, msg))
+		}
+	}
+
+	allErrs = append(allErrs, validateDefaultUser(spec.DefaultUser, path.Child("defaultUser"))...)
+
+	if bmc := spec.BMC; bmc != nil {
+		if bmc.WorkerHardwareName == "" {
+			allErrs = append(allErrs, field.Required(path.Child("bmc", "workerHardwareName"), "must be set"))
+		}
+
+		if bmc.Image == "" {
+			allErrs = append(allErrs, field.Required(path.Child("bmc", "image"), "must be set"))
+		}
+
+		if bmc.CredentialsRef.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("bmc", "credentialsRef", "name"), "must be set"))
+		}
+	}
+
+	return allErrs
 }
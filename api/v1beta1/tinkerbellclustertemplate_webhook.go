@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// SetupWebhookWithManager sets up and registers the webhook with the manager.
+func (c *TinkerbellClusterTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(c).Complete() //nolint:wrapcheck
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-tinkerbellclustertemplate,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellclustertemplates,versions=v1beta1,name=validation.tinkerbellclustertemplate.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-infrastructure-cluster-x-k8s-io-v1beta1-tinkerbellclustertemplate,mutating=true,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellclustertemplates,versions=v1beta1,name=default.tinkerbellclustertemplate.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (c *TinkerbellClusterTemplate) ValidateCreate() error {
+	allErrs := validateTinkerbellClusterSpec(c.Spec.Template.Spec, field.NewPath("spec", "template", "spec"))
+
+	return aggregateObjErrors(c.GroupVersionKind().GroupKind(), c.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (c *TinkerbellClusterTemplate) ValidateUpdate(old runtime.Object) error {
+	oldTinkerbellClusterTemplate, _ := old.(*TinkerbellClusterTemplate)
+
+	if !reflect.DeepEqual(c.Spec, oldTinkerbellClusterTemplate.Spec) {
+		return apierrors.NewBadRequest("TinkerbellClusterTemplate.Spec is immutable")
+	}
+
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (c *TinkerbellClusterTemplate) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhookutil.defaulter so a webhook will be registered for the type.
+func (c *TinkerbellClusterTemplate) Default() {
+	defaultTinkerbellClusterSpec(&c.Spec.Template.Spec)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ImageCompression is the compression format of the OS image.
+// +kubebuilder:validation:Enum=none;gzip;xz;bzip2
+type ImageCompression string
+
+const (
+	// ImageCompressionNone is used for raw, uncompressed images.
+	ImageCompressionNone = ImageCompression("none")
+
+	// ImageCompressionGzip is used for gzip compressed images.
+	ImageCompressionGzip = ImageCompression("gzip")
+
+	// ImageCompressionXZ is used for xz compressed images.
+	ImageCompressionXZ = ImageCompression("xz")
+
+	// ImageCompressionBzip2 is used for bzip2 compressed images.
+	ImageCompressionBzip2 = ImageCompression("bzip2")
+)
+
+// Firmware is the firmware type the OS image boots with.
+// +kubebuilder:validation:Enum=bios;uefi
+type Firmware string
+
+const (
+	// FirmwareBIOS is used for machines booting with legacy BIOS.
+	FirmwareBIOS = Firmware("bios")
+
+	// FirmwareUEFI is used for machines booting with UEFI.
+	FirmwareUEFI = Firmware("uefi")
+)
+
+// DefaultArch is the architecture assumed when neither the image nor the hardware specify one.
+const DefaultArch = "amd64"
+
+// OSImage describes a single OS image artifact.
+type OSImage struct {
+	// KubernetesVersion is the kubernetes version the image is built for, e.g. v1.20.11.
+	// +kubebuilder:validation:MinLength=1
+	KubernetesVersion string `json:"kubernetesVersion"`
+
+	// OSDistro is the name of the OS distribution, e.g. ubuntu. When the machine specifies
+	// ImageLookupOSDistro, only images with matching distribution are selected.
+	// +optional
+	OSDistro string `json:"osDistro,omitempty"`
+
+	// OSVersion is the version of the OS distribution, e.g. 20.04. When the machine specifies
+	// ImageLookupOSVersion, only images with matching version are selected.
+	// +optional
+	OSVersion string `json:"osVersion,omitempty"`
+
+	// Arch is the CPU architecture of the image, using GOARCH naming. Defaults to amd64.
+	// +optional
+	Arch string `json:"arch,omitempty"`
+
+	// Firmware is the firmware type the image supports. If not set, the image is used for
+	// both BIOS and UEFI machines, but images with matching firmware are preferred.
+	// +optional
+	Firmware Firmware `json:"firmware,omitempty"`
+
+	// URL is the location of the image, which is streamed to the disk. Either HTTP(S) URL or
+	// OCI reference.
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// SHA256 is the hex encoded sha256 digest of the image artifact as served from HTTP(S) URL or
+	// of the image manifest for OCI references. The workflow fails without leaving a bootable disk
+	// if the digest of a downloaded image does not match, and OCI references are pinned to the digest.
+	// +kubebuilder:validation:Pattern=`^[a-f0-9]{64}$`
+	SHA256 string `json:"sha256"`
+
+	// Compression is the compression format of the image. Defaults to gzip.
+	// +optional
+	Compression ImageCompression `json:"compression,omitempty"`
+}
+
+// TinkerbellImageSpec defines the desired state of TinkerbellImage.
+type TinkerbellImageSpec struct {
+	// Images is the list of available OS images.
+	// +kubebuilder:validation:MinItems=1
+	Images []OSImage `json:"images"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=tinkerbellimages,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+
+// TinkerbellImage is the Schema for the tinkerbellimages API. It is a catalog of OS images
+// referenced by TinkerbellMachines.
+type TinkerbellImage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TinkerbellImageSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TinkerbellImageList contains a list of TinkerbellImage.
+type TinkerbellImageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TinkerbellImage `json:"items"`
+}
+
+//nolint:gochecknoinits
+func init() {
+	SchemeBuilder.Register(&TinkerbellImage{}, &TinkerbellImageList{})
+}
@@ -18,7 +18,9 @@ package v1beta1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 )
 
@@ -26,6 +28,18 @@ const (
 	// MachineFinalizer allows ReconcileTinkerbellMachine to clean up Tinkerbell resources before
 	// removing it from the apiserver.
 	MachineFinalizer = "tinkerbellmachine.infrastructure.cluster.x-k8s.io"
+
+	// ReprovisionAnnotation can be set on TinkerbellMachine to request the provisioning workflow
+	// to be recreated for already selected hardware. Netboot is re-enabled for the hardware
+	// only when reprovisioning is explicitly requested. With BMC configured for the cluster, the
+	// machine is then power cycled into the installation environment running the workflow.
+	ReprovisionAnnotation = "tinkerbellmachine.infrastructure.cluster.x-k8s.io/reprovision"
+
+	// RebootAnnotation can be set on TinkerbellMachine to request already provisioned hardware to be
+	// rebooted without reinstalling it. The machine is power cycled through its BMC by a Tinkerbell
+	// workflow running on the BMC worker configured for the cluster. The annotation is removed once the
+	// workflow finishes or when the machine cannot be power cycled.
+	RebootAnnotation = "tinkerbellmachine.infrastructure.cluster.x-k8s.io/reboot"
 )
 
 // TinkerbellMachineSpec defines the desired state of TinkerbellMachine.
@@ -41,7 +55,10 @@ type TinkerbellMachineSpec struct {
 	// (if known), and the kubernetes version as defined by the packages produced by
 	// kubernetes/release: v1.13.0, v1.12.5-mybuild.1, or v1.17.3. For example, the default
 	// image format of {{.BaseRegistry}}/{{.OSDistro}}-{{.OSVersion}}:{{.KubernetesVersion}}.gz will
-	// attempt to pull the image from that location. See also: https://golang.org/pkg/text/template/
+	// attempt to pull the image from that location. Additionally {{.Arch}} and {{.Firmware}} are
+	// substituted with the CPU architecture of the selected hardware using GOARCH naming (e.g. amd64
+	// or arm64) and its firmware type (bios or uefi), which allows using per-architecture images.
+	// See also: https://golang.org/pkg/text/template/
 	// +optional
 	ImageLookupFormat string `json:"imageLookupFormat,omitempty"`
 
@@ -60,15 +77,365 @@ type TinkerbellMachineSpec struct {
 	// +optional
 	ImageLookupOSVersion string `json:"imageLookupOSVersion,omitempty"`
 
+	// Arch is the CPU architecture of the hardware to select for the machine using GOARCH naming,
+	// e.g. amd64 or arm64. The architecture is reported by the Hardware DHCP configuration. If not
+	// set, hardware of any architecture is selected.
+	// +optional
+	Arch string `json:"arch,omitempty"`
+
+	// ImageCatalogRef is a reference to a TinkerbellImage in the TinkerbellMachine namespace. When set,
+	// the machine image is selected from the catalog based on the kubernetes version, OS distribution,
+	// architecture and firmware of the hardware instead of using ImageLookupFormat, and the workflow
+	// verifies the image digest before writing it to the disk.
+	// +optional
+	ImageCatalogRef *corev1.LocalObjectReference `json:"imageCatalogRef,omitempty"`
+
+	// NodeLabelsFromHardware is a list of keys of Hardware labels or annotations, which are registered
+	// as labels of the Node with the same key and value. Labels take precedence over annotations with
+	// the same key. The labels are substituted for the NODE_LABELS placeholder in the bootstrap data,
+	// e.g. in the node-labels kubelet argument. Keys missing on the Hardware are skipped. Note that
+	// kubelet only allows registering labels outside of the kubernetes.io and k8s.io namespaces,
+	// except for the well-known ones, e.g. topology.kubernetes.io/zone.
+	// +optional
+	NodeLabelsFromHardware []string `json:"nodeLabelsFromHardware,omitempty"`
+
+	// NodeTaintsFromHardware is a list of taints registered on the Node, which values are taken from
+	// Hardware labels or annotations with the same key as the taint. The taints are substituted for the
+	// NODE_TAINTS placeholder in the bootstrap data, e.g. in the register-with-taints kubelet argument.
+	// Taints with keys missing on the Hardware are skipped.
+	// +optional
+	NodeTaintsFromHardware []NodeTaintFromHardware `json:"nodeTaintsFromHardware,omitempty"`
+
+	// HardwareReuse configures reusing Hardware released by other machines of the same MachineDeployment,
+	// control plane or machine pool, so local data survives rolling upgrades. If not set, machines
+	// select any available Hardware.
+	// +optional
+	HardwareReuse *HardwareReuse `json:"hardwareReuse,omitempty"`
+
+	// Storage configures the partition layout and filesystems of the machine. If not set, the machine
+	// image is written to the first disk and booted from its first partition with ext4 filesystem.
+	// +optional
+	Storage *StorageLayout `json:"storage,omitempty"`
+
+	// RAID configures a software RAID array the machine image is written to instead of the first disk
+	// of the Hardware. Only Hardware with all member disks is selected for the machine.
+	// +optional
+	RAID *RAID `json:"raid,omitempty"`
+
+	// PreInstallActions are added to the default provisioning workflow before the machine image is written,
+	// e.g. to update firmware or apply BIOS settings. They cannot be used together with TemplateOverride.
+	// +optional
+	PreInstallActions []WorkflowAction `json:"preInstallActions,omitempty"`
+
+	// PostInstallActions are added to the default provisioning workflow after the bootstrap configuration
+	// is written to the installed OS and before it is booted. They cannot be used together with
+	// TemplateOverride.
+	// +optional
+	PostInstallActions []WorkflowAction `json:"postInstallActions,omitempty"`
+
+	// BootMode is how the machine boots into the installed OS once the provisioning workflow finishes:
+	// kexec, reboot or poweroff. With reboot and poweroff, the machine is restarted by a separate boot
+	// workflow, which is created only once netboot is disabled, so the machine boots from the disk.
+	// Machines powered off must be powered on out of band, e.g. using their BMC. If not set, the workflow
+	// kexecs into the installed OS with cloud-config bootstrap data, and reboots with Ignition bootstrap
+	// data like with reboot.
+	// +kubebuilder:validation:Enum=kexec;reboot;poweroff
+	// +optional
+	BootMode BootMode `json:"bootMode,omitempty"`
+
+	// BootTimeout opts in to waiting for the node to become reachable from the management cluster after
+	// the provisioning workflow hands over. When set, the machine becomes ready only once the node is
+	// reachable, and the host reachability condition reports when it is not reachable within the timeout.
+	// Otherwise the machine becomes ready once the workflow hands over.
+	// +optional
+	BootTimeout *metav1.Duration `json:"bootTimeout,omitempty"`
+
+	// DefaultUser configures the default user of the installed OS. When set, it is used instead of
+	// the DefaultUser of the TinkerbellCluster.
+	// +optional
+	DefaultUser *DefaultUser `json:"defaultUser,omitempty"`
+
+	// Provisioning is either install or adopt. With adopt, the machine adopts the Hardware named in
+	// HardwareName, which already runs a provisioned node, e.g. when migrating existing clusters. No
+	// Tinkerbell template and workflow are created and the machine becomes ready once the node is
+	// reachable and has the provider ID of the machine. Defaults to install.
+	// +kubebuilder:validation:Enum=install;adopt
+	// +optional
+	Provisioning ProvisioningMode `json:"provisioning,omitempty"`
+
 	// TemplateOverride overrides the default Tinkerbell template used by CAPT.
 	// You can learn more about Tinkerbell templates here: https://docs.tinkerbell.org/templates/
 	// +optional
 	TemplateOverride string `json:"templateOverride,omitempty"`
 
+	// TemplateOverrideRef references a ConfigMap or a Template holding a Tinkerbell template, which is
+	// used instead of the default template. Unlike TemplateOverride, it is rendered as a Go template with
+	// the same data as the default template, e.g. the Hardware, its disks, the image URL and the metadata
+	// URL. Until the workflow is created in Tinkerbell, the Tinkerbell template is re-rendered when the
+	// referenced object changes. Cannot be set together with TemplateOverride.
+	// +optional
+	TemplateOverrideRef *TemplateOverrideReference `json:"templateOverrideRef,omitempty"`
+
 	// Those fields are set programmatically, but they cannot be re-constructed from "state of the world", so
 	// we put them in spec instead of status.
 	HardwareName string `json:"hardwareName,omitempty"`
-	ProviderID   string `json:"providerID,omitempty"`
+
+	// ProviderID is set to tinkerbell://<Hardware ID> once Hardware is selected. Machines adopting
+	// Hardware may set it to the provider ID of the existing Node instead, as Cluster API links the
+	// Machine to the Node with the same provider ID and the provider ID of a Node can not be changed.
+	ProviderID string `json:"providerID,omitempty"`
+}
+
+// NodeTaintFromHardware describes a Node taint with value taken from the Hardware.
+type NodeTaintFromHardware struct {
+	// Key is the key of the taint and of the Hardware label or annotation holding the taint value.
+	Key string `json:"key"`
+
+	// Effect is the effect of the taint.
+	// +kubebuilder:validation:Enum=NoSchedule;PreferNoSchedule;NoExecute
+	Effect corev1.TaintEffect `json:"effect"`
+}
+
+// ProvisioningMode defines how the machine is provisioned on the Hardware.
+type ProvisioningMode string
+
+const (
+	// ProvisioningModeInstall installs the machine image on the Hardware using a Tinkerbell workflow.
+	ProvisioningModeInstall = ProvisioningMode("install")
+
+	// ProvisioningModeAdopt adopts Hardware already running a provisioned node without reinstalling it.
+	ProvisioningModeAdopt = ProvisioningMode("adopt")
+)
+
+// BootMode defines how the machine boots into the installed OS after the provisioning workflow.
+type BootMode string
+
+const (
+	// BootModeKexec kexecs into the installed OS from the installation environment.
+	BootModeKexec = BootMode("kexec")
+
+	// BootModeReboot reboots the machine, which boots the installed OS from the disk.
+	BootModeReboot = BootMode("reboot")
+
+	// BootModePowerOff powers off the machine, which boots the installed OS from the disk once powered on.
+	BootModePowerOff = BootMode("poweroff")
+)
+
+// TemplateOverrideKind is the kind of the object holding the template override.
+type TemplateOverrideKind string
+
+const (
+	// TemplateOverrideKindConfigMap references a ConfigMap in the namespace of the machine.
+	TemplateOverrideKindConfigMap = TemplateOverrideKind("ConfigMap")
+
+	// TemplateOverrideKindTemplate references a Tinkerbell Template annotated with
+	// template.tinkerbell.org/unmanaged set to true, so it is not pushed to Tinkerbell unrendered.
+	TemplateOverrideKindTemplate = TemplateOverrideKind("Template")
+)
+
+// DefaultTemplateOverrideKey is the key of the ConfigMap holding the template override when not specified.
+const DefaultTemplateOverrideKey = "template"
+
+// TemplateOverrideReference references an object holding a Tinkerbell template used instead of the
+// default template.
+type TemplateOverrideReference struct {
+	// Kind is either ConfigMap or Template. Referenced Templates must be annotated with
+	// template.tinkerbell.org/unmanaged set to true, so they are not pushed to Tinkerbell unrendered.
+	// +kubebuilder:validation:Enum=ConfigMap;Template
+	Kind TemplateOverrideKind `json:"kind"`
+
+	// Name is the name of the referenced object.
+	Name string `json:"name"`
+
+	// Key is the key of the ConfigMap holding the template. Defaults to template.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// HardwareReusePolicy defines whether machines prefer or require Hardware released by the machines
+// they replace.
+type HardwareReusePolicy string
+
+const (
+	// HardwareReusePolicyPrefer selects Hardware reserved for the machine group if there is any,
+	// otherwise any available Hardware.
+	HardwareReusePolicyPrefer = HardwareReusePolicy("Prefer")
+
+	// HardwareReusePolicyRequire additionally waits for Hardware of machines of the group, which are
+	// being removed, instead of selecting other Hardware.
+	HardwareReusePolicyRequire = HardwareReusePolicy("Require")
+)
+
+// HardwareReuse describes how machines reuse Hardware released by other machines of the same group,
+// which is the MachineDeployment, control plane or machine pool of the machine.
+type HardwareReuse struct {
+	// Policy is either Prefer or Require. Defaults to Prefer.
+	// +kubebuilder:validation:Enum=Prefer;Require
+	// +optional
+	Policy HardwareReusePolicy `json:"policy,omitempty"`
+
+	// ReservationPeriod is for how long Hardware released by a machine is reserved for other machines
+	// of the group. Other machines do not select reserved Hardware. Defaults to 10 minutes.
+	// +optional
+	ReservationPeriod *metav1.Duration `json:"reservationPeriod,omitempty"`
+
+	// RootPartitionImageLookupFormat is the URL naming format of images containing only the root
+	// partition, with the same substitutions as ImageLookupFormat. When set, Hardware previously
+	// provisioned by the group is reprovisioned by writing the image to the root partition only,
+	// which keeps the partition table and other data partitions intact.
+	// +optional
+	RootPartitionImageLookupFormat string `json:"rootPartitionImageLookupFormat,omitempty"`
+}
+
+// FilesystemType is the type of filesystem.
+type FilesystemType string
+
+const (
+	// FilesystemTypeExt4 is the ext4 filesystem.
+	FilesystemTypeExt4 = FilesystemType("ext4")
+
+	// FilesystemTypeXFS is the XFS filesystem.
+	FilesystemTypeXFS = FilesystemType("xfs")
+)
+
+// StorageLayout describes the root partition of the machine image and additional partitions, LVM volume
+// groups and filesystems created in the free space of the disk after the image is written.
+type StorageLayout struct {
+	// RootPartition selects the partition of the machine image, which the bootstrap configuration is
+	// written to and which is booted.
+	// +optional
+	RootPartition *RootPartition `json:"rootPartition,omitempty"`
+
+	// Partitions are created in the order listed after the partitions of the machine image. Filesystems
+	// with mount point are registered in the fstab of the root partition. When Hardware is reused keeping
+	// data partitions, they are not recreated.
+	// +optional
+	Partitions []Partition `json:"partitions,omitempty"`
+
+	// VolumeGroups are LVM volume groups created on the partitions referencing them.
+	// +optional
+	VolumeGroups []VolumeGroup `json:"volumeGroups,omitempty"`
+}
+
+// RootPartition selects the root partition of the machine image.
+type RootPartition struct {
+	// Number is the number of the root partition on the disk. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Number int `json:"number,omitempty"`
+
+	// Label is the GPT partition name of the root partition. If set, the partition is found by the label
+	// instead of the number.
+	// +optional
+	Label string `json:"label,omitempty"`
+
+	// Filesystem is the filesystem of the root partition. Defaults to ext4.
+	// +kubebuilder:validation:Enum=ext4;xfs
+	// +optional
+	Filesystem FilesystemType `json:"filesystem,omitempty"`
+}
+
+// Partition describes a partition created in the free space of the disk.
+type Partition struct {
+	// Label is the GPT partition name. It must be unique within the disk.
+	Label string `json:"label"`
+
+	// Size is the size of the partition. If not set, the partition takes the remaining space of the
+	// disk, so only the last partition may omit the size.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// Filesystem is the filesystem the partition is formatted with. Required unless VolumeGroup is set.
+	// +kubebuilder:validation:Enum=ext4;xfs
+	// +optional
+	Filesystem FilesystemType `json:"filesystem,omitempty"`
+
+	// MountPoint is the absolute path the filesystem is mounted at, e.g. /var/lib/containerd.
+	// +optional
+	MountPoint string `json:"mountPoint,omitempty"`
+
+	// VolumeGroup is the name of the volume group the partition is added to as LVM physical volume
+	// instead of being formatted.
+	// +optional
+	VolumeGroup string `json:"volumeGroup,omitempty"`
+}
+
+// VolumeGroup describes an LVM volume group.
+type VolumeGroup struct {
+	// Name is the name of the volume group.
+	Name string `json:"name"`
+
+	// LogicalVolumes are created in the volume group in the order listed.
+	// +optional
+	LogicalVolumes []LogicalVolume `json:"logicalVolumes,omitempty"`
+}
+
+// LogicalVolume describes an LVM logical volume.
+type LogicalVolume struct {
+	// Name is the name of the logical volume.
+	Name string `json:"name"`
+
+	// Size is the size of the logical volume. If not set, the volume takes the remaining space of the
+	// volume group, so only the last volume may omit the size.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// Filesystem is the filesystem the logical volume is formatted with.
+	// +kubebuilder:validation:Enum=ext4;xfs
+	Filesystem FilesystemType `json:"filesystem"`
+
+	// MountPoint is the absolute path the filesystem is mounted at, e.g. /var/lib/kubelet.
+	// +optional
+	MountPoint string `json:"mountPoint,omitempty"`
+}
+
+// RAID describes a software RAID array created with mdadm before the machine image is written.
+type RAID struct {
+	// Level is the RAID level of the array.
+	// +kubebuilder:validation:Enum=0;1;5;6;10
+	Level int `json:"level"`
+
+	// Devices are the member disks of the array, e.g. /dev/sda, which must be listed in the disks of the
+	// Hardware status.
+	// +kubebuilder:validation:MinItems=2
+	Devices []string `json:"devices"`
+}
+
+// WorkflowAction describes a user supplied action of the provisioning workflow.
+type WorkflowAction struct {
+	// Name is the name of the action. It must be unique within the workflow.
+	Name string `json:"name"`
+
+	// Image is the container image of the action.
+	Image string `json:"image"`
+
+	// Env is the environment of the action container.
+	// +optional
+	Env []WorkflowActionEnvVar `json:"env,omitempty"`
+
+	// Volumes are host paths mounted into the action container in the host-path:container-path[:ro]
+	// format, e.g. /dev:/dev.
+	// +optional
+	Volumes []string `json:"volumes,omitempty"`
+
+	// Timeout is how long the action may run before the workflow fails. Defaults to 10 minutes.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// WorkflowActionEnvVar describes an environment variable of a workflow action.
+type WorkflowActionEnvVar struct {
+	// Name is the name of the environment variable.
+	Name string `json:"name"`
+
+	// Value is the value of the environment variable.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// SecretKeyRef selects a key of a Secret in the namespace of the machine holding the value. Note that
+	// the value is rendered into the Tinkerbell template of the machine.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
 
 // TinkerbellMachineStatus defines the observed state of TinkerbellMachine.
@@ -87,6 +454,12 @@ type TinkerbellMachineStatus struct {
 	// +optional
 	InstanceStatus *TinkerbellResourceStatus `json:"instanceStatus,omitempty"`
 
+	// WaitingForHardwareSince is set when no Hardware was available for the machine and records since
+	// when the machine is waiting. Hardware becoming available is assigned to waiting machines in this
+	// order. It is cleared once Hardware is selected for the machine.
+	// +optional
+	WaitingForHardwareSince *metav1.Time `json:"waitingForHardwareSince,omitempty"`
+
 	// Any transient errors that occur during the reconciliation of Machines
 	// can be added as events to the Machine object and/or logged in the
 	// controller's output.
@@ -111,6 +484,10 @@ type TinkerbellMachineStatus struct {
 	// controller's output.
 	// +optional
 	ErrorMessage *string `json:"errorMessage,omitempty"`
+
+	// Conditions defines current service state of the TinkerbellMachine.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:subresource:status
@@ -132,6 +509,16 @@ type TinkerbellMachine struct {
 	Status TinkerbellMachineStatus `json:"status,omitempty"`
 }
 
+// GetConditions returns the set of conditions for this object.
+func (m *TinkerbellMachine) GetConditions() clusterv1.Conditions {
+	return m.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (m *TinkerbellMachine) SetConditions(conditions clusterv1.Conditions) {
+	m.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // TinkerbellMachineList contains a list of TinkerbellMachine.
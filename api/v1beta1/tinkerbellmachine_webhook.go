@@ -17,21 +17,123 @@ limitations under the License.
 package v1beta1
 
 import (
+	"context"
+	"fmt"
+	"regexp"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 )
 
 // SetupWebhookWithManager sets up and registers the webhook with the manager.
 func (m *TinkerbellMachine) SetupWebhookWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewWebhookManagedBy(mgr).For(m).Complete() //nolint:wrapcheck
+	validator := &tinkerbellMachineValidator{client: mgr.GetClient()}
+
+	return ctrl.NewWebhookManagedBy(mgr).For(m).WithValidator(validator).Complete() //nolint:wrapcheck
+}
+
+// tinkerbellMachineValidator validates TinkerbellMachines including their references to the Hardware.
+type tinkerbellMachineValidator struct {
+	client client.Reader
+}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *tinkerbellMachineValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	m, ok := obj.(*TinkerbellMachine)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a TinkerbellMachine but got a %T", obj))
+	}
+
+	if err := m.ValidateCreate(); err != nil {
+		return err
+	}
+
+	return v.validateRAIDDevices(ctx, m)
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (v *tinkerbellMachineValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	m, ok := newObj.(*TinkerbellMachine)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a TinkerbellMachine but got a %T", newObj))
+	}
+
+	if err := m.ValidateUpdate(oldObj); err != nil {
+		return err
+	}
+
+	return v.validateRAIDDevices(ctx, m)
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (v *tinkerbellMachineValidator) ValidateDelete(_ context.Context, obj runtime.Object) error {
+	m, ok := obj.(*TinkerbellMachine)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a TinkerbellMachine but got a %T", obj))
+	}
+
+	return m.ValidateDelete()
+}
+
+// validateRAIDDevices validates the RAID member disks exist on the Hardware of the machine. Machines
+// without Hardware are not validated, as only Hardware with the member disks is selected for them.
+func (v *tinkerbellMachineValidator) validateRAIDDevices(ctx context.Context, m *TinkerbellMachine) error {
+	if m.Spec.RAID == nil || m.Spec.HardwareName == "" {
+		return nil
+	}
+
+	hardware := &tinkv1.Hardware{}
+
+	if err := v.client.Get(ctx, client.ObjectKey{Name: m.Spec.HardwareName}, hardware); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return apierrors.NewInternalError(fmt.Errorf("getting Hardware %q: %w", m.Spec.HardwareName, err))
+	}
+
+	disks := map[string]bool{}
+
+	for _, disk := range hardware.Status.Disks {
+		disks[disk.Device] = true
+	}
+
+	var allErrs field.ErrorList
+
+	for i, device := range m.Spec.RAID.Devices {
+		if !disks[device] {
+			allErrs = append(allErrs, field.NotFound(field.NewPath("spec", "raid", "devices").Index(i), device))
+		}
+	}
+
+	return aggregateObjErrors(m.GroupVersionKind().GroupKind(), m.Name, allErrs)
 }
 
 // +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-tinkerbellmachine,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellmachines,versions=v1beta1,name=validation.tinkerbellmachine.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (m *TinkerbellMachine) ValidateCreate() error {
-	return nil
+	var allErrs field.ErrorList
+
+	if m.Spec.Provisioning == ProvisioningModeAdopt && m.Spec.HardwareName == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("spec", "hardwareName"),
+			"must be set when adopting Hardware"))
+	}
+
+	allErrs = append(allErrs, validateStorageLayout(m.Spec.Storage, field.NewPath("spec", "storage"))...)
+	allErrs = append(allErrs, validateRAID(m.Spec.RAID, field.NewPath("spec", "raid"))...)
+	allErrs = append(allErrs, validateWorkflowActions(&m.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateBoot(&m.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateTemplateOverride(&m.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateDefaultUser(m.Spec.DefaultUser, field.NewPath("spec", "defaultUser"))...)
+
+	return aggregateObjErrors(m.GroupVersionKind().GroupKind(), m.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
@@ -48,6 +150,17 @@ func (m *TinkerbellMachine) ValidateUpdate(oldRaw runtime.Object) error {
 		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "providerID"), "is immutable once set"))
 	}
 
+	allErrs = append(allErrs, validateStorageLayout(m.Spec.Storage, field.NewPath("spec", "storage"))...)
+	allErrs = append(allErrs, validateRAID(m.Spec.RAID, field.NewPath("spec", "raid"))...)
+	allErrs = append(allErrs, validateWorkflowActions(&m.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateBoot(&m.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateTemplateOverride(&m.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateDefaultUser(m.Spec.DefaultUser, field.NewPath("spec", "defaultUser"))...)
+
+	if m.Spec.Provisioning != old.Spec.Provisioning {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "provisioning"), "is immutable"))
+	}
+
 	return aggregateObjErrors(m.GroupVersionKind().GroupKind(), m.Name, allErrs)
 }
 
@@ -55,3 +168,410 @@ func (m *TinkerbellMachine) ValidateUpdate(oldRaw runtime.Object) error {
 func (m *TinkerbellMachine) ValidateDelete() error {
 	return nil
 }
+
+var (
+	// storageNameRegexp matches names of partitions and LVM objects, which are safe to use in the rendered
+	// workflow. GPT partition names are limited to 36 characters.
+	storageNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,35}$`)
+
+	// deviceRegexp matches block devices, which are safe to use in the rendered workflow.
+	deviceRegexp = regexp.MustCompile(`^/dev/[a-zA-Z0-9_-]+(/[a-zA-Z0-9_.:-]+)*$`)
+
+	// raidMinDevices is the minimal number of member disks for RAID levels requiring more than two.
+	raidMinDevices = map[int]int{5: 3, 6: 4, 10: 4} //nolint:gomnd
+
+	// mountPointRegexp matches absolute paths, which are safe to use in the rendered workflow.
+	mountPointRegexp = regexp.MustCompile(`^(/[a-zA-Z0-9._-]+)+$`)
+
+	// envNameRegexp matches names of environment variables of workflow actions.
+	envNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	// volumeRegexp matches volumes of workflow actions in host-path:container-path[:ro] format.
+	volumeRegexp = regexp.MustCompile(`^/[^:]*:/[^:]*(:ro|:rw)?$`)
+
+	// userNameRegexp matches names of users and groups of the installed OS.
+	userNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+	// defaultActionNames are the names of the actions of the default workflow, which user supplied
+	// actions must not use.
+	defaultActionNames = map[string]bool{
+		"create-raid":                   true,
+		"stream-image":                  true,
+		"configure-storage":             true,
+		"add-tink-cloud-init-config":    true,
+		"add-tink-cloud-init-ds-config": true,
+		"add-tink-ignition-config":      true,
+		"kexec-image":                   true,
+		"reboot-image":                  true,
+		"poweroff-image":                true,
+	}
+)
+
+// validateStorageLayout validates the names, sizes and references of the partitions and LVM objects.
+//
+//nolint:funlen,gocognit,cyclop
+func validateStorageLayout(layout *StorageLayout, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if layout == nil {
+		return allErrs
+	}
+
+	if root := layout.RootPartition; root != nil && root.Label != "" {
+		if root.Number != 0 {
+			allErrs = append(allErrs, field.Forbidden(path.Child("rootPartition", "number"),
+				"cannot be set together with label"))
+		}
+
+		if !storageNameRegexp.MatchString(root.Label) {
+			allErrs = append(allErrs, field.Invalid(path.Child("rootPartition", "label"), root.Label,
+				"must match "+storageNameRegexp.String()))
+		}
+	}
+
+	volumeGroups := map[string]bool{}
+
+	for i, vg := range layout.VolumeGroups {
+		vgPath := path.Child("volumeGroups").Index(i)
+
+		if !storageNameRegexp.MatchString(vg.Name) {
+			allErrs = append(allErrs, field.Invalid(vgPath.Child("name"), vg.Name, "must match "+storageNameRegexp.String()))
+		}
+
+		if volumeGroups[vg.Name] {
+			allErrs = append(allErrs, field.Duplicate(vgPath.Child("name"), vg.Name))
+		}
+
+		volumeGroups[vg.Name] = false
+
+		logicalVolumes := map[string]bool{}
+
+		for j, lv := range vg.LogicalVolumes {
+			lvPath := vgPath.Child("logicalVolumes").Index(j)
+
+			if !storageNameRegexp.MatchString(lv.Name) {
+				allErrs = append(allErrs, field.Invalid(lvPath.Child("name"), lv.Name,
+					"must match "+storageNameRegexp.String()))
+			}
+
+			if logicalVolumes[lv.Name] {
+				allErrs = append(allErrs, field.Duplicate(lvPath.Child("name"), lv.Name))
+			}
+
+			logicalVolumes[lv.Name] = true
+
+			allErrs = append(allErrs, validateStorageSize(lv.Size, j == len(vg.LogicalVolumes)-1, lvPath.Child("size"))...)
+		}
+	}
+
+	labels := map[string]bool{}
+
+	for i, p := range layout.Partitions {
+		partitionPath := path.Child("partitions").Index(i)
+
+		if !storageNameRegexp.MatchString(p.Label) {
+			allErrs = append(allErrs, field.Invalid(partitionPath.Child("label"), p.Label,
+				"must match "+storageNameRegexp.String()))
+		}
+
+		if labels[p.Label] {
+			allErrs = append(allErrs, field.Duplicate(partitionPath.Child("label"), p.Label))
+		}
+
+		labels[p.Label] = true
+
+		allErrs = append(allErrs, validateStorageSize(p.Size, i == len(layout.Partitions)-1, partitionPath.Child("size"))...)
+
+		switch _, ok := volumeGroups[p.VolumeGroup]; {
+		case p.VolumeGroup == "" && p.Filesystem == "":
+			allErrs = append(allErrs, field.Required(partitionPath.Child("filesystem"),
+				"must be set unless volumeGroup is set"))
+		case p.VolumeGroup == "":
+		case !ok:
+			allErrs = append(allErrs, field.NotFound(partitionPath.Child("volumeGroup"), p.VolumeGroup))
+		case p.Filesystem != "" || p.MountPoint != "":
+			allErrs = append(allErrs, field.Forbidden(partitionPath.Child("volumeGroup"),
+				"cannot be set together with filesystem or mountPoint"))
+		default:
+			volumeGroups[p.VolumeGroup] = true
+		}
+	}
+
+	for i, vg := range layout.VolumeGroups {
+		if !volumeGroups[vg.Name] {
+			allErrs = append(allErrs, field.Invalid(path.Child("volumeGroups").Index(i).Child("name"), vg.Name,
+				"must be referenced by at least one partition"))
+		}
+	}
+
+	return append(allErrs, validateMountPoints(layout, path)...)
+}
+
+// validateStorageSize validates the size of a partition or logical volume. Only the last one may omit
+// the size to take the remaining space.
+func validateStorageSize(size *resource.Quantity, last bool, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch {
+	case size == nil && !last:
+		allErrs = append(allErrs, field.Required(path, "can only be omitted for the last one"))
+	case size != nil && size.Value() < 1<<20:
+		allErrs = append(allErrs, field.Invalid(path, size.String(), "must be at least 1Mi"))
+	}
+
+	return allErrs
+}
+
+// validateMountPoints validates the mount points are absolute paths used only once.
+func validateMountPoints(layout *StorageLayout, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	mountPoints := map[string]bool{}
+
+	validate := func(mountPoint string, mountPointPath *field.Path) {
+		if mountPoint == "" {
+			return
+		}
+
+		if !mountPointRegexp.MatchString(mountPoint) {
+			allErrs = append(allErrs, field.Invalid(mountPointPath, mountPoint, "must be an absolute path other than /"))
+		}
+
+		if mountPoints[mountPoint] {
+			allErrs = append(allErrs, field.Duplicate(mountPointPath, mountPoint))
+		}
+
+		mountPoints[mountPoint] = true
+	}
+
+	for i, p := range layout.Partitions {
+		validate(p.MountPoint, path.Child("partitions").Index(i).Child("mountPoint"))
+	}
+
+	for i, vg := range layout.VolumeGroups {
+		for j, lv := range vg.LogicalVolumes {
+			validate(lv.MountPoint, path.Child("volumeGroups").Index(i).Child("logicalVolumes").Index(j).Child("mountPoint"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateRAID validates the member disks of the RAID array are unique block devices and there are enough
+// of them for the RAID level.
+func validateRAID(raid *RAID, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if raid == nil {
+		return allErrs
+	}
+
+	devices := map[string]bool{}
+
+	for i, device := range raid.Devices {
+		if !deviceRegexp.MatchString(device) {
+			allErrs = append(allErrs, field.Invalid(path.Child("devices").Index(i), device, "must be a block device"))
+		}
+
+		if devices[device] {
+			allErrs = append(allErrs, field.Duplicate(path.Child("devices").Index(i), device))
+		}
+
+		devices[device] = true
+	}
+
+	if min := raidMinDevices[raid.Level]; len(raid.Devices) < min {
+		allErrs = append(allErrs, field.Invalid(path.Child("devices"), raid.Devices,
+			fmt.Sprintf("RAID level %d requires at least %d devices", raid.Level, min)))
+	}
+
+	return allErrs
+}
+
+// validateWorkflowActions validates the pre- and post-install actions have unique names, images and
+// valid environment and volumes.
+//
+//nolint:funlen,gocognit,cyclop
+func validateWorkflowActions(spec *TinkerbellMachineSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := map[string]bool{}
+
+	for _, actions := range []struct {
+		name  string
+		items []WorkflowAction
+	}{
+		{name: "preInstallActions", items: spec.PreInstallActions},
+		{name: "postInstallActions", items: spec.PostInstallActions},
+	} {
+		actionsPath := path.Child(actions.name)
+
+		if spec.TemplateOverride != "" && len(actions.items) > 0 {
+			allErrs = append(allErrs, field.Forbidden(actionsPath, "cannot be set together with templateOverride"))
+		}
+
+		if spec.TemplateOverrideRef != nil && len(actions.items) > 0 {
+			allErrs = append(allErrs, field.Forbidden(actionsPath, "cannot be set together with templateOverrideRef"))
+		}
+
+		for i, action := range actions.items {
+			actionPath := actionsPath.Index(i)
+
+			switch {
+			case action.Name == "":
+				allErrs = append(allErrs, field.Required(actionPath.Child("name"), ""))
+			case defaultActionNames[action.Name]:
+				allErrs = append(allErrs, field.Invalid(actionPath.Child("name"), action.Name,
+					"is used by the default workflow"))
+			case names[action.Name]:
+				allErrs = append(allErrs, field.Duplicate(actionPath.Child("name"), action.Name))
+			}
+
+			names[action.Name] = true
+
+			if action.Image == "" {
+				allErrs = append(allErrs, field.Required(actionPath.Child("image"), ""))
+			}
+
+			if action.Timeout != nil && action.Timeout.Seconds() < 1 {
+				allErrs = append(allErrs, field.Invalid(actionPath.Child("timeout"), action.Timeout.Duration.String(),
+					"must be at least 1s"))
+			}
+
+			for j, volume := range action.Volumes {
+				if !volumeRegexp.MatchString(volume) {
+					allErrs = append(allErrs, field.Invalid(actionPath.Child("volumes").Index(j), volume,
+						"must be in host-path:container-path[:ro] format"))
+				}
+			}
+
+			envNames := map[string]bool{}
+
+			for j, env := range action.Env {
+				envPath := actionPath.Child("env").Index(j)
+
+				if !envNameRegexp.MatchString(env.Name) {
+					allErrs = append(allErrs, field.Invalid(envPath.Child("name"), env.Name,
+						"must match "+envNameRegexp.String()))
+				}
+
+				if envNames[env.Name] {
+					allErrs = append(allErrs, field.Duplicate(envPath.Child("name"), env.Name))
+				}
+
+				envNames[env.Name] = true
+
+				if ref := env.SecretKeyRef; ref != nil {
+					if env.Value != "" {
+						allErrs = append(allErrs, field.Forbidden(envPath.Child("value"),
+							"cannot be set together with secretKeyRef"))
+					}
+
+					if ref.Name == "" {
+						allErrs = append(allErrs, field.Required(envPath.Child("secretKeyRef", "name"), ""))
+					}
+
+					if ref.Key == "" {
+						allErrs = append(allErrs, field.Required(envPath.Child("secretKeyRef", "key"), ""))
+					}
+				}
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateBoot validates the boot timeout is positive.
+func validateBoot(spec *TinkerbellMachineSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if spec.BootTimeout == nil {
+		return allErrs
+	}
+
+	if spec.BootTimeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("bootTimeout"), spec.BootTimeout.Duration.String(),
+			"must be positive"))
+	}
+
+	return allErrs
+}
+
+// validateDefaultUser validates the names of the default user and its groups and the reference to its
+// SSH authorized keys.
+func validateDefaultUser(user *DefaultUser, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if user == nil || user.Disabled {
+		return allErrs
+	}
+
+	if user.Name != "" && !userNameRegexp.MatchString(user.Name) {
+		allErrs = append(allErrs, field.Invalid(path.Child("name"), user.Name, "must match "+userNameRegexp.String()))
+	}
+
+	for i, group := range user.Groups {
+		if !userNameRegexp.MatchString(group) {
+			allErrs = append(allErrs, field.Invalid(path.Child("groups").Index(i), group,
+				"must match "+userNameRegexp.String()))
+		}
+	}
+
+	source := user.SSHAuthorizedKeysFrom
+	if source == nil {
+		return allErrs
+	}
+
+	sourcePath := path.Child("sshAuthorizedKeysFrom")
+
+	switch {
+	case source.SecretKeyRef != nil && source.ConfigMapKeyRef != nil:
+		allErrs = append(allErrs, field.Forbidden(sourcePath, "only one of secretKeyRef and configMapKeyRef can be set"))
+	case source.SecretKeyRef != nil:
+		if source.SecretKeyRef.Name == "" {
+			allErrs = append(allErrs, field.Required(sourcePath.Child("secretKeyRef", "name"), ""))
+		}
+	case source.ConfigMapKeyRef != nil:
+		if source.ConfigMapKeyRef.Name == "" {
+			allErrs = append(allErrs, field.Required(sourcePath.Child("configMapKeyRef", "name"), ""))
+		}
+	default:
+		allErrs = append(allErrs, field.Required(sourcePath, "one of secretKeyRef and configMapKeyRef must be set"))
+	}
+
+	return allErrs
+}
+
+// validateTemplateOverride validates the reference to the template override.
+func validateTemplateOverride(spec *TinkerbellMachineSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	ref := spec.TemplateOverrideRef
+	if ref == nil {
+		return allErrs
+	}
+
+	refPath := path.Child("templateOverrideRef")
+
+	if spec.TemplateOverride != "" {
+		allErrs = append(allErrs, field.Forbidden(refPath, "cannot be set together with templateOverride"))
+	}
+
+	if ref.Name == "" {
+		allErrs = append(allErrs, field.Required(refPath.Child("name"), ""))
+	}
+
+	switch ref.Kind {
+	case TemplateOverrideKindConfigMap:
+	case TemplateOverrideKindTemplate:
+		if ref.Key != "" {
+			allErrs = append(allErrs, field.Forbidden(refPath.Child("key"), "can only be set for ConfigMap kind"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(refPath.Child("kind"), ref.Kind,
+			[]string{string(TemplateOverrideKindConfigMap), string(TemplateOverrideKindTemplate)}))
+	}
+
+	return allErrs
+}
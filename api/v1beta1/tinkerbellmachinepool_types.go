@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// MachinePoolFinalizer allows ReconcileTinkerbellMachinePool to remove machines of the pool, releasing
+	// their Hardware, before removing it from the apiserver.
+	MachinePoolFinalizer = "tinkerbellmachinepool.infrastructure.cluster.x-k8s.io"
+
+	// MachinePoolNameLabel is set on TinkerbellMachines created for a TinkerbellMachinePool to the name
+	// of the pool.
+	MachinePoolNameLabel = "tinkerbellmachinepool.infrastructure.cluster.x-k8s.io/name"
+)
+
+// TinkerbellMachinePoolSpec defines the desired state of TinkerbellMachinePool.
+type TinkerbellMachinePoolSpec struct {
+	// HardwareSelector selects Hardware, which may be claimed by machines of the pool. If not set,
+	// any available Hardware may be claimed.
+	// +optional
+	HardwareSelector *metav1.LabelSelector `json:"hardwareSelector,omitempty"`
+
+	// Template is the specification of TinkerbellMachines created for the pool. Changes are only
+	// applied to machines created afterwards.
+	// +optional
+	Template TinkerbellMachineSpec `json:"template,omitempty"`
+
+	// ProviderIDList are the identifications of the provisioned machines of the pool.
+	// +optional
+	ProviderIDList []string `json:"providerIDList,omitempty"`
+}
+
+// TinkerbellMachinePoolStatus defines the observed state of TinkerbellMachinePool.
+type TinkerbellMachinePoolStatus struct {
+	// Ready is true when all replicas of the pool are provisioned.
+	// +optional
+	Ready bool `json:"ready"`
+
+	// Replicas is the number of machines of the pool, including machines being provisioned.
+	// +optional
+	Replicas int32 `json:"replicas"`
+
+	// ReadyReplicas is the number of provisioned machines of the pool.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// Conditions defines current service state of the TinkerbellMachinePool.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:subresource:status
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=tinkerbellmachinepools,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".metadata.labels.cluster\\.x-k8s\\.io/cluster-name",description="Cluster to which this TinkerbellMachinePool belongs"
+// +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".status.replicas",description="Number of machines of the pool"
+// +kubebuilder:printcolumn:name="Ready Replicas",type="integer",JSONPath=".status.readyReplicas",description="Number of provisioned machines of the pool"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Machine pool ready status"
+// +kubebuilder:printcolumn:name="MachinePool",type="string",JSONPath=".metadata.ownerReferences[?(@.kind==\"MachinePool\")].name",description="MachinePool object which owns with this TinkerbellMachinePool"
+
+// TinkerbellMachinePool is the Schema for the tinkerbellmachinepools API. It claims Hardware for
+// the replicas of a MachinePool by managing a TinkerbellMachine for each replica.
+type TinkerbellMachinePool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TinkerbellMachinePoolSpec   `json:"spec,omitempty"`
+	Status TinkerbellMachinePoolStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (m *TinkerbellMachinePool) GetConditions() clusterv1.Conditions {
+	return m.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (m *TinkerbellMachinePool) SetConditions(conditions clusterv1.Conditions) {
+	m.Status.Conditions = conditions
+}
+
+// +kubebuilder:object:root=true
+
+// TinkerbellMachinePoolList contains a list of TinkerbellMachinePool.
+type TinkerbellMachinePoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TinkerbellMachinePool `json:"items"`
+}
+
+//nolint:gochecknoinits
+func init() {
+	SchemeBuilder.Register(&TinkerbellMachinePool{}, &TinkerbellMachinePoolList{})
+}
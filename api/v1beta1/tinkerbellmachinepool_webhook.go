@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// SetupWebhookWithManager sets up and registers the webhook with the manager.
+func (m *TinkerbellMachinePool) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(m).Complete() //nolint:wrapcheck
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-tinkerbellmachinepool,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellmachinepools,versions=v1beta1,name=validation.tinkerbellmachinepool.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (m *TinkerbellMachinePool) ValidateCreate() error {
+	return aggregateObjErrors(m.GroupVersionKind().GroupKind(), m.Name, m.validateSpec())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (m *TinkerbellMachinePool) ValidateUpdate(oldRaw runtime.Object) error {
+	return aggregateObjErrors(m.GroupVersionKind().GroupKind(), m.Name, m.validateSpec())
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (m *TinkerbellMachinePool) ValidateDelete() error {
+	return nil
+}
+
+func (m *TinkerbellMachinePool) validateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+
+	templatePath := field.NewPath("spec", "template")
+
+	// Each machine of the pool claims its own Hardware.
+	if m.Spec.Template.HardwareName != "" {
+		allErrs = append(allErrs, field.Forbidden(templatePath.Child("hardwareName"), "must not be set for machine pools"))
+	}
+
+	if m.Spec.Template.ProviderID != "" {
+		allErrs = append(allErrs, field.Forbidden(templatePath.Child("providerID"), "must not be set for machine pools"))
+	}
+
+	if m.Spec.Template.Provisioning == ProvisioningModeAdopt {
+		allErrs = append(allErrs, field.Forbidden(templatePath.Child("provisioning"), "must not be adopt for machine pools"))
+	}
+
+	allErrs = append(allErrs, validateStorageLayout(m.Spec.Template.Storage, templatePath.Child("storage"))...)
+	allErrs = append(allErrs, validateRAID(m.Spec.Template.RAID, templatePath.Child("raid"))...)
+	allErrs = append(allErrs, validateWorkflowActions(&m.Spec.Template, templatePath)...)
+	allErrs = append(allErrs, validateBoot(&m.Spec.Template, templatePath)...)
+	allErrs = append(allErrs, validateTemplateOverride(&m.Spec.Template, templatePath)...)
+	allErrs = append(allErrs, validateDefaultUser(m.Spec.Template.DefaultUser, templatePath.Child("defaultUser"))...)
+
+	if m.Spec.HardwareSelector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(m.Spec.HardwareSelector,
+			field.NewPath("spec", "hardwareSelector"))...)
+	}
+
+	return allErrs
+}
@@ -47,6 +47,18 @@ func (m *TinkerbellMachineTemplate) ValidateCreate() error {
 		allErrs = append(allErrs, field.Forbidden(fieldBasePath.Child("hardwareName"), "cannot be set in templates"))
 	}
 
+	if spec.Provisioning == ProvisioningModeAdopt {
+		allErrs = append(allErrs, field.Forbidden(fieldBasePath.Child("provisioning"),
+			"adopting Hardware is only supported for individual machines"))
+	}
+
+	allErrs = append(allErrs, validateStorageLayout(spec.Storage, fieldBasePath.Child("storage"))...)
+	allErrs = append(allErrs, validateRAID(spec.RAID, fieldBasePath.Child("raid"))...)
+	allErrs = append(allErrs, validateWorkflowActions(&spec, fieldBasePath)...)
+	allErrs = append(allErrs, validateBoot(&spec, fieldBasePath)...)
+	allErrs = append(allErrs, validateTemplateOverride(&spec, fieldBasePath)...)
+	allErrs = append(allErrs, validateDefaultUser(spec.DefaultUser, fieldBasePath.Child("defaultUser"))...)
+
 	return aggregateObjErrors(m.GroupVersionKind().GroupKind(), m.Name, allErrs)
 }
 
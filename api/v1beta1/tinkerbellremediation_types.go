@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultRebootTimeout is the time to wait for the machine to become healthy after the reboot,
+	// before it is reprovisioned.
+	DefaultRebootTimeout = 15 * time.Minute
+
+	// DefaultReprovisionTimeout is the time to wait for the machine to become healthy after
+	// reprovisioning, before it is deleted.
+	DefaultReprovisionTimeout = 30 * time.Minute
+)
+
+// RemediationPhase is the remediation step being executed for the unhealthy machine.
+type RemediationPhase string
+
+const (
+	// RemediationPhaseRebooting means the machine is being rebooted through a Tinkerbell workflow.
+	RemediationPhaseRebooting = RemediationPhase("Rebooting")
+
+	// RemediationPhaseReprovisioning means the machine is being reprovisioned on the same Hardware.
+	RemediationPhaseReprovisioning = RemediationPhase("Reprovisioning")
+
+	// RemediationPhaseDeleting means in-place remediation did not help and the Machine has been deleted,
+	// so its owner replaces it.
+	RemediationPhaseDeleting = RemediationPhase("Deleting")
+)
+
+// TinkerbellRemediationSpec defines the desired state of TinkerbellRemediation.
+type TinkerbellRemediationSpec struct {
+	// RebootTimeout is the time to wait for the machine to become healthy after the reboot, before
+	// it is reprovisioned. Defaults to 15 minutes.
+	// +optional
+	RebootTimeout *metav1.Duration `json:"rebootTimeout,omitempty"`
+
+	// ReprovisionTimeout is the time to wait for the machine to become healthy after reprovisioning,
+	// before the Machine is deleted. Defaults to 30 minutes.
+	// +optional
+	ReprovisionTimeout *metav1.Duration `json:"reprovisionTimeout,omitempty"`
+}
+
+// TinkerbellRemediationStatus defines the observed state of TinkerbellRemediation.
+type TinkerbellRemediationStatus struct {
+	// Phase is the remediation step being executed.
+	// +optional
+	Phase RemediationPhase `json:"phase,omitempty"`
+
+	// RebootAttempts is the number of reboots requested for the machine.
+	// +optional
+	RebootAttempts int32 `json:"rebootAttempts,omitempty"`
+
+	// ReprovisionAttempts is the number of reprovisionings requested for the machine.
+	// +optional
+	ReprovisionAttempts int32 `json:"reprovisionAttempts,omitempty"`
+
+	// LastTransitionTime is the time the current phase started.
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// TimeoutTime is the time the current phase times out and the next remediation step is taken.
+	// +optional
+	TimeoutTime *metav1.Time `json:"timeoutTime,omitempty"`
+}
+
+// +kubebuilder:subresource:status
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=tinkerbellremediations,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Remediation step being executed"
+// +kubebuilder:printcolumn:name="Reboots",type="integer",JSONPath=".status.rebootAttempts",description="Number of requested reboots"
+// +kubebuilder:printcolumn:name="Reprovisions",type="integer",JSONPath=".status.reprovisionAttempts",description="Number of requested reprovisionings"
+// +kubebuilder:printcolumn:name="Machine",type="string",JSONPath=".metadata.ownerReferences[?(@.kind==\"Machine\")].name",description="Machine object being remediated"
+
+// TinkerbellRemediation is the Schema for the tinkerbellremediations API. It is created by
+// MachineHealthCheck for an unhealthy Machine, which is first rebooted, then reprovisioned on
+// the same Hardware and only then deleted.
+type TinkerbellRemediation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TinkerbellRemediationSpec   `json:"spec,omitempty"`
+	Status TinkerbellRemediationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TinkerbellRemediationList contains a list of TinkerbellRemediation.
+type TinkerbellRemediationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TinkerbellRemediation `json:"items"`
+}
+
+//nolint:gochecknoinits
+func init() {
+	SchemeBuilder.Register(&TinkerbellRemediation{}, &TinkerbellRemediationList{})
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// SetupWebhookWithManager sets up and registers the webhook with the manager.
+func (r *TinkerbellRemediation) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(r).Complete() //nolint:wrapcheck
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-tinkerbellremediation,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellremediations,versions=v1beta1,name=validation.tinkerbellremediation.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-infrastructure-cluster-x-k8s-io-v1beta1-tinkerbellremediation,mutating=true,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellremediations,versions=v1beta1,name=default.tinkerbellremediation.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *TinkerbellRemediation) ValidateCreate() error {
+	allErrs := validateTinkerbellRemediationSpec(r.Spec, field.NewPath("spec"))
+
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *TinkerbellRemediation) ValidateUpdate(old runtime.Object) error {
+	allErrs := validateTinkerbellRemediationSpec(r.Spec, field.NewPath("spec"))
+
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *TinkerbellRemediation) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhookutil.defaulter so a webhook will be registered for the type.
+func (r *TinkerbellRemediation) Default() {
+	defaultTinkerbellRemediationSpec(&r.Spec)
+}
+
+func defaultTinkerbellRemediationSpec(spec *TinkerbellRemediationSpec) {
+	if spec.RebootTimeout == nil {
+		spec.RebootTimeout = &metav1.Duration{Duration: DefaultRebootTimeout}
+	}
+
+	if spec.ReprovisionTimeout == nil {
+		spec.ReprovisionTimeout = &metav1.Duration{Duration: DefaultReprovisionTimeout}
+	}
+}
+
+func validateTinkerbellRemediationSpec(spec TinkerbellRemediationSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if spec.RebootTimeout != nil && spec.RebootTimeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("rebootTimeout"), spec.RebootTimeout.Duration.String(),
+			"must be positive"))
+	}
+
+	if spec.ReprovisionTimeout != nil && spec.ReprovisionTimeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("reprovisionTimeout"),
+			spec.ReprovisionTimeout.Duration.String(), "must be positive"))
+	}
+
+	return allErrs
+}
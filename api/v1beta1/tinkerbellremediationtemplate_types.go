@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TinkerbellRemediationTemplateSpec defines the desired state of TinkerbellRemediationTemplate.
+type TinkerbellRemediationTemplateSpec struct {
+	Template TinkerbellRemediationTemplateResource `json:"template"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=tinkerbellremediationtemplates,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+
+// TinkerbellRemediationTemplate is the Schema for the tinkerbellremediationtemplates API. It is
+// referenced by MachineHealthCheck remediation template for creating TinkerbellRemediations.
+type TinkerbellRemediationTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TinkerbellRemediationTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TinkerbellRemediationTemplateList contains a list of TinkerbellRemediationTemplate.
+type TinkerbellRemediationTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TinkerbellRemediationTemplate `json:"items"`
+}
+
+//nolint:gochecknoinits
+func init() {
+	SchemeBuilder.Register(&TinkerbellRemediationTemplate{}, &TinkerbellRemediationTemplateList{})
+}
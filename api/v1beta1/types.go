@@ -16,6 +16,10 @@ limitations under the License.
 
 package v1beta1
 
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
 // TinkerbellResourceStatus describes the status of a Tinkerbell resource.
 type TinkerbellResourceStatus int
 
@@ -33,3 +37,86 @@ type TinkerbellMachineTemplateResource struct {
 	// Spec is the specification of the desired behavior of the machine.
 	Spec TinkerbellMachineSpec `json:"spec"`
 }
+
+// TinkerbellClusterTemplateResource describes the data needed to create a TinkerbellCluster from a template.
+type TinkerbellClusterTemplateResource struct {
+	// Spec is the specification of the desired behavior of the cluster.
+	Spec TinkerbellClusterSpec `json:"spec"`
+}
+
+// TinkerbellRemediationTemplateResource describes the data needed to create a TinkerbellRemediation
+// from a template.
+type TinkerbellRemediationTemplateResource struct {
+	// Spec is the specification of the desired remediation behavior.
+	Spec TinkerbellRemediationSpec `json:"spec"`
+}
+
+// SudoPolicy defines the sudo privileges of the default user of the installed OS.
+type SudoPolicy string
+
+const (
+	// SudoPolicyNoPassword allows the user to run any command as root without a password.
+	SudoPolicyNoPassword = SudoPolicy("NoPassword")
+
+	// SudoPolicyPassword allows the user to run any command as root after entering the user password.
+	SudoPolicyPassword = SudoPolicy("Password")
+
+	// SudoPolicyNone does not allow the user to use sudo.
+	SudoPolicyNone = SudoPolicy("None")
+)
+
+// DefaultUser describes the default user of the installed OS, which is created by cloud-init. It is
+// not used with Ignition bootstrap data.
+type DefaultUser struct {
+	// Disabled disables creating the default user. Other fields are then ignored.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Name is the name of the user. Defaults to tink.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Groups are the groups the user is added to. Defaults to wheel and adm.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// Sudo is either NoPassword, Password or None. Defaults to NoPassword.
+	// +kubebuilder:validation:Enum=NoPassword;Password;None
+	// +optional
+	Sudo SudoPolicy `json:"sudo,omitempty"`
+
+	// SSHAuthorizedKeysFrom selects the SSH public keys authorized to log in as the user.
+	// +optional
+	SSHAuthorizedKeysFrom *SSHAuthorizedKeysSource `json:"sshAuthorizedKeysFrom,omitempty"`
+}
+
+// SSHAuthorizedKeysSource selects a key of a Secret or ConfigMap in the namespace of the machine holding
+// SSH public keys in the authorized_keys format. Exactly one of the references must be set.
+type SSHAuthorizedKeysSource struct {
+	// SecretKeyRef selects a key of a Secret.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	// ConfigMapKeyRef selects a key of a ConfigMap.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// BMC configures power management of machines using IPMI. The address of the BMC of each machine is
+// read from the v1alpha1.tinkerbell.org/bmc-address annotation of its Hardware. Power actions run as
+// Tinkerbell workflows on a dedicated worker, which can reach the BMCs.
+type BMC struct {
+	// WorkerHardwareName is the name of the Hardware running tink-worker with access to the BMCs of the
+	// machines, which runs the power workflows.
+	// +kubebuilder:validation:MinLength=1
+	WorkerHardwareName string `json:"workerHardwareName"`
+
+	// Image is the image of the action running the power workflows, which must provide /bin/sh and ipmitool.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// CredentialsRef is a reference to a Secret of type kubernetes.io/basic-auth in the TinkerbellCluster
+	// namespace with the username and password keys used for accessing the BMCs. The credentials are
+	// stored in the Templates of the power workflows and passed to the worker.
+	CredentialsRef corev1.LocalObjectReference `json:"credentialsRef"`
+}
@@ -22,17 +22,259 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/errors"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BMC) DeepCopyInto(out *BMC) {
+	*out = *in
+	out.CredentialsRef = in.CredentialsRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMC.
+func (in *BMC) DeepCopy() *BMC {
+	if in == nil {
+		return nil
+	}
+	out := new(BMC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultUser) DeepCopyInto(out *DefaultUser) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SSHAuthorizedKeysFrom != nil {
+		in, out := &in.SSHAuthorizedKeysFrom, &out.SSHAuthorizedKeysFrom
+		*out = new(SSHAuthorizedKeysSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultUser.
+func (in *DefaultUser) DeepCopy() *DefaultUser {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareReuse) DeepCopyInto(out *HardwareReuse) {
+	*out = *in
+	if in.ReservationPeriod != nil {
+		in, out := &in.ReservationPeriod, &out.ReservationPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareReuse.
+func (in *HardwareReuse) DeepCopy() *HardwareReuse {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareReuse)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogicalVolume) DeepCopyInto(out *LogicalVolume) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalVolume.
+func (in *LogicalVolume) DeepCopy() *LogicalVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(LogicalVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTaintFromHardware) DeepCopyInto(out *NodeTaintFromHardware) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTaintFromHardware.
+func (in *NodeTaintFromHardware) DeepCopy() *NodeTaintFromHardware {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTaintFromHardware)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSImage) DeepCopyInto(out *OSImage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSImage.
+func (in *OSImage) DeepCopy() *OSImage {
+	if in == nil {
+		return nil
+	}
+	out := new(OSImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Partition) DeepCopyInto(out *Partition) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Partition.
+func (in *Partition) DeepCopy() *Partition {
+	if in == nil {
+		return nil
+	}
+	out := new(Partition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RAID) DeepCopyInto(out *RAID) {
+	*out = *in
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RAID.
+func (in *RAID) DeepCopy() *RAID {
+	if in == nil {
+		return nil
+	}
+	out := new(RAID)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RootPartition) DeepCopyInto(out *RootPartition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RootPartition.
+func (in *RootPartition) DeepCopy() *RootPartition {
+	if in == nil {
+		return nil
+	}
+	out := new(RootPartition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHAuthorizedKeysSource) DeepCopyInto(out *SSHAuthorizedKeysSource) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHAuthorizedKeysSource.
+func (in *SSHAuthorizedKeysSource) DeepCopy() *SSHAuthorizedKeysSource {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHAuthorizedKeysSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageLayout) DeepCopyInto(out *StorageLayout) {
+	*out = *in
+	if in.RootPartition != nil {
+		in, out := &in.RootPartition, &out.RootPartition
+		*out = new(RootPartition)
+		**out = **in
+	}
+	if in.Partitions != nil {
+		in, out := &in.Partitions, &out.Partitions
+		*out = make([]Partition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeGroups != nil {
+		in, out := &in.VolumeGroups, &out.VolumeGroups
+		*out = make([]VolumeGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageLayout.
+func (in *StorageLayout) DeepCopy() *StorageLayout {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageLayout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateOverrideReference) DeepCopyInto(out *TemplateOverrideReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateOverrideReference.
+func (in *TemplateOverrideReference) DeepCopy() *TemplateOverrideReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateOverrideReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TinkerbellCluster) DeepCopyInto(out *TinkerbellCluster) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellCluster.
@@ -89,6 +331,36 @@ func (in *TinkerbellClusterList) DeepCopyObject() runtime.Object {
 func (in *TinkerbellClusterSpec) DeepCopyInto(out *TinkerbellClusterSpec) {
 	*out = *in
 	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
+	if in.ImageLookupFallbackFormats != nil {
+		in, out := &in.ImageLookupFallbackFormats, &out.ImageLookupFallbackFormats
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImageLookupCredentialsRef != nil {
+		in, out := &in.ImageLookupCredentialsRef, &out.ImageLookupCredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.ImageLookupPlainHTTPRegistries != nil {
+		in, out := &in.ImageLookupPlainHTTPRegistries, &out.ImageLookupPlainHTTPRegistries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ControlPlaneFailureDomains != nil {
+		in, out := &in.ControlPlaneFailureDomains, &out.ControlPlaneFailureDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultUser != nil {
+		in, out := &in.DefaultUser, &out.DefaultUser
+		*out = new(DefaultUser)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BMC != nil {
+		in, out := &in.BMC, &out.BMC
+		*out = new(BMC)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellClusterSpec.
@@ -104,6 +376,13 @@ func (in *TinkerbellClusterSpec) DeepCopy() *TinkerbellClusterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TinkerbellClusterStatus) DeepCopyInto(out *TinkerbellClusterStatus) {
 	*out = *in
+	if in.FailureDomains != nil {
+		in, out := &in.FailureDomains, &out.FailureDomains
+		*out = make(apiv1beta1.FailureDomains, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellClusterStatus.
@@ -117,26 +396,25 @@ func (in *TinkerbellClusterStatus) DeepCopy() *TinkerbellClusterStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TinkerbellMachine) DeepCopyInto(out *TinkerbellMachine) {
+func (in *TinkerbellClusterTemplate) DeepCopyInto(out *TinkerbellClusterTemplate) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	in.Status.DeepCopyInto(&out.Status)
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachine.
-func (in *TinkerbellMachine) DeepCopy() *TinkerbellMachine {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellClusterTemplate.
+func (in *TinkerbellClusterTemplate) DeepCopy() *TinkerbellClusterTemplate {
 	if in == nil {
 		return nil
 	}
-	out := new(TinkerbellMachine)
+	out := new(TinkerbellClusterTemplate)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *TinkerbellMachine) DeepCopyObject() runtime.Object {
+func (in *TinkerbellClusterTemplate) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -144,31 +422,31 @@ func (in *TinkerbellMachine) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TinkerbellMachineList) DeepCopyInto(out *TinkerbellMachineList) {
+func (in *TinkerbellClusterTemplateList) DeepCopyInto(out *TinkerbellClusterTemplateList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]TinkerbellMachine, len(*in))
+		*out = make([]TinkerbellClusterTemplate, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineList.
-func (in *TinkerbellMachineList) DeepCopy() *TinkerbellMachineList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellClusterTemplateList.
+func (in *TinkerbellClusterTemplateList) DeepCopy() *TinkerbellClusterTemplateList {
 	if in == nil {
 		return nil
 	}
-	out := new(TinkerbellMachineList)
+	out := new(TinkerbellClusterTemplateList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *TinkerbellMachineList) DeepCopyObject() runtime.Object {
+func (in *TinkerbellClusterTemplateList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -176,75 +454,57 @@ func (in *TinkerbellMachineList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TinkerbellMachineSpec) DeepCopyInto(out *TinkerbellMachineSpec) {
+func (in *TinkerbellClusterTemplateResource) DeepCopyInto(out *TinkerbellClusterTemplateResource) {
 	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineSpec.
-func (in *TinkerbellMachineSpec) DeepCopy() *TinkerbellMachineSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellClusterTemplateResource.
+func (in *TinkerbellClusterTemplateResource) DeepCopy() *TinkerbellClusterTemplateResource {
 	if in == nil {
 		return nil
 	}
-	out := new(TinkerbellMachineSpec)
+	out := new(TinkerbellClusterTemplateResource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TinkerbellMachineStatus) DeepCopyInto(out *TinkerbellMachineStatus) {
+func (in *TinkerbellClusterTemplateSpec) DeepCopyInto(out *TinkerbellClusterTemplateSpec) {
 	*out = *in
-	if in.Addresses != nil {
-		in, out := &in.Addresses, &out.Addresses
-		*out = make([]v1.NodeAddress, len(*in))
-		copy(*out, *in)
-	}
-	if in.InstanceStatus != nil {
-		in, out := &in.InstanceStatus, &out.InstanceStatus
-		*out = new(TinkerbellResourceStatus)
-		**out = **in
-	}
-	if in.ErrorReason != nil {
-		in, out := &in.ErrorReason, &out.ErrorReason
-		*out = new(errors.MachineStatusError)
-		**out = **in
-	}
-	if in.ErrorMessage != nil {
-		in, out := &in.ErrorMessage, &out.ErrorMessage
-		*out = new(string)
-		**out = **in
-	}
+	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineStatus.
-func (in *TinkerbellMachineStatus) DeepCopy() *TinkerbellMachineStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellClusterTemplateSpec.
+func (in *TinkerbellClusterTemplateSpec) DeepCopy() *TinkerbellClusterTemplateSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(TinkerbellMachineStatus)
+	out := new(TinkerbellClusterTemplateSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TinkerbellMachineTemplate) DeepCopyInto(out *TinkerbellMachineTemplate) {
+func (in *TinkerbellImage) DeepCopyInto(out *TinkerbellImage) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineTemplate.
-func (in *TinkerbellMachineTemplate) DeepCopy() *TinkerbellMachineTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellImage.
+func (in *TinkerbellImage) DeepCopy() *TinkerbellImage {
 	if in == nil {
 		return nil
 	}
-	out := new(TinkerbellMachineTemplate)
+	out := new(TinkerbellImage)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *TinkerbellMachineTemplate) DeepCopyObject() runtime.Object {
+func (in *TinkerbellImage) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -252,31 +512,31 @@ func (in *TinkerbellMachineTemplate) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TinkerbellMachineTemplateList) DeepCopyInto(out *TinkerbellMachineTemplateList) {
+func (in *TinkerbellImageList) DeepCopyInto(out *TinkerbellImageList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]TinkerbellMachineTemplate, len(*in))
+		*out = make([]TinkerbellImage, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineTemplateList.
-func (in *TinkerbellMachineTemplateList) DeepCopy() *TinkerbellMachineTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellImageList.
+func (in *TinkerbellImageList) DeepCopy() *TinkerbellImageList {
 	if in == nil {
 		return nil
 	}
-	out := new(TinkerbellMachineTemplateList)
+	out := new(TinkerbellImageList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *TinkerbellMachineTemplateList) DeepCopyObject() runtime.Object {
+func (in *TinkerbellImageList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -284,33 +544,668 @@ func (in *TinkerbellMachineTemplateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TinkerbellMachineTemplateResource) DeepCopyInto(out *TinkerbellMachineTemplateResource) {
+func (in *TinkerbellImageSpec) DeepCopyInto(out *TinkerbellImageSpec) {
 	*out = *in
-	out.Spec = in.Spec
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]OSImage, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineTemplateResource.
-func (in *TinkerbellMachineTemplateResource) DeepCopy() *TinkerbellMachineTemplateResource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellImageSpec.
+func (in *TinkerbellImageSpec) DeepCopy() *TinkerbellImageSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(TinkerbellMachineTemplateResource)
+	out := new(TinkerbellImageSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TinkerbellMachineTemplateSpec) DeepCopyInto(out *TinkerbellMachineTemplateSpec) {
+func (in *TinkerbellMachine) DeepCopyInto(out *TinkerbellMachine) {
 	*out = *in
-	out.Template = in.Template
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineTemplateSpec.
-func (in *TinkerbellMachineTemplateSpec) DeepCopy() *TinkerbellMachineTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachine.
+func (in *TinkerbellMachine) DeepCopy() *TinkerbellMachine {
 	if in == nil {
 		return nil
 	}
-	out := new(TinkerbellMachineTemplateSpec)
+	out := new(TinkerbellMachine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellMachine) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachineList) DeepCopyInto(out *TinkerbellMachineList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TinkerbellMachine, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineList.
+func (in *TinkerbellMachineList) DeepCopy() *TinkerbellMachineList {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachineList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellMachineList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachinePool) DeepCopyInto(out *TinkerbellMachinePool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachinePool.
+func (in *TinkerbellMachinePool) DeepCopy() *TinkerbellMachinePool {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachinePool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellMachinePool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachinePoolList) DeepCopyInto(out *TinkerbellMachinePoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TinkerbellMachinePool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachinePoolList.
+func (in *TinkerbellMachinePoolList) DeepCopy() *TinkerbellMachinePoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachinePoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellMachinePoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachinePoolSpec) DeepCopyInto(out *TinkerbellMachinePoolSpec) {
+	*out = *in
+	if in.HardwareSelector != nil {
+		in, out := &in.HardwareSelector, &out.HardwareSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Template.DeepCopyInto(&out.Template)
+	if in.ProviderIDList != nil {
+		in, out := &in.ProviderIDList, &out.ProviderIDList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachinePoolSpec.
+func (in *TinkerbellMachinePoolSpec) DeepCopy() *TinkerbellMachinePoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachinePoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachinePoolStatus) DeepCopyInto(out *TinkerbellMachinePoolStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachinePoolStatus.
+func (in *TinkerbellMachinePoolStatus) DeepCopy() *TinkerbellMachinePoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachinePoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachineSpec) DeepCopyInto(out *TinkerbellMachineSpec) {
+	*out = *in
+	if in.ImageCatalogRef != nil {
+		in, out := &in.ImageCatalogRef, &out.ImageCatalogRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.NodeLabelsFromHardware != nil {
+		in, out := &in.NodeLabelsFromHardware, &out.NodeLabelsFromHardware
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeTaintsFromHardware != nil {
+		in, out := &in.NodeTaintsFromHardware, &out.NodeTaintsFromHardware
+		*out = make([]NodeTaintFromHardware, len(*in))
+		copy(*out, *in)
+	}
+	if in.HardwareReuse != nil {
+		in, out := &in.HardwareReuse, &out.HardwareReuse
+		*out = new(HardwareReuse)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageLayout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RAID != nil {
+		in, out := &in.RAID, &out.RAID
+		*out = new(RAID)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreInstallActions != nil {
+		in, out := &in.PreInstallActions, &out.PreInstallActions
+		*out = make([]WorkflowAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostInstallActions != nil {
+		in, out := &in.PostInstallActions, &out.PostInstallActions
+		*out = make([]WorkflowAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BootTimeout != nil {
+		in, out := &in.BootTimeout, &out.BootTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.DefaultUser != nil {
+		in, out := &in.DefaultUser, &out.DefaultUser
+		*out = new(DefaultUser)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TemplateOverrideRef != nil {
+		in, out := &in.TemplateOverrideRef, &out.TemplateOverrideRef
+		*out = new(TemplateOverrideReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineSpec.
+func (in *TinkerbellMachineSpec) DeepCopy() *TinkerbellMachineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachineStatus) DeepCopyInto(out *TinkerbellMachineStatus) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]v1.NodeAddress, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstanceStatus != nil {
+		in, out := &in.InstanceStatus, &out.InstanceStatus
+		*out = new(TinkerbellResourceStatus)
+		**out = **in
+	}
+	if in.WaitingForHardwareSince != nil {
+		in, out := &in.WaitingForHardwareSince, &out.WaitingForHardwareSince
+		*out = (*in).DeepCopy()
+	}
+	if in.ErrorReason != nil {
+		in, out := &in.ErrorReason, &out.ErrorReason
+		*out = new(errors.MachineStatusError)
+		**out = **in
+	}
+	if in.ErrorMessage != nil {
+		in, out := &in.ErrorMessage, &out.ErrorMessage
+		*out = new(string)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineStatus.
+func (in *TinkerbellMachineStatus) DeepCopy() *TinkerbellMachineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachineTemplate) DeepCopyInto(out *TinkerbellMachineTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineTemplate.
+func (in *TinkerbellMachineTemplate) DeepCopy() *TinkerbellMachineTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachineTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellMachineTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachineTemplateList) DeepCopyInto(out *TinkerbellMachineTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TinkerbellMachineTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineTemplateList.
+func (in *TinkerbellMachineTemplateList) DeepCopy() *TinkerbellMachineTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachineTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellMachineTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachineTemplateResource) DeepCopyInto(out *TinkerbellMachineTemplateResource) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineTemplateResource.
+func (in *TinkerbellMachineTemplateResource) DeepCopy() *TinkerbellMachineTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachineTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellMachineTemplateSpec) DeepCopyInto(out *TinkerbellMachineTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellMachineTemplateSpec.
+func (in *TinkerbellMachineTemplateSpec) DeepCopy() *TinkerbellMachineTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellMachineTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellRemediation) DeepCopyInto(out *TinkerbellRemediation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellRemediation.
+func (in *TinkerbellRemediation) DeepCopy() *TinkerbellRemediation {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellRemediation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellRemediation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellRemediationList) DeepCopyInto(out *TinkerbellRemediationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TinkerbellRemediation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellRemediationList.
+func (in *TinkerbellRemediationList) DeepCopy() *TinkerbellRemediationList {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellRemediationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellRemediationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellRemediationSpec) DeepCopyInto(out *TinkerbellRemediationSpec) {
+	*out = *in
+	if in.RebootTimeout != nil {
+		in, out := &in.RebootTimeout, &out.RebootTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ReprovisionTimeout != nil {
+		in, out := &in.ReprovisionTimeout, &out.ReprovisionTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellRemediationSpec.
+func (in *TinkerbellRemediationSpec) DeepCopy() *TinkerbellRemediationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellRemediationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellRemediationStatus) DeepCopyInto(out *TinkerbellRemediationStatus) {
+	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.TimeoutTime != nil {
+		in, out := &in.TimeoutTime, &out.TimeoutTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellRemediationStatus.
+func (in *TinkerbellRemediationStatus) DeepCopy() *TinkerbellRemediationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellRemediationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellRemediationTemplate) DeepCopyInto(out *TinkerbellRemediationTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellRemediationTemplate.
+func (in *TinkerbellRemediationTemplate) DeepCopy() *TinkerbellRemediationTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellRemediationTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellRemediationTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellRemediationTemplateList) DeepCopyInto(out *TinkerbellRemediationTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TinkerbellRemediationTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellRemediationTemplateList.
+func (in *TinkerbellRemediationTemplateList) DeepCopy() *TinkerbellRemediationTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellRemediationTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TinkerbellRemediationTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellRemediationTemplateResource) DeepCopyInto(out *TinkerbellRemediationTemplateResource) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellRemediationTemplateResource.
+func (in *TinkerbellRemediationTemplateResource) DeepCopy() *TinkerbellRemediationTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellRemediationTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TinkerbellRemediationTemplateSpec) DeepCopyInto(out *TinkerbellRemediationTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TinkerbellRemediationTemplateSpec.
+func (in *TinkerbellRemediationTemplateSpec) DeepCopy() *TinkerbellRemediationTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TinkerbellRemediationTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeGroup) DeepCopyInto(out *VolumeGroup) {
+	*out = *in
+	if in.LogicalVolumes != nil {
+		in, out := &in.LogicalVolumes, &out.LogicalVolumes
+		*out = make([]LogicalVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeGroup.
+func (in *VolumeGroup) DeepCopy() *VolumeGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowAction) DeepCopyInto(out *WorkflowAction) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]WorkflowActionEnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowAction.
+func (in *WorkflowAction) DeepCopy() *WorkflowAction {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowActionEnvVar) DeepCopyInto(out *WorkflowActionEnvVar) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowActionEnvVar.
+func (in *WorkflowActionEnvVar) DeepCopy() *WorkflowActionEnvVar {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowActionEnvVar)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
+)
+
+// workflowActions returns the workflow template actions for user supplied actions with environment
+// values read from the referenced Secrets.
+func (mrc *machineReconcileContext) workflowActions(
+	actions []infrastructurev1.WorkflowAction,
+) ([]templates.Action, error) {
+	result := []templates.Action{}
+
+	for _, action := range actions {
+		templateAction := templates.Action{
+			Name:    action.Name,
+			Image:   action.Image,
+			Volumes: action.Volumes,
+		}
+
+		if action.Timeout != nil {
+			templateAction.Timeout = int64(action.Timeout.Seconds())
+		}
+
+		for _, env := range action.Env {
+			value, ok, err := mrc.actionEnvValue(env)
+			if err != nil {
+				return nil, fmt.Errorf("getting value of %q of action %q: %w", env.Name, action.Name, err)
+			}
+
+			if !ok {
+				continue
+			}
+
+			if templateAction.Environment == nil {
+				templateAction.Environment = map[string]string{}
+			}
+
+			templateAction.Environment[env.Name] = value
+		}
+
+		result = append(result, templateAction)
+	}
+
+	return result, nil
+}
+
+// actionEnvValue returns the value of the environment variable of a workflow action. Missing optional
+// Secrets and keys are reported as not found.
+func (mrc *machineReconcileContext) actionEnvValue(env infrastructurev1.WorkflowActionEnvVar) (string, bool, error) {
+	if env.SecretKeyRef == nil {
+		return env.Value, true, nil
+	}
+
+	return mrc.secretKeyValue(env.SecretKeyRef)
+}
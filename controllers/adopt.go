@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// adoptionProbeInterval is the interval of checking reachability of the node running on adopted Hardware.
+const adoptionProbeInterval = 30 * time.Second
+
+// ErrHardwareOwnedByOtherMachine is returned when the Hardware to adopt is already owned by other machine.
+var ErrHardwareOwnedByOtherMachine = fmt.Errorf("hardware is owned by other machine")
+
+// adopting reports whether the machine adopts already provisioned Hardware instead of installing it.
+func (mrc *machineReconcileContext) adopting() bool {
+	return mrc.tinkerbellMachine.Spec.Provisioning == infrastructurev1.ProvisioningModeAdopt
+}
+
+// adoptedHardware returns the Hardware named in the machine spec, unless it is owned by other machine.
+func (mrc *machineReconcileContext) adoptedHardware() (*tinkv1.Hardware, error) {
+	hardware := &tinkv1.Hardware{}
+
+	namespacedName := types.NamespacedName{
+		Name: mrc.tinkerbellMachine.Spec.HardwareName,
+	}
+
+	if err := mrc.client.Get(mrc.ctx, namespacedName, hardware); err != nil {
+		return nil, fmt.Errorf("getting Hardware %q to adopt: %w", namespacedName.Name, err)
+	}
+
+	if owner, ok := hardware.Labels[HardwareOwnerNameLabel]; ok {
+		return nil, fmt.Errorf("%w: %s/%s", ErrHardwareOwnedByOtherMachine,
+			hardware.Labels[HardwareOwnerNamespaceLabel], owner)
+	}
+
+	return hardware, nil
+}
+
+// ensureAdopted keeps netboot disabled for the adopted Hardware, so it is never reinstalled, and waits
+// until the node running on it is reachable and has the provider ID of the machine. Once the machine is
+// ready, the node is no longer probed.
+func (mrc *machineReconcileContext) ensureAdopted(hardware *tinkv1.Hardware) error {
+	if err := mrc.ensureHardwareNetboot(hardware, false); err != nil {
+		return fmt.Errorf("disabling netboot: %w", err)
+	}
+
+	if mrc.tinkerbellMachine.Status.Ready {
+		return nil
+	}
+
+	ip, err := hardwareIP(hardware)
+	if err != nil {
+		return fmt.Errorf("extracting Hardware IP address: %w", err)
+	}
+
+	reachable, err := mrc.hostProber.Reachable(mrc.ctx, ip)
+	if err != nil {
+		return fmt.Errorf("probing host %q: %w", ip, err)
+	}
+
+	if !reachable {
+		mrc.log.Info("Adopted host is not reachable, waiting", "address", ip)
+
+		if !conditions.IsFalse(mrc.tinkerbellMachine, infrastructurev1.HostReachableCondition) {
+			mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeWarning, infrastructurev1.HostUnreachableReason,
+				"Adopted host %s on Hardware %s is not reachable", ip, hardware.Name)
+		}
+
+		conditions.MarkFalse(mrc.tinkerbellMachine, infrastructurev1.HostReachableCondition,
+			infrastructurev1.HostUnreachableReason, clusterv1.ConditionSeverityWarning,
+			"Host %s is not reachable", ip)
+
+		if err := mrc.patch(); err != nil {
+			return fmt.Errorf("patching TinkerbellMachine with host reachability condition: %w", err)
+		}
+
+		return &requeueAfterError{after: adoptionProbeInterval}
+	}
+
+	conditions.MarkTrue(mrc.tinkerbellMachine, infrastructurev1.HostReachableCondition)
+
+	return mrc.ensureAdoptedNodeProviderID(hardware, ip)
+}
+
+// ensureAdoptedNodeProviderID waits until the Node running on the adopted Hardware has the provider ID
+// of the machine, so Cluster API links the Machine to the Node. The Node is found by its provider ID or
+// by the Hardware IP address. Nodes without provider ID get the provider ID of the machine, while Nodes
+// with a different provider ID are reported, as the provider ID of a Node can not be changed.
+func (mrc *machineReconcileContext) ensureAdoptedNodeProviderID(hardware *tinkv1.Hardware, ip string) error {
+	providerID := mrc.tinkerbellMachine.Spec.ProviderID
+	cluster := client.ObjectKey{Namespace: mrc.machine.Namespace, Name: mrc.machine.Spec.ClusterName}
+
+	workloadClient, err := mrc.clusterClient(mrc.ctx, cluster)
+	if err != nil {
+		return fmt.Errorf("getting workload cluster client: %w", err)
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := workloadClient.List(mrc.ctx, nodes); err != nil {
+		return fmt.Errorf("listing workload cluster Nodes: %w", err)
+	}
+
+	node := adoptedNode(nodes.Items, providerID, ip)
+
+	switch {
+	case node == nil:
+		return mrc.waitForAdoptedNodeProviderID(infrastructurev1.NodeNotFoundReason,
+			"No Node with provider ID %s or address %s found on Hardware %s", providerID, ip, hardware.Name)
+	case node.Spec.ProviderID == "":
+		patchHelper, err := patch.NewHelper(node, workloadClient)
+		if err != nil {
+			return fmt.Errorf("initializing patch helper for Node %q: %w", node.Name, err)
+		}
+
+		node.Spec.ProviderID = providerID
+
+		if err := patchHelper.Patch(mrc.ctx, node); err != nil {
+			return fmt.Errorf("setting provider ID of Node %q: %w", node.Name, err)
+		}
+
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "NodeProviderIDSet",
+			"Set provider ID %s of Node %s", providerID, node.Name)
+	case node.Spec.ProviderID != providerID:
+		return mrc.waitForAdoptedNodeProviderID(infrastructurev1.ProviderIDMismatchReason,
+			"Node %s has provider ID %s instead of %s, set spec.providerID of the TinkerbellMachine to it",
+			node.Name, node.Spec.ProviderID, providerID)
+	}
+
+	conditions.MarkTrue(mrc.tinkerbellMachine, infrastructurev1.NodeProviderIDMatchesCondition)
+
+	return nil
+}
+
+// waitForAdoptedNodeProviderID reports the Node of the adopted Hardware can not be linked to the machine
+// and checks it again later.
+func (mrc *machineReconcileContext) waitForAdoptedNodeProviderID(
+	reason string,
+	messageFormat string,
+	args ...interface{},
+) error {
+	message := fmt.Sprintf(messageFormat, args...)
+
+	mrc.log.Info("Node of adopted host can not be linked, waiting", "reason", reason, "message", message)
+
+	if conditions.GetReason(mrc.tinkerbellMachine, infrastructurev1.NodeProviderIDMatchesCondition) != reason {
+		mrc.recorder.Event(mrc.tinkerbellMachine, corev1.EventTypeWarning, reason, message)
+	}
+
+	conditions.MarkFalse(mrc.tinkerbellMachine, infrastructurev1.NodeProviderIDMatchesCondition, reason,
+		clusterv1.ConditionSeverityWarning, "%s", message)
+
+	if err := mrc.patch(); err != nil {
+		return fmt.Errorf("patching TinkerbellMachine with Node provider ID condition: %w", err)
+	}
+
+	return &requeueAfterError{after: adoptionProbeInterval}
+}
+
+// adoptedNode returns the Node with the given provider ID or, if there is none, the Node with the given
+// internal IP address.
+func adoptedNode(nodes []corev1.Node, providerID, ip string) *corev1.Node {
+	for i := range nodes {
+		if nodes[i].Spec.ProviderID == providerID {
+			return &nodes[i]
+		}
+	}
+
+	for i := range nodes {
+		for _, address := range nodes[i].Status.Addresses {
+			if address.Type == corev1.NodeInternalIP && address.Address == ip {
+				return &nodes[i]
+			}
+		}
+	}
+
+	return nil
+}
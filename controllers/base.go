@@ -19,11 +19,13 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -32,6 +34,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/hosts"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/images"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
 	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 )
 
@@ -49,6 +54,14 @@ type baseMachineReconcileContext struct {
 	tinkerbellMachine *infrastructurev1.TinkerbellMachine
 	patchHelper       *patch.Helper
 	client            client.Client
+	imageResolver     ImageResolver
+	hostProber        HostProber
+	clusterClient     hosts.ClusterClientFunc
+	recorder          record.EventRecorder
+
+	quarantineThreshold            int
+	evictFromHardwareInMaintenance bool
+	maintenanceGracePeriod         time.Duration
 }
 
 // BaseMachineReconcileContext is an interface allowing basic machine reconciliation which
@@ -73,6 +86,9 @@ var (
 	ErrMissingBootstrapDataSecretValueKey = fmt.Errorf("retrieving bootstrap data: secret value key is missing")
 	// ErrBootstrapUserDataEmpty is the error returned when the referenced bootstrap data is empty.
 	ErrBootstrapUserDataEmpty = fmt.Errorf("received bootstrap user data is empty")
+	// ErrUnsupportedBootstrapDataFormat is the error returned when the Secret referenced for bootstrap data
+	// specifies a format, which is not supported.
+	ErrUnsupportedBootstrapDataFormat = fmt.Errorf("unsupported bootstrap data format")
 )
 
 // New builds a context for machine reconciliation process, collecting all required
@@ -95,6 +111,26 @@ func (tmr *TinkerbellMachineReconciler) newReconcileContext(ctx context.Context,
 		ctx:               ctx,
 		tinkerbellMachine: &infrastructurev1.TinkerbellMachine{},
 		client:            tmr.Client,
+		imageResolver:     tmr.ImageResolver,
+		hostProber:        tmr.HostProber,
+		clusterClient:     tmr.ClusterClient,
+		recorder:          eventRecorderOrDiscard(tmr.Recorder),
+
+		quarantineThreshold:            tmr.HardwareQuarantineThreshold,
+		evictFromHardwareInMaintenance: tmr.EvictFromHardwareInMaintenance,
+		maintenanceGracePeriod:         tmr.HardwareMaintenanceGracePeriod,
+	}
+
+	if bmrc.imageResolver == nil {
+		bmrc.imageResolver = images.NewResolver()
+	}
+
+	if bmrc.hostProber == nil {
+		bmrc.hostProber = hosts.NewProber()
+	}
+
+	if bmrc.clusterClient == nil {
+		bmrc.clusterClient = hosts.NewClusterClientFunc(tmr.Client)
 	}
 
 	if err := bmrc.client.Get(bmrc.ctx, namespacedName, bmrc.tinkerbellMachine); err != nil {
@@ -130,6 +166,16 @@ func (tmr *TinkerbellMachineReconciler) validate() error {
 	return nil
 }
 
+// eventRecorderOrDiscard returns given recorder or, if nil, a recorder discarding all events.
+func eventRecorderOrDiscard(recorder record.EventRecorder) record.EventRecorder {
+	if recorder == nil {
+		// FakeRecorder without events channel drops all events.
+		return &record.FakeRecorder{}
+	}
+
+	return recorder
+}
+
 // MachineScheduledForDeletion implements BaseMachineReconcileContext interface method
 // using TinkerbellMachine deletion timestamp.
 func (bmrc *baseMachineReconcileContext) MachineScheduledForDeletion() bool {
@@ -155,12 +201,19 @@ func (bmrc *baseMachineReconcileContext) releaseHardware() error {
 	delete(hardware.ObjectMeta.Labels, HardwareOwnerNameLabel)
 	delete(hardware.ObjectMeta.Labels, HardwareOwnerNamespaceLabel)
 
+	if err := bmrc.reserveHardware(hardware); err != nil {
+		return fmt.Errorf("reserving Hardware: %w", err)
+	}
+
 	controllerutil.RemoveFinalizer(hardware, infrastructurev1.MachineFinalizer)
 
 	if err := patchHelper.Patch(bmrc.ctx, hardware); err != nil {
 		return fmt.Errorf("patching Hardware object: %w", err)
 	}
 
+	bmrc.recorder.Eventf(bmrc.tinkerbellMachine, corev1.EventTypeNormal, "HardwareReleased",
+		"Released Hardware %s", hardware.Name)
+
 	return nil
 }
 
@@ -168,12 +221,21 @@ func (bmrc *baseMachineReconcileContext) releaseHardware() error {
 func (bmrc *baseMachineReconcileContext) DeleteMachineWithDependencies() error {
 	bmrc.log.Info("Removing machine", "hardwareName", bmrc.tinkerbellMachine.Spec.HardwareName)
 
-	if err := bmrc.removeTemplate(); err != nil {
-		return fmt.Errorf("removing Template: %w", err)
+	names := []string{
+		bmrc.tinkerbellMachine.Name,
+		bootWorkflowName(bmrc.tinkerbellMachine),
+		rebootWorkflowName(bmrc.tinkerbellMachine),
+		reprovisionWorkflowName(bmrc.tinkerbellMachine),
 	}
 
-	if err := bmrc.removeWorkflow(); err != nil {
-		return fmt.Errorf("removing Workflow: %w", err)
+	for _, name := range names {
+		if err := bmrc.removeTemplate(name); err != nil {
+			return fmt.Errorf("removing Template: %w", err)
+		}
+
+		if err := bmrc.removeWorkflow(name); err != nil {
+			return fmt.Errorf("removing Workflow: %w", err)
+		}
 	}
 
 	if err := bmrc.releaseHardware(); err != nil {
@@ -190,7 +252,17 @@ func (bmrc *baseMachineReconcileContext) DeleteMachineWithDependencies() error {
 // IntoMachineReconcileContext implements BaseMachineReconcileContext by building MachineReconcileContext
 // from existing fields.
 func (bmrc *baseMachineReconcileContext) IntoMachineReconcileContext() (ReconcileContext, error) {
-	machine, err := bmrc.getReadyMachine()
+	pool, err := getTinkerbellMachinePool(bmrc.ctx, bmrc.client, bmrc.tinkerbellMachine)
+	if err != nil {
+		return nil, fmt.Errorf("getting TinkerbellMachinePool: %w", err)
+	}
+
+	hardwareSelector, err := machinePoolHardwareSelector(pool)
+	if err != nil {
+		return nil, err
+	}
+
+	machine, err := bmrc.getReadyMachine(pool)
 	if err != nil {
 		return nil, fmt.Errorf("getting valid Machine object: %w", err)
 	}
@@ -199,9 +271,9 @@ func (bmrc *baseMachineReconcileContext) IntoMachineReconcileContext() (Reconcil
 		return nil, nil
 	}
 
-	bootstrapCloudConfig, err := bmrc.getReadyBootstrapCloudConfig(machine)
+	bootstrapData, bootstrapFormat, err := bmrc.getReadyBootstrapData(machine)
 	if err != nil {
-		return nil, fmt.Errorf("receiving bootstrap cloud config: %w", err)
+		return nil, fmt.Errorf("receiving bootstrap data: %w", err)
 	}
 
 	tinkerbellCluster, err := bmrc.getReadyTinkerbellCluster(machine)
@@ -219,7 +291,9 @@ func (bmrc *baseMachineReconcileContext) IntoMachineReconcileContext() (Reconcil
 		baseMachineReconcileContext: bmrc,
 		machine:                     machine,
 		tinkerbellCluster:           tinkerbellCluster,
-		bootstrapCloudConfig:        bootstrapCloudConfig,
+		bootstrapData:               bootstrapData,
+		bootstrapFormat:             bootstrapFormat,
+		hardwareSelector:            hardwareSelector,
 	}, nil
 }
 
@@ -229,11 +303,11 @@ func (bmrc *baseMachineReconcileContext) Log() logr.Logger {
 	return bmrc.log
 }
 
-// removeTemplate makes sure template for TinkerbellMachine has been cleaned up.
-func (bmrc *baseMachineReconcileContext) removeTemplate() error {
+// removeTemplate makes sure template with given name for TinkerbellMachine has been cleaned up.
+func (bmrc *baseMachineReconcileContext) removeTemplate(name string) error {
+	// Templates and Workflows are cluster-scoped.
 	namespacedName := types.NamespacedName{
-		Name:      bmrc.tinkerbellMachine.Name,
-		Namespace: bmrc.tinkerbellMachine.Namespace,
+		Name: name,
 	}
 
 	template := &tinkv1.Template{}
@@ -258,11 +332,11 @@ func (bmrc *baseMachineReconcileContext) removeTemplate() error {
 	return nil
 }
 
-// removeWorkflow makes sure workflow for TinkerbellMachine has been cleaned up.
-func (bmrc *baseMachineReconcileContext) removeWorkflow() error {
+// removeWorkflow makes sure workflow with given name for TinkerbellMachine has been cleaned up.
+func (bmrc *baseMachineReconcileContext) removeWorkflow(name string) error {
+	// Templates and Workflows are cluster-scoped.
 	namespacedName := types.NamespacedName{
-		Name:      bmrc.tinkerbellMachine.Name,
-		Namespace: bmrc.tinkerbellMachine.Namespace,
+		Name: name,
 	}
 
 	workflow := &tinkv1.Workflow{}
@@ -298,12 +372,19 @@ func (bmrc *baseMachineReconcileContext) patch() error {
 	return nil
 }
 
-// getReadyMachine returns valid ClusterAPI Machine object.
+// getReadyMachine returns valid ClusterAPI Machine object. For members of given machine pool,
+// the Machine is built from the MachinePool.
 //
 // If error occurs while fetching the machine, error is returned.
 //
 // If machine is not ready yet, nil is returned.
-func (bmrc *baseMachineReconcileContext) getReadyMachine() (*clusterv1.Machine, error) {
+func (bmrc *baseMachineReconcileContext) getReadyMachine(
+	pool *infrastructurev1.TinkerbellMachinePool,
+) (*clusterv1.Machine, error) {
+	if pool != nil {
+		return bmrc.getReadyMachinePoolMachine(pool)
+	}
+
 	// Continue building the context with some validation rules.
 	machine, err := util.GetOwnerMachine(bmrc.ctx, bmrc.client, bmrc.tinkerbellMachine.ObjectMeta)
 	if err != nil {
@@ -347,27 +428,38 @@ func isMachineReady(machine *clusterv1.Machine) (string, error) {
 	return "", nil
 }
 
-// getReadyBootstrapCloudConfig returns initialized bootstrap cloud config for a given machine.
+// getReadyBootstrapData returns initialized bootstrap data for a given machine together with
+// its format. If the bootstrap data Secret does not specify the format, cloud-config is assumed.
 //
-// If bootstrap cloud config is not yet initialized, empty string is returned.
-func (bmrc *baseMachineReconcileContext) getReadyBootstrapCloudConfig(machine *clusterv1.Machine) (string, error) {
+//nolint:lll
+func (bmrc *baseMachineReconcileContext) getReadyBootstrapData(machine *clusterv1.Machine) (string, templates.BootstrapFormat, error) {
 	secret := &corev1.Secret{}
 	key := types.NamespacedName{Namespace: machine.Namespace, Name: *machine.Spec.Bootstrap.DataSecretName}
 
 	if err := bmrc.client.Get(bmrc.ctx, key, secret); err != nil {
-		return "", fmt.Errorf("retrieving bootstrap data secret: %w", err)
+		return "", "", fmt.Errorf("retrieving bootstrap data secret: %w", err)
 	}
 
 	bootstrapUserData, ok := secret.Data["value"]
 	if !ok {
-		return "", ErrMissingBootstrapDataSecretValueKey
+		return "", "", ErrMissingBootstrapDataSecretValueKey
 	}
 
 	if len(bootstrapUserData) == 0 {
-		return "", ErrBootstrapUserDataEmpty
+		return "", "", ErrBootstrapUserDataEmpty
+	}
+
+	format := templates.BootstrapFormat(secret.Data["format"])
+
+	switch format {
+	case "":
+		format = templates.BootstrapFormatCloudConfig
+	case templates.BootstrapFormatCloudConfig, templates.BootstrapFormatIgnition:
+	default:
+		return "", "", fmt.Errorf("%w: %q", ErrUnsupportedBootstrapDataFormat, format)
 	}
 
-	return string(bootstrapUserData), nil
+	return string(bootstrapUserData), format, nil
 }
 
 // getTinkerbellCluster returns associated TinkerbellCluster object for a given machine.
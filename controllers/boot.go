@@ -0,0 +1,324 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// bootProbeInterval is the interval of checking reachability of the node booting after provisioning.
+const bootProbeInterval = 30 * time.Second
+
+// waitsForBoot reports whether the machine becomes ready only once the node booted after the provisioning
+// workflow is reachable. Waiting is opt-in by setting the boot timeout, as the management cluster may
+// not be able to reach the nodes. Otherwise the machine becomes ready once the workflow hands over.
+func (mrc *machineReconcileContext) waitsForBoot() bool {
+	return mrc.tinkerbellMachine.Spec.BootTimeout != nil
+}
+
+// waitForBoot keeps the machine not ready while it is being provisioned, when the machine booting from the
+// disk becomes ready only once the node boots after the workflow.
+func (mrc *machineReconcileContext) waitForBoot() error {
+	if mrc.waitsForBoot() && !mrc.tinkerbellMachine.Status.Ready {
+		return &requeueAfterError{after: bootProbeInterval}
+	}
+
+	return nil
+}
+
+// workflowHandedOver reports whether the boot workflow started the action rebooting or powering off the
+// machine. The machine goes down before the worker reports the action, so the workflow never succeeds.
+func workflowHandedOver(workflow *tinkv1.Workflow) bool {
+	return actionStarted(workflow, templates.RebootActionName) || actionStarted(workflow, templates.PowerOffActionName)
+}
+
+// actionStarted reports whether the workflow started the action with the given name.
+func actionStarted(workflow *tinkv1.Workflow, name string) bool {
+	for _, event := range workflow.Status.Events {
+		if event.ActionName != name {
+			continue
+		}
+
+		if event.ActionStatus == tinkv1.WorkflowStateRunning || event.ActionStatus == tinkv1.WorkflowStateSuccess {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bootWorkflowName returns the name of the Template and Workflow restarting given machine into the
+// installed OS.
+func bootWorkflowName(tinkerbellMachine *infrastructurev1.TinkerbellMachine) string {
+	return tinkerbellMachine.Name + "-boot"
+}
+
+// bootMode returns how the machine boots into the installed OS after the provisioning workflow.
+func (mrc *machineReconcileContext) bootMode() templates.BootMode {
+	workflowTemplate := templates.WorkflowTemplate{
+		BootstrapFormat: mrc.bootstrapFormat,
+		BootMode:        templates.BootMode(mrc.tinkerbellMachine.Spec.BootMode),
+	}
+
+	return workflowTemplate.EffectiveBootMode()
+}
+
+// ensureBootWorkflow restarts the machine into the installed OS once the provisioning workflow succeeded,
+// unless it kexecs into it. Netboot is disabled first, while the Hardware is still allowed to run
+// workflows, and the boot workflow is created only once Tinkerbell no longer allows the machine to netboot,
+// so the restarted machine boots from the disk instead of re-entering the installation environment. The
+// installation environment keeps polling for workflows and runs it. It returns true once the boot workflow
+// started restarting the machine.
+func (mrc *machineReconcileContext) ensureBootWorkflow(hardware *tinkv1.Hardware) (bool, error) {
+	if mrc.bootMode() == templates.BootModeKexec {
+		return true, nil
+	}
+
+	name := bootWorkflowName(mrc.tinkerbellMachine)
+
+	workflow, err := mrc.getWorkflow(name, hardware.Name)
+	if err != nil {
+		return false, fmt.Errorf("getting boot Workflow: %w", err)
+	}
+
+	switch {
+	case workflow == nil:
+		if err := mrc.disableHardwarePXE(hardware); err != nil {
+			return false, fmt.Errorf("disabling netboot: %w", err)
+		}
+
+		if !hardwareNetbootDisabled(hardware) {
+			mrc.log.Info("Waiting for disabled netboot to be propagated into Tinkerbell")
+
+			return false, nil
+		}
+
+		created, err := mrc.ensureBootTemplate(name)
+		if err != nil || !created {
+			return false, err
+		}
+
+		return false, mrc.createWorkflow(name, hardware.Name, nil)
+	case !workflow.DeletionTimestamp.IsZero():
+		mrc.log.Info("Boot Workflow of previous provisioning is being removed, waiting")
+
+		return false, nil
+	case workflowFailed(workflow):
+		return false, mrc.recordProvisioningFailure(hardware, workflow)
+	}
+
+	return workflowHandedOver(workflow), nil
+}
+
+// ensureBootTemplate creates the Template restarting the machine into the installed OS, unless it already
+// exists. It returns false while the Template of the previous provisioning is being removed.
+func (mrc *machineReconcileContext) ensureBootTemplate(name string) (bool, error) {
+	existing := &tinkv1.Template{}
+
+	err := mrc.client.Get(mrc.ctx, types.NamespacedName{Name: name}, existing)
+	if err == nil {
+		return existing.DeletionTimestamp.IsZero(), nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("getting boot Template: %w", err)
+	}
+
+	bootTemplate := templates.BootWorkflowTemplate{
+		Name:     name,
+		BootMode: mrc.bootMode(),
+	}
+
+	templateData, err := bootTemplate.Render()
+	if err != nil {
+		return false, fmt.Errorf("rendering boot template: %w", err)
+	}
+
+	templateObject := &tinkv1.Template{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1",
+					Kind:       "TinkerbellMachine",
+					Name:       mrc.tinkerbellMachine.Name,
+					UID:        mrc.tinkerbellMachine.ObjectMeta.UID,
+				},
+			},
+		},
+		Spec: tinkv1.TemplateSpec{
+			Data: &templateData,
+		},
+	}
+
+	if err := mrc.client.Create(mrc.ctx, templateObject); err != nil {
+		return false, fmt.Errorf("creating boot template: %w", err)
+	}
+
+	return true, nil
+}
+
+// removeBootWorkflow removes the boot workflow and its template, so the machine is restarted again after
+// it gets reprovisioned.
+func (mrc *machineReconcileContext) removeBootWorkflow() error {
+	name := bootWorkflowName(mrc.tinkerbellMachine)
+
+	if err := mrc.removeWorkflow(name); err != nil {
+		return fmt.Errorf("removing boot Workflow: %w", err)
+	}
+
+	if err := mrc.removeTemplate(name); err != nil {
+		return fmt.Errorf("removing boot Template: %w", err)
+	}
+
+	return nil
+}
+
+// disableHardwarePXE disables netboot of the Hardware, while still allowing it to run workflows, so the
+// installation environment picks up the boot workflow.
+func (mrc *machineReconcileContext) disableHardwarePXE(hardware *tinkv1.Hardware) error {
+	if hardware.Spec.AllowPXE != nil && !*hardware.Spec.AllowPXE {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(hardware, mrc.client)
+	if err != nil {
+		return fmt.Errorf("initializing patch helper for selected hardware: %w", err)
+	}
+
+	hardware.Spec.AllowPXE = pointer.BoolPtr(false)
+
+	if err := patchHelper.Patch(mrc.ctx, hardware); err != nil {
+		return fmt.Errorf("patching Hardware object: %w", err)
+	}
+
+	mrc.log.Info("Disabled Hardware netboot before restarting into installed OS", "Hardware name", hardware.Name)
+
+	return nil
+}
+
+// hardwareNetbootDisabled reports whether Tinkerbell disallows all interfaces of the hardware to netboot.
+func hardwareNetbootDisabled(hardware *tinkv1.Hardware) bool {
+	if len(hardware.Status.Interfaces) == 0 {
+		return false
+	}
+
+	for _, iface := range hardware.Status.Interfaces {
+		if iface.Netboot == nil || iface.Netboot.AllowPXE == nil || *iface.Netboot.AllowPXE {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hostReachable reports whether the host running on the hardware is reachable.
+func (mrc *machineReconcileContext) hostReachable(hardware *tinkv1.Hardware) (bool, error) {
+	ip, err := hardwareIP(hardware)
+	if err != nil {
+		return false, fmt.Errorf("extracting Hardware IP address: %w", err)
+	}
+
+	reachable, err := mrc.hostProber.Reachable(mrc.ctx, ip)
+	if err != nil {
+		return false, fmt.Errorf("probing host %q: %w", ip, err)
+	}
+
+	return reachable, nil
+}
+
+// ensureBooted waits until the node booted from the disk after the provisioning workflow is reachable.
+// If it does not become reachable within the boot timeout, it is reported by the host reachability
+// condition and the node is still probed, as the reachability probe alone does not make the machine
+// failed. Once the machine is ready, the node is no longer probed.
+func (mrc *machineReconcileContext) ensureBooted(hardware *tinkv1.Hardware) error {
+	if !mrc.waitsForBoot() || mrc.tinkerbellMachine.Status.Ready {
+		return nil
+	}
+
+	ip, err := hardwareIP(hardware)
+	if err != nil {
+		return fmt.Errorf("extracting Hardware IP address: %w", err)
+	}
+
+	reachable, err := mrc.hostProber.Reachable(mrc.ctx, ip)
+	if err != nil {
+		return fmt.Errorf("probing host %q: %w", ip, err)
+	}
+
+	if reachable {
+		conditions.MarkTrue(mrc.tinkerbellMachine, infrastructurev1.HostReachableCondition)
+
+		return nil
+	}
+
+	switch conditions.GetReason(mrc.tinkerbellMachine, infrastructurev1.HostReachableCondition) {
+	case infrastructurev1.BootTimedOutReason:
+		return &requeueAfterError{after: bootProbeInterval}
+	case infrastructurev1.WaitingForBootReason:
+		since := conditions.GetLastTransitionTime(mrc.tinkerbellMachine, infrastructurev1.HostReachableCondition)
+		if since != nil && time.Since(since.Time) < mrc.tinkerbellMachine.Spec.BootTimeout.Duration {
+			return &requeueAfterError{after: bootProbeInterval}
+		}
+
+		return mrc.markBootTimedOut(hardware, ip)
+	}
+
+	mrc.log.Info("Waiting for host to boot", "address", ip)
+
+	conditions.MarkFalse(mrc.tinkerbellMachine, infrastructurev1.HostReachableCondition,
+		infrastructurev1.WaitingForBootReason, clusterv1.ConditionSeverityInfo,
+		"Waiting for host %s to boot", ip)
+
+	if err := mrc.patch(); err != nil {
+		return fmt.Errorf("patching TinkerbellMachine with host reachability condition: %w", err)
+	}
+
+	return &requeueAfterError{after: bootProbeInterval}
+}
+
+// markBootTimedOut reports the node did not boot within the boot timeout. The host keeps being probed,
+// so the machine becomes ready if the node eventually boots.
+func (mrc *machineReconcileContext) markBootTimedOut(hardware *tinkv1.Hardware, ip string) error {
+	message := fmt.Sprintf("Host %s on Hardware %s did not become reachable within %s after provisioning",
+		ip, hardware.Name, mrc.tinkerbellMachine.Spec.BootTimeout.Duration)
+
+	conditions.MarkFalse(mrc.tinkerbellMachine, infrastructurev1.HostReachableCondition,
+		infrastructurev1.BootTimedOutReason, clusterv1.ConditionSeverityWarning, "%s", message)
+
+	mrc.recorder.Event(mrc.tinkerbellMachine, corev1.EventTypeWarning, infrastructurev1.BootTimedOutReason, message)
+
+	if err := mrc.patch(); err != nil {
+		return fmt.Errorf("patching TinkerbellMachine with host reachability condition: %w", err)
+	}
+
+	return &requeueAfterError{after: bootProbeInterval}
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+const (
+	dataURLPrefix       = "data:"
+	dataURLBase64Suffix = ";base64"
+)
+
+// ErrInvalidNodeLabelsOrTaints is returned when Node labels or taints taken from the Hardware are invalid.
+var ErrInvalidNodeLabelsOrTaints = fmt.Errorf("invalid Node labels or taints from Hardware")
+
+// userDataWithPlaceholders returns bootstrap data with placeholders substituted with their values
+// according to the bootstrap data format.
+func userDataWithPlaceholders(format templates.BootstrapFormat, data string, placeholders map[string]string) (string, error) { //nolint:lll
+	replacer := placeholdersReplacer(placeholders)
+
+	switch format {
+	case templates.BootstrapFormatIgnition:
+		return ignitionWithPlaceholders(data, replacer)
+	default:
+		return replacer.Replace(data), nil
+	}
+}
+
+// placeholdersReplacer returns a replacer substituting given placeholders. Placeholders are sorted
+// so the substitution does not depend on the map iteration order.
+func placeholdersReplacer(placeholders map[string]string) *placeholderReplacer {
+	names := make([]string, 0, len(placeholders))
+
+	for name := range placeholders {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	oldnew := make([]string, 0, 2*len(names)) //nolint:gomnd
+
+	for _, name := range names {
+		oldnew = append(oldnew, name, placeholders[name])
+	}
+
+	return &placeholderReplacer{Replacer: strings.NewReplacer(oldnew...), names: names}
+}
+
+// placeholderReplacer substitutes placeholders in bootstrap data.
+type placeholderReplacer struct {
+	*strings.Replacer
+	names []string
+}
+
+// contains reports whether the data contains any of the placeholders.
+func (r *placeholderReplacer) contains(data string) bool {
+	for _, name := range r.names {
+		if strings.Contains(data, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ignitionWithPlaceholders substitutes placeholders in Ignition config. Contents of files are stored
+// as data URLs, which may be base64 or percent encoded, so they are decoded before substitution and
+// encoded back afterwards. Remaining occurrences, e.g. in systemd units, are substituted directly.
+func ignitionWithPlaceholders(data string, replacer *placeholderReplacer) (string, error) {
+	config := map[string]interface{}{}
+
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return "", fmt.Errorf("parsing Ignition config: %w", err)
+	}
+
+	storage, _ := config["storage"].(map[string]interface{})
+	files, _ := storage["files"].([]interface{})
+
+	for _, f := range files {
+		file, _ := f.(map[string]interface{})
+		contents, _ := file["contents"].(map[string]interface{})
+
+		// Compressed contents can't be substituted.
+		if compression, _ := contents["compression"].(string); compression != "" {
+			continue
+		}
+
+		source, ok := contents["source"].(string)
+		if !ok || !strings.HasPrefix(source, dataURLPrefix) {
+			continue
+		}
+
+		substituted, err := dataURLWithPlaceholders(source, replacer)
+		if err != nil {
+			return "", fmt.Errorf("substituting placeholders in file %v: %w", file["path"], err)
+		}
+
+		contents["source"] = substituted
+	}
+
+	buf := &bytes.Buffer{}
+
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(config); err != nil {
+		return "", fmt.Errorf("serializing Ignition config: %w", err)
+	}
+
+	return replacer.Replace(strings.TrimSuffix(buf.String(), "\n")), nil
+}
+
+func dataURLWithPlaceholders(source string, replacer *placeholderReplacer) (string, error) {
+	separator := strings.Index(source, ",")
+	if separator < 0 {
+		return "", fmt.Errorf("malformed data URL %q", source) //nolint:goerr113
+	}
+
+	mediaType, payload := source[:separator], source[separator+1:]
+
+	if strings.HasSuffix(mediaType, dataURLBase64Suffix) {
+		decoded, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return "", fmt.Errorf("decoding base64 data URL: %w", err)
+		}
+
+		if !replacer.contains(string(decoded)) {
+			return source, nil
+		}
+
+		substituted := replacer.Replace(string(decoded))
+
+		return mediaType + "," + base64.StdEncoding.EncodeToString([]byte(substituted)), nil
+	}
+
+	decoded, err := url.PathUnescape(payload)
+	if err != nil {
+		return "", fmt.Errorf("decoding data URL: %w", err)
+	}
+
+	if !replacer.contains(decoded) {
+		return source, nil
+	}
+
+	substituted := replacer.Replace(decoded)
+
+	return mediaType + "," + url.PathEscape(substituted), nil
+}
+
+// nodeLabelsFromHardware returns Node labels in kubelet node-labels format with values of the Hardware labels
+// or annotations with given keys. Invalid labels are reported, as kubelet would fail to register the Node.
+func nodeLabelsFromHardware(hardware *tinkv1.Hardware, keys []string) (string, error) {
+	labels := []string{}
+
+	for _, key := range keys {
+		value, ok := hardwareLabelOrAnnotation(hardware, key)
+		if !ok {
+			continue
+		}
+
+		errs := append(validation.IsQualifiedName(key), validation.IsValidLabelValue(value)...)
+		if len(errs) > 0 {
+			return "", fmt.Errorf("%w: label %q: %s", ErrInvalidNodeLabelsOrTaints, key+"="+value,
+				strings.Join(errs, ", "))
+		}
+
+		labels = append(labels, key+"="+value)
+	}
+
+	return strings.Join(labels, ","), nil
+}
+
+// nodeTaintsFromHardware returns Node taints in kubelet register-with-taints format with values of the Hardware
+// labels or annotations with the taint keys. Invalid taints are reported, as kubelet would fail to register the
+// Node.
+func nodeTaintsFromHardware(hardware *tinkv1.Hardware, taints []infrastructurev1.NodeTaintFromHardware) (string, error) { //nolint:lll
+	result := []string{}
+
+	for _, taint := range taints {
+		value, ok := hardwareLabelOrAnnotation(hardware, taint.Key)
+		if !ok {
+			continue
+		}
+
+		errs := append(validation.IsQualifiedName(taint.Key), validation.IsValidLabelValue(value)...)
+
+		switch taint.Effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			errs = append(errs, fmt.Sprintf("unsupported effect %q", taint.Effect))
+		}
+
+		if len(errs) > 0 {
+			return "", fmt.Errorf("%w: taint %q: %s", ErrInvalidNodeLabelsOrTaints,
+				fmt.Sprintf("%s=%s:%s", taint.Key, value, taint.Effect), strings.Join(errs, ", "))
+		}
+
+		result = append(result, fmt.Sprintf("%s=%s:%s", taint.Key, value, taint.Effect))
+	}
+
+	return strings.Join(result, ","), nil
+}
+
+func hardwareLabelOrAnnotation(hardware *tinkv1.Hardware, key string) (string, bool) {
+	if value, ok := hardware.Labels[key]; ok {
+		return value, true
+	}
+
+	value, ok := hardware.Annotations[key]
+
+	return value, ok
+}
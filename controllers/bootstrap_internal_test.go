@@ -0,0 +1,180 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+//nolint:funlen
+func Test_User_data_with_provider_ID(t *testing.T) {
+	t.Parallel()
+
+	providerID := "tinkerbell://foo"
+	encodedKubeletConfig := base64.StdEncoding.EncodeToString([]byte("provider-id: PROVIDER_ID"))
+
+	cases := map[string]struct {
+		format      templates.BootstrapFormat
+		data        string
+		expectError bool
+		expected    string
+	}{
+		"substitutes_placeholder_in_cloud_config": {
+			format:   templates.BootstrapFormatCloudConfig,
+			data:     "provider-id: PROVIDER_ID",
+			expected: "provider-id: tinkerbell://foo",
+		},
+		"substitutes_placeholder_in_percent_encoded_ignition_file": {
+			format:   templates.BootstrapFormatIgnition,
+			data:     `{"storage":{"files":[{"path":"/etc/kubeadm.yml","contents":{"source":"data:,provider-id%3A%20PROVIDER_ID"}}]}}`,        //nolint:lll
+			expected: `{"storage":{"files":[{"contents":{"source":"data:,provider-id:%20tinkerbell:%2F%2Ffoo"},"path":"/etc/kubeadm.yml"}]}}`, //nolint:lll
+		},
+		"substitutes_placeholder_in_base64_encoded_ignition_file": {
+			format: templates.BootstrapFormatIgnition,
+			data:   `{"storage":{"files":[{"contents":{"source":"data:;base64,` + encodedKubeletConfig + `"}}]}}`,
+			expected: `{"storage":{"files":[{"contents":{"source":"data:;base64,` +
+				base64.StdEncoding.EncodeToString([]byte("provider-id: tinkerbell://foo")) + `"}}]}}`,
+		},
+		"substitutes_placeholder_in_ignition_systemd_units": {
+			format:   templates.BootstrapFormatIgnition,
+			data:     `{"systemd":{"units":[{"contents":"--provider-id=PROVIDER_ID"}]}}`,
+			expected: `{"systemd":{"units":[{"contents":"--provider-id=tinkerbell://foo"}]}}`,
+		},
+		"fails_when_ignition_config_is_not_valid_JSON": {
+			format:      templates.BootstrapFormatIgnition,
+			data:        "#cloud-config",
+			expectError: true,
+		},
+	}
+
+	for name, c := range cases { //nolint:paralleltest
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			userData, err := userDataWithPlaceholders(c.format, c.data, map[string]string{providerIDPlaceholder: providerID})
+			if c.expectError {
+				g.Expect(err).To(HaveOccurred())
+
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(userData).To(Equal(c.expected))
+		})
+	}
+}
+
+func Test_User_data_with_node_labels_and_taints_from_hardware(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardware := &tinkv1.Hardware{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"rack":    "r1",
+				"chassis": "c1",
+			},
+			Annotations: map[string]string{
+				"rack":           "ignored",
+				"hardware-class": "gpu",
+			},
+		},
+	}
+
+	labels, err := nodeLabelsFromHardware(hardware, []string{"rack", "hardware-class", "missing"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(labels).To(Equal("rack=r1,hardware-class=gpu"), "Expected labels to take precedence over annotations")
+
+	taints, err := nodeTaintsFromHardware(hardware, []infrastructurev1.NodeTaintFromHardware{
+		{Key: "hardware-class", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "missing", Effect: corev1.TaintEffectNoExecute},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(taints).To(Equal("hardware-class=gpu:NoSchedule"))
+
+	userData, err := userDataWithPlaceholders(templates.BootstrapFormatCloudConfig,
+		"provider-id: PROVIDER_ID\nnode-labels: NODE_LABELS\nregister-with-taints: NODE_TAINTS",
+		map[string]string{
+			providerIDPlaceholder: "tinkerbell://foo",
+			nodeLabelsPlaceholder: labels,
+			nodeTaintsPlaceholder: taints,
+		})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(userData).To(Equal("provider-id: tinkerbell://foo\n" +
+		"node-labels: rack=r1,hardware-class=gpu\n" +
+		"register-with-taints: hardware-class=gpu:NoSchedule"))
+}
+
+func Test_Invalid_node_labels_and_taints_from_hardware(t *testing.T) {
+	t.Parallel()
+
+	hardware := &tinkv1.Hardware{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				"hardware-class": "gpu with spaces",
+				"bad key!":       "value",
+				"rack":           "r1",
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		labels []string
+		taints []infrastructurev1.NodeTaintFromHardware
+	}{
+		"label_with_invalid_value": {labels: []string{"hardware-class"}},
+		"label_with_invalid_key":   {labels: []string{"bad key!"}},
+		"taint_with_invalid_value": {
+			taints: []infrastructurev1.NodeTaintFromHardware{{Key: "hardware-class", Effect: corev1.TaintEffectNoSchedule}},
+		},
+		"taint_with_invalid_effect": {
+			taints: []infrastructurev1.NodeTaintFromHardware{{Key: "rack", Effect: "NoWay"}},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			_, labelsErr := nodeLabelsFromHardware(hardware, c.labels)
+			_, taintsErr := nodeTaintsFromHardware(hardware, c.taints)
+
+			if c.labels != nil {
+				g.Expect(labelsErr).To(MatchError(ErrInvalidNodeLabelsOrTaints))
+			}
+
+			if c.taints != nil {
+				g.Expect(taintsErr).To(MatchError(ErrInvalidNodeLabelsOrTaints))
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
+)
+
+// applyDefaultUser configures the workflow template with the default user of the installed OS configured
+// for the machine or, if not set, for the cluster.
+func (mrc *machineReconcileContext) applyDefaultUser(workflowTemplate *templates.WorkflowTemplate) error {
+	config := mrc.tinkerbellMachine.Spec.DefaultUser
+	if config == nil {
+		config = mrc.tinkerbellCluster.Spec.DefaultUser
+	}
+
+	if config == nil {
+		return nil
+	}
+
+	if config.Disabled {
+		workflowTemplate.DisableDefaultUser = true
+
+		return nil
+	}
+
+	user := templates.DefaultUser()
+
+	if config.Name != "" {
+		user.Name = config.Name
+	}
+
+	if len(config.Groups) > 0 {
+		user.Groups = config.Groups
+	}
+
+	switch config.Sudo {
+	case infrastructurev1.SudoPolicyPassword:
+		user.Sudo = templates.SudoPassword
+	case infrastructurev1.SudoPolicyNone:
+		user.Sudo = ""
+	}
+
+	if config.SSHAuthorizedKeysFrom != nil {
+		keys, err := mrc.sshAuthorizedKeys(config.SSHAuthorizedKeysFrom)
+		if err != nil {
+			return fmt.Errorf("getting SSH authorized keys: %w", err)
+		}
+
+		user.SSHAuthorizedKeys = keys
+	}
+
+	workflowTemplate.DefaultUser = &user
+
+	return nil
+}
+
+// sshAuthorizedKeys returns the SSH public keys read from the referenced Secret or ConfigMap in the
+// authorized_keys format. Missing optional references result in no keys.
+func (mrc *machineReconcileContext) sshAuthorizedKeys(
+	source *infrastructurev1.SSHAuthorizedKeysSource,
+) ([]string, error) {
+	var (
+		data string
+		err  error
+	)
+
+	switch {
+	case source.SecretKeyRef != nil:
+		data, _, err = mrc.secretKeyValue(source.SecretKeyRef)
+	case source.ConfigMapKeyRef != nil:
+		data, _, err = mrc.configMapKeyValue(source.ConfigMapKeyRef)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAuthorizedKeys(data), nil
+}
+
+// parseAuthorizedKeys returns the keys of the authorized_keys file skipping empty lines and comments.
+func parseAuthorizedKeys(data string) []string {
+	keys := []string{}
+
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keys = append(keys, line)
+	}
+
+	return keys
+}
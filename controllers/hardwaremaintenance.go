@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// HardwareMaintenanceAnnotation can be set on Hardware to take it out of the pool, e.g. for firmware
+// upgrades or repairs. The value may describe the reason. Hardware in maintenance is not selected for
+// machines and, if enabled, machines already running on it are evicted after a grace period.
+const HardwareMaintenanceAnnotation = "v1alpha1.tinkerbell.org/maintenance"
+
+// ErrHardwareInMaintenance is returned when the Hardware selected for a machine, which has not been
+// provisioned yet, is in maintenance. The machine is provisioned once the maintenance is over.
+var ErrHardwareInMaintenance = fmt.Errorf("hardware is in maintenance")
+
+// requeueAfterError is returned when the reconciliation should be retried after given duration.
+type requeueAfterError struct {
+	after time.Duration
+}
+
+func (e *requeueAfterError) Error() string {
+	return fmt.Sprintf("requeue in %s", e.after)
+}
+
+// hardwareInMaintenance reports whether the Hardware is annotated for maintenance.
+func hardwareInMaintenance(hardware *tinkv1.Hardware) bool {
+	_, ok := hardware.Annotations[HardwareMaintenanceAnnotation]
+
+	return ok
+}
+
+// hardwareNotInMaintenance selects Hardware, which is not in maintenance.
+func hardwareNotInMaintenance(hardware *tinkv1.Hardware) bool {
+	return !hardwareInMaintenance(hardware)
+}
+
+// ensureHardwareInService reports Hardware maintenance in the HardwareInService condition and, if
+// enabled, deletes the Machine running on the Hardware once the grace period elapses, so it gets
+// replaced by its MachineSet or control plane on other Hardware. Machines not provisioned yet are not
+// provisioned on Hardware in maintenance, regardless of eviction.
+func (mrc *machineReconcileContext) ensureHardwareInService(hardware *tinkv1.Hardware) error {
+	if !hardwareInMaintenance(hardware) {
+		if conditions.IsFalse(mrc.tinkerbellMachine, infrastructurev1.HardwareInServiceCondition) {
+			conditions.MarkTrue(mrc.tinkerbellMachine, infrastructurev1.HardwareInServiceCondition)
+			mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "HardwareInService",
+				"Hardware %s is no longer in maintenance", hardware.Name)
+		}
+
+		return nil
+	}
+
+	if !conditions.IsFalse(mrc.tinkerbellMachine, infrastructurev1.HardwareInServiceCondition) {
+		conditions.MarkFalse(mrc.tinkerbellMachine, infrastructurev1.HardwareInServiceCondition,
+			infrastructurev1.HardwareMaintenanceReason, clusterv1.ConditionSeverityWarning,
+			"Hardware is in maintenance: %s", hardware.Annotations[HardwareMaintenanceAnnotation])
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeWarning, infrastructurev1.HardwareMaintenanceReason,
+			"Hardware %s is in maintenance", hardware.Name)
+
+		if err := mrc.patch(); err != nil {
+			return fmt.Errorf("patching machine with hardware maintenance condition: %w", err)
+		}
+	}
+
+	if !mrc.tinkerbellMachine.Status.Ready {
+		return ErrHardwareInMaintenance
+	}
+
+	if !mrc.evictFromHardwareInMaintenance || !mrc.machine.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	// Machines without a controller would not be replaced.
+	if metav1.GetControllerOf(mrc.machine) == nil {
+		mrc.log.Info("Not evicting Machine without controller from Hardware in maintenance", "Machine", mrc.machine.Name)
+
+		return nil
+	}
+
+	since := conditions.GetLastTransitionTime(mrc.tinkerbellMachine, infrastructurev1.HardwareInServiceCondition)
+
+	if remaining := mrc.maintenanceGracePeriod - time.Since(since.Time); remaining > 0 {
+		return &requeueAfterError{after: remaining}
+	}
+
+	mrc.log.Info("Evicting Machine from Hardware in maintenance",
+		"Machine", mrc.machine.Name, "Hardware name", hardware.Name)
+	mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeWarning, "EvictingMachine",
+		"Deleting Machine %s running on Hardware %s in maintenance", mrc.machine.Name, hardware.Name)
+
+	if err := mrc.client.Delete(mrc.ctx, mrc.machine); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting Machine: %w", err)
+	}
+
+	return nil
+}
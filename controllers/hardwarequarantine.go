@@ -0,0 +1,196 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+const (
+	// HardwareQuarantinedLabel is set on Hardware, which failed provisioning too many times. Quarantined
+	// Hardware is not selected for machines.
+	HardwareQuarantinedLabel = "v1alpha1.tinkerbell.org/quarantined"
+
+	// HardwareProvisioningFailuresAnnotation holds the number of failed provisioning workflows of the Hardware.
+	HardwareProvisioningFailuresAnnotation = "v1alpha1.tinkerbell.org/provisioningFailures"
+
+	// HardwareLastFailedWorkflowAnnotation holds UID of the last failed workflow counted in the Hardware
+	// provisioning failures, so each workflow is counted only once.
+	HardwareLastFailedWorkflowAnnotation = "v1alpha1.tinkerbell.org/lastFailedWorkflow"
+
+	// HardwareClearQuarantineAnnotation can be set on Hardware to clear the quarantine and reset the number
+	// of provisioning failures, e.g. after the faulty disk has been replaced.
+	HardwareClearQuarantineAnnotation = "v1alpha1.tinkerbell.org/clearQuarantine"
+)
+
+// hardwareNotQuarantined selects Hardware, which is not quarantined.
+func hardwareNotQuarantined(hardware *tinkv1.Hardware) bool {
+	_, quarantined := hardware.Labels[HardwareQuarantinedLabel]
+
+	return !quarantined
+}
+
+// provisioningFailures returns the number of failed provisioning workflows recorded on the Hardware.
+func provisioningFailures(hardware *tinkv1.Hardware) int {
+	failures, err := strconv.Atoi(hardware.Annotations[HardwareProvisioningFailuresAnnotation])
+	if err != nil {
+		return 0
+	}
+
+	return failures
+}
+
+// workflowFailed reports whether the workflow reached a terminal state other than success.
+func workflowFailed(workflow *tinkv1.Workflow) bool {
+	return workflow.Status.State == tinkv1.WorkflowStateFailed || workflow.Status.State == tinkv1.WorkflowStateTimeout
+}
+
+// recordProvisioningFailure counts the failed workflow in the Hardware provisioning failures and quarantines
+// the Hardware once the failures reach the quarantine threshold.
+func (mrc *machineReconcileContext) recordProvisioningFailure(hardware *tinkv1.Hardware, workflow *tinkv1.Workflow) error { //nolint:lll
+	if hardware.Annotations[HardwareLastFailedWorkflowAnnotation] == string(workflow.UID) {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(hardware, mrc.client)
+	if err != nil {
+		return fmt.Errorf("initializing patch helper for selected hardware: %w", err)
+	}
+
+	failures := provisioningFailures(hardware) + 1
+	quarantine := mrc.quarantineThreshold > 0 && failures >= mrc.quarantineThreshold
+
+	if hardware.Annotations == nil {
+		hardware.Annotations = map[string]string{}
+	}
+
+	hardware.Annotations[HardwareProvisioningFailuresAnnotation] = strconv.Itoa(failures)
+	hardware.Annotations[HardwareLastFailedWorkflowAnnotation] = string(workflow.UID)
+
+	if quarantine {
+		if hardware.Labels == nil {
+			hardware.Labels = map[string]string{}
+		}
+
+		hardware.Labels[HardwareQuarantinedLabel] = "true"
+	}
+
+	if err := patchHelper.Patch(mrc.ctx, hardware); err != nil {
+		return fmt.Errorf("patching Hardware object: %w", err)
+	}
+
+	mrc.log.Info("Workflow failed", "name", workflow.Name, "state", workflow.Status.State,
+		"Hardware name", hardware.Name, "failures", failures)
+	mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeWarning, "WorkflowFailed",
+		"Workflow %s finished with state %s on Hardware %s, which failed provisioning %d times",
+		workflow.Name, workflow.Status.State, hardware.Name, failures)
+
+	if quarantine {
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeWarning, "HardwareQuarantined",
+			"Hardware %s quarantined after %d failed provisioning attempts", hardware.Name, failures)
+	}
+
+	return nil
+}
+
+// HardwareQuarantineReconciler clears the quarantine of Hardware annotated with
+// HardwareClearQuarantineAnnotation.
+type HardwareQuarantineReconciler struct {
+	client.Client
+
+	// Recorder is used for emitting events about clearing the quarantine. If nil, events are discarded.
+	Recorder record.EventRecorder
+}
+
+// Reconcile clears the quarantine of the Hardware, if requested.
+func (hqr *HardwareQuarantineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) { //nolint:lll
+	ctx, span := tracing.StartReconcile(ctx, "hardwarequarantine", req)
+	defer func() {
+		recordReconcileError("hardwarequarantine", reterr)
+		tracing.End(span, reterr)
+	}()
+
+	log := ctrl.LoggerFrom(ctx).WithValues("Hardware", req.Name)
+
+	hardware := &tinkv1.Hardware{}
+
+	if err := hqr.Client.Get(ctx, req.NamespacedName, hardware); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, fmt.Errorf("getting Hardware: %w", err)
+	}
+
+	if _, ok := hardware.Annotations[HardwareClearQuarantineAnnotation]; !ok {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(hardware, hqr.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("initializing patch helper: %w", err)
+	}
+
+	delete(hardware.Labels, HardwareQuarantinedLabel)
+	delete(hardware.Annotations, HardwareProvisioningFailuresAnnotation)
+	delete(hardware.Annotations, HardwareLastFailedWorkflowAnnotation)
+	delete(hardware.Annotations, HardwareClearQuarantineAnnotation)
+
+	if err := patchHelper.Patch(ctx, hardware); err != nil {
+		return ctrl.Result{}, fmt.Errorf("patching Hardware: %w", err)
+	}
+
+	log.Info("Cleared Hardware quarantine")
+	eventRecorderOrDiscard(hqr.Recorder).Event(hardware, corev1.EventTypeNormal, "QuarantineCleared",
+		"Cleared Hardware quarantine and provisioning failures")
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager configures reconciler with a given manager.
+func (hqr *HardwareQuarantineReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	clearQuarantineRequested := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		_, ok := o.GetAnnotations()[HardwareClearQuarantineAnnotation]
+
+		return ok
+	})
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named("hardwarequarantine").
+		WithOptions(options).
+		For(&tinkv1.Hardware{}, builder.WithPredicates(clearQuarantineRequested)).
+		Complete(hqr); err != nil {
+		return fmt.Errorf("failed to configure controller: %w", err)
+	}
+
+	return nil
+}
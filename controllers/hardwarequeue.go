@@ -0,0 +1,274 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// waitingForHardware reports whether the machine is waiting for Hardware to become available.
+func waitingForHardware(tinkerbellMachine *infrastructurev1.TinkerbellMachine) bool {
+	return tinkerbellMachine.Spec.HardwareName == "" &&
+		tinkerbellMachine.Status.WaitingForHardwareSince != nil &&
+		tinkerbellMachine.DeletionTimestamp.IsZero()
+}
+
+// waitsLonger reports whether machine a has been waiting for Hardware longer than machine b. Machine
+// which is not yet waiting is queued after all waiting machines.
+func waitsLonger(a, b *infrastructurev1.TinkerbellMachine) bool {
+	aSince, bSince := a.Status.WaitingForHardwareSince, b.Status.WaitingForHardwareSince
+
+	switch {
+	case aSince == nil:
+		return false
+	case bSince == nil:
+		return true
+	case !aSince.Equal(bSince):
+		return aSince.Before(bSince)
+	case a.Namespace != b.Namespace:
+		return a.Namespace < b.Namespace
+	default:
+		return a.Name < b.Name
+	}
+}
+
+// machinesWaitingLonger returns the machines, which wait for Hardware longer than the reconciled machine,
+// in the order they started waiting.
+func (mrc *machineReconcileContext) machinesWaitingLonger() ([]*infrastructurev1.TinkerbellMachine, error) {
+	machines, err := listMachinesWaitingForHardware(mrc.ctx, mrc.client)
+	if err != nil {
+		return nil, err
+	}
+
+	waiting := []*infrastructurev1.TinkerbellMachine{}
+
+	for _, machine := range machines {
+		if machine.UID == mrc.tinkerbellMachine.UID || !waitsLonger(machine, mrc.tinkerbellMachine) {
+			continue
+		}
+
+		waiting = append(waiting, machine)
+	}
+
+	sort.Slice(waiting, func(i, j int) bool {
+		return waitsLonger(waiting[i], waiting[j])
+	})
+
+	return waiting, nil
+}
+
+// hardwareLeftInQueue returns the available Hardware left for the reconciled machine once the machines
+// waiting longer claim Hardware in the order they started waiting. Each of them claims the first Hardware
+// matching its own filters, so Hardware it can't select stays available for the machines behind it.
+// Paused machines do not claim Hardware.
+func (mrc *machineReconcileContext) hardwareLeftInQueue(hardware []tinkv1.Hardware) ([]tinkv1.Hardware, error) {
+	waiting, err := mrc.machinesWaitingLonger()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, machine := range waiting {
+		if len(hardware) == 0 {
+			break
+		}
+
+		paused, err := mrc.waitingMachinePaused(machine)
+		if err != nil {
+			return nil, fmt.Errorf("checking if TinkerbellMachine %q is paused: %w", machine.Name, err)
+		}
+
+		if paused {
+			continue
+		}
+
+		filters, err := mrc.waitingMachineHardwareFilters(machine)
+		if err != nil {
+			return nil, fmt.Errorf("getting Hardware filters of TinkerbellMachine %q: %w", machine.Name, err)
+		}
+
+		if i := firstMatchingHardware(hardware, filters); i >= 0 {
+			hardware = append(hardware[:i:i], hardware[i+1:]...)
+		}
+	}
+
+	return hardware, nil
+}
+
+// waitingMachinePaused reports whether the other machine waiting for Hardware or its cluster is paused.
+func (mrc *machineReconcileContext) waitingMachinePaused(tinkerbellMachine *infrastructurev1.TinkerbellMachine) (bool, error) { //nolint:lll
+	if annotations.HasPausedAnnotation(tinkerbellMachine) {
+		return true, nil
+	}
+
+	cluster, err := util.GetClusterFromMetadata(mrc.ctx, mrc.client, tinkerbellMachine.ObjectMeta)
+	if err != nil {
+		if apierrors.IsNotFound(err) || errors.Is(err, util.ErrNoCluster) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("getting cluster from metadata: %w", err)
+	}
+
+	return cluster.Spec.Paused, nil
+}
+
+// waitingMachineHardwareFilters returns the filters of Hardware, which may be selected for the other
+// machine waiting for Hardware.
+func (mrc *machineReconcileContext) waitingMachineHardwareFilters(
+	tinkerbellMachine *infrastructurev1.TinkerbellMachine,
+) ([]hardwareFilter, error) {
+	machine, err := util.GetOwnerMachine(mrc.ctx, mrc.client, tinkerbellMachine.ObjectMeta)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("getting owner Machine: %w", err)
+	}
+
+	pool, err := getTinkerbellMachinePool(mrc.ctx, mrc.client, tinkerbellMachine)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := machinePoolHardwareSelector(pool)
+	if err != nil {
+		return nil, err
+	}
+
+	tinkerbellCluster, err := mrc.tinkerbellClusterOf(machine)
+	if err != nil {
+		return nil, err
+	}
+
+	return hardwareFilters(tinkerbellMachine, machine, tinkerbellCluster, selector), nil
+}
+
+// tinkerbellClusterOf returns the TinkerbellCluster of the cluster given Machine belongs to. If it does not
+// exist, nil is returned.
+func (mrc *machineReconcileContext) tinkerbellClusterOf(machine *clusterv1.Machine) (*infrastructurev1.TinkerbellCluster, error) { //nolint:lll
+	if machine == nil {
+		return nil, nil
+	}
+
+	if machine.Namespace == mrc.tinkerbellCluster.Namespace &&
+		machine.Labels[clusterv1.ClusterLabelName] == mrc.machine.Labels[clusterv1.ClusterLabelName] {
+		return mrc.tinkerbellCluster, nil
+	}
+
+	cluster, err := util.GetClusterFromMetadata(mrc.ctx, mrc.client, machine.ObjectMeta)
+	if err != nil {
+		if apierrors.IsNotFound(err) || errors.Is(err, util.ErrNoCluster) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("getting cluster from metadata: %w", err)
+	}
+
+	if cluster.Spec.InfrastructureRef == nil {
+		return nil, nil
+	}
+
+	tinkerbellCluster := &infrastructurev1.TinkerbellCluster{}
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.InfrastructureRef.Name}
+
+	if err := mrc.client.Get(mrc.ctx, key, tinkerbellCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("getting TinkerbellCluster object: %w", err)
+	}
+
+	return tinkerbellCluster, nil
+}
+
+// firstMatchingHardware returns the index of the first Hardware matching all given filters or -1, if
+// none matches.
+func firstMatchingHardware(hardware []tinkv1.Hardware, filters []hardwareFilter) int {
+hardware:
+	for i := range hardware {
+		for _, filter := range filters {
+			if !filter(&hardware[i]) {
+				continue hardware
+			}
+		}
+
+		return i
+	}
+
+	return -1
+}
+
+// selectAvailableHardware selects Hardware for the machine from the available Hardware in the order
+// machines started waiting, so machines waiting longer get Hardware first.
+func (mrc *machineReconcileContext) selectAvailableHardware(filters ...hardwareFilter) (*tinkv1.Hardware, error) {
+	hardware, err := availableHardware(mrc.ctx, mrc.client)
+	if err != nil {
+		return nil, fmt.Errorf("getting next Hardware object: %w", err)
+	}
+
+	// All machines claim Hardware in the same order, so they agree on what is left for each of them.
+	sort.Slice(hardware, func(i, j int) bool {
+		return hardware[i].Name < hardware[j].Name
+	})
+
+	// Leave Hardware for machines waiting longer. They are reconciled by the Hardware watch as well.
+	hardware, err = mrc.hardwareLeftInQueue(hardware)
+	if err != nil {
+		return nil, fmt.Errorf("getting Hardware left in Hardware queue: %w", err)
+	}
+
+	i := firstMatchingHardware(hardware, filters)
+	if i < 0 {
+		return nil, ErrNoHardwareAvailable
+	}
+
+	return &hardware[i], nil
+}
+
+// waitForHardware marks the machine as waiting for Hardware. The machine is enqueued again by the
+// Hardware watch once some Hardware becomes available.
+func (mrc *machineReconcileContext) waitForHardware() error {
+	if mrc.tinkerbellMachine.Status.WaitingForHardwareSince == nil {
+		now := metav1.Now()
+		mrc.tinkerbellMachine.Status.WaitingForHardwareSince = &now
+
+		mrc.log.Info("No Hardware available for machine, waiting")
+		mrc.recorder.Event(mrc.tinkerbellMachine, corev1.EventTypeWarning, infrastructurev1.WaitingForHardwareReason,
+			"No Hardware available for machine, waiting")
+	}
+
+	conditions.MarkFalse(mrc.tinkerbellMachine, infrastructurev1.HardwareSelectedCondition,
+		infrastructurev1.WaitingForHardwareReason, clusterv1.ConditionSeverityInfo, "No Hardware available for machine")
+
+	if err := mrc.patch(); err != nil {
+		return fmt.Errorf("patching machine with waiting for hardware status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+const (
+	// HardwareReuseGroupAnnotation holds the group of the machine, which released the Hardware, i.e. its
+	// namespace and MachineDeployment, control plane or machine pool. It is only set for machines
+	// reusing Hardware.
+	HardwareReuseGroupAnnotation = "v1alpha1.tinkerbell.org/reuseGroup"
+
+	// HardwareReservedUntilAnnotation holds the time in RFC 3339 format, until which the released Hardware
+	// is reserved for machines of the group in HardwareReuseGroupAnnotation.
+	HardwareReservedUntilAnnotation = "v1alpha1.tinkerbell.org/reservedUntil"
+
+	// defaultHardwareReservationPeriod is used when the machine does not specify the reservation period.
+	defaultHardwareReservationPeriod = 10 * time.Minute
+)
+
+// hardwareReuseGroup returns the group of machines, which reuse Hardware released by each other. Machines
+// of MachineDeployments are grouped by the MachineDeployment, as rolling upgrades replace their MachineSet.
+// If the machine does not belong to any group, empty string is returned.
+func hardwareReuseGroup(tinkerbellMachine *infrastructurev1.TinkerbellMachine, machine *clusterv1.Machine) string {
+	var group string
+
+	poolName, inPool := tinkerbellMachine.Labels[infrastructurev1.MachinePoolNameLabel]
+
+	switch {
+	case inPool:
+		group = tinkerbellMachinePoolKind + "/" + poolName
+	case machine == nil:
+		return ""
+	case machine.Labels[clusterv1.MachineDeploymentLabelName] != "":
+		group = "MachineDeployment/" + machine.Labels[clusterv1.MachineDeploymentLabelName]
+	case metav1.GetControllerOf(machine) != nil:
+		ref := metav1.GetControllerOf(machine)
+		group = ref.Kind + "/" + ref.Name
+	default:
+		return ""
+	}
+
+	return namespacedKey(tinkerbellMachine.Namespace, group)
+}
+
+// hardwareReservedUntil returns the time until which the Hardware is reserved for its reuse group. If the
+// Hardware is not reserved, zero time is returned.
+func hardwareReservedUntil(hardware *tinkv1.Hardware) time.Time {
+	reservedUntil, err := time.Parse(time.RFC3339, hardware.Annotations[HardwareReservedUntilAnnotation])
+	if err != nil || hardware.Annotations[HardwareReuseGroupAnnotation] == "" {
+		return time.Time{}
+	}
+
+	return reservedUntil
+}
+
+// hardwareNotReservedForOthers selects Hardware, which is not reserved for a reuse group other than given one.
+func hardwareNotReservedForOthers(group string) hardwareFilter {
+	return func(hardware *tinkv1.Hardware) bool {
+		return hardware.Annotations[HardwareReuseGroupAnnotation] == group ||
+			!time.Now().Before(hardwareReservedUntil(hardware))
+	}
+}
+
+// hardwareReleasedBy selects Hardware, which was released by a machine of given reuse group.
+func hardwareReleasedBy(group string) hardwareFilter {
+	return func(hardware *tinkv1.Hardware) bool {
+		return hardware.Annotations[HardwareReuseGroupAnnotation] == group
+	}
+}
+
+// hardwareReuseGroup returns the reuse group of the reconciled machine. If the machine does not reuse
+// Hardware, empty string is returned.
+func (mrc *machineReconcileContext) hardwareReuseGroup() string {
+	if mrc.tinkerbellMachine.Spec.HardwareReuse == nil {
+		return ""
+	}
+
+	return hardwareReuseGroup(mrc.tinkerbellMachine, mrc.machine)
+}
+
+// selectReleasedHardware selects Hardware released by other machines of the reuse group. Such Hardware
+// is not subject to the Hardware queue, as it is not available to machines of other groups while reserved.
+// If there is none and the machine requires reusing Hardware, it waits while machines of the group are
+// being removed. Otherwise nil is returned, so any available Hardware can be selected.
+func (mrc *machineReconcileContext) selectReleasedHardware(
+	group string,
+	filters []hardwareFilter,
+) (*tinkv1.Hardware, error) {
+	hardware, err := availableHardware(mrc.ctx, mrc.client, append(filters, hardwareReleasedBy(group))...)
+	if err != nil {
+		return nil, fmt.Errorf("getting released Hardware object: %w", err)
+	}
+
+	if len(hardware) > 0 {
+		mrc.log.Info("Reusing Hardware released by machine of the same group", "group", group,
+			"Hardware name", hardware[0].Name)
+
+		return &hardware[0], nil
+	}
+
+	if mrc.tinkerbellMachine.Spec.HardwareReuse.Policy != infrastructurev1.HardwareReusePolicyRequire {
+		return nil, nil
+	}
+
+	releasing, err := mrc.groupReleasingHardware(group)
+	if err != nil {
+		return nil, err
+	}
+
+	if releasing {
+		mrc.log.Info("Waiting for Hardware of removed machine of the same group", "group", group)
+
+		return nil, ErrNoHardwareAvailable
+	}
+
+	return nil, nil
+}
+
+// groupReleasingHardware reports whether some machine of given reuse group is being removed, so its
+// Hardware is about to be released.
+func (mrc *machineReconcileContext) groupReleasingHardware(group string) (bool, error) {
+	machines := &infrastructurev1.TinkerbellMachineList{}
+
+	if err := mrc.client.List(mrc.ctx, machines, client.InNamespace(mrc.tinkerbellMachine.Namespace)); err != nil {
+		return false, fmt.Errorf("listing TinkerbellMachines: %w", err)
+	}
+
+	for i := range machines.Items {
+		tinkerbellMachine := &machines.Items[i]
+
+		if tinkerbellMachine.DeletionTimestamp.IsZero() || tinkerbellMachine.Spec.HardwareName == "" ||
+			tinkerbellMachine.Spec.HardwareReuse == nil {
+			continue
+		}
+
+		machine, err := util.GetOwnerMachine(mrc.ctx, mrc.client, tinkerbellMachine.ObjectMeta)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("getting owner Machine of TinkerbellMachine %q: %w", tinkerbellMachine.Name, err)
+		}
+
+		if hardwareReuseGroup(tinkerbellMachine, machine) == group {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// nextReservationExpiry returns the time until the earliest reservation of available Hardware for other
+// reuse groups expires, so machines waiting for Hardware can be reconciled again. If no Hardware is
+// reserved, zero is returned.
+func (mrc *machineReconcileContext) nextReservationExpiry() (time.Duration, error) {
+	hardware, err := availableHardware(mrc.ctx, mrc.client)
+	if err != nil {
+		return 0, err
+	}
+
+	var next time.Duration
+
+	for i := range hardware {
+		remaining := time.Until(hardwareReservedUntil(&hardware[i]))
+		if remaining > 0 && (next == 0 || remaining < next) {
+			next = remaining
+		}
+	}
+
+	return next, nil
+}
+
+// preserveDataPartitions reports whether the machine reprovisions Hardware previously provisioned by its
+// reuse group keeping the data partitions.
+func (mrc *machineReconcileContext) preserveDataPartitions(hardware *tinkv1.Hardware) bool {
+	reuse := mrc.tinkerbellMachine.Spec.HardwareReuse
+	if reuse == nil || reuse.RootPartitionImageLookupFormat == "" {
+		return false
+	}
+
+	group := mrc.hardwareReuseGroup()
+
+	return group != "" && hardwareReleasedBy(group)(hardware)
+}
+
+// reserveHardware reserves the released Hardware for other machines of the reuse group of the removed
+// machine. Hardware released by machines, which do not reuse Hardware, is not reserved.
+func (bmrc *baseMachineReconcileContext) reserveHardware(hardware *tinkv1.Hardware) error {
+	delete(hardware.Annotations, HardwareReuseGroupAnnotation)
+	delete(hardware.Annotations, HardwareReservedUntilAnnotation)
+
+	reuse := bmrc.tinkerbellMachine.Spec.HardwareReuse
+	if reuse == nil {
+		return nil
+	}
+
+	machine, err := util.GetOwnerMachine(bmrc.ctx, bmrc.client, bmrc.tinkerbellMachine.ObjectMeta)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("getting owner Machine: %w", err)
+	}
+
+	group := hardwareReuseGroup(bmrc.tinkerbellMachine, machine)
+	if group == "" {
+		return nil
+	}
+
+	reservationPeriod := defaultHardwareReservationPeriod
+	if reuse.ReservationPeriod != nil {
+		reservationPeriod = reuse.ReservationPeriod.Duration
+	}
+
+	if hardware.Annotations == nil {
+		hardware.Annotations = map[string]string{}
+	}
+
+	hardware.Annotations[HardwareReuseGroupAnnotation] = group
+	hardware.Annotations[HardwareReservedUntilAnnotation] = time.Now().Add(reservationPeriod).UTC().Format(time.RFC3339)
+
+	bmrc.log.Info("Reserving released Hardware for machines of the same group", "group", group,
+		"reservationPeriod", reservationPeriod)
+
+	return nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// ErrNoMatchingCatalogImage is returned when the referenced image catalog has no image
+// matching the machine.
+var ErrNoMatchingCatalogImage = fmt.Errorf("no matching image in catalog")
+
+// archAliases maps architecture names reported by hardware to GOARCH naming used by images.
+//
+//nolint:gochecknoglobals
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+}
+
+// hardwareArch returns the CPU architecture of the hardware using GOARCH naming. If the
+// hardware does not report architecture, DefaultArch is assumed.
+func hardwareArch(hardware *tinkv1.Hardware) string {
+	for _, iface := range hardware.Status.Interfaces {
+		if iface.DHCP == nil || iface.DHCP.Arch == "" {
+			continue
+		}
+
+		arch := strings.ToLower(iface.DHCP.Arch)
+		if alias, ok := archAliases[arch]; ok {
+			return alias
+		}
+
+		return arch
+	}
+
+	return infrastructurev1.DefaultArch
+}
+
+// hardwareFirmware returns the firmware type the hardware boots with.
+func hardwareFirmware(hardware *tinkv1.Hardware) infrastructurev1.Firmware {
+	for _, iface := range hardware.Status.Interfaces {
+		if iface.DHCP == nil {
+			continue
+		}
+
+		if iface.DHCP.UEFI {
+			return infrastructurev1.FirmwareUEFI
+		}
+
+		return infrastructurev1.FirmwareBIOS
+	}
+
+	return infrastructurev1.FirmwareBIOS
+}
+
+// catalogImage selects image for the machine from the referenced TinkerbellImage.
+func (mrc *machineReconcileContext) catalogImage(hardware *tinkv1.Hardware) (*infrastructurev1.OSImage, error) {
+	catalog := &infrastructurev1.TinkerbellImage{}
+
+	namespacedName := types.NamespacedName{
+		Namespace: mrc.tinkerbellMachine.Namespace,
+		Name:      mrc.tinkerbellMachine.Spec.ImageCatalogRef.Name,
+	}
+
+	if err := mrc.client.Get(mrc.ctx, namespacedName, catalog); err != nil {
+		return nil, fmt.Errorf("getting TinkerbellImage %q: %w", namespacedName, err)
+	}
+
+	osDistro := mrc.tinkerbellMachine.Spec.ImageLookupOSDistro
+	if osDistro == "" {
+		osDistro = mrc.tinkerbellCluster.Spec.ImageLookupOSDistro
+	}
+
+	osVersion := mrc.tinkerbellMachine.Spec.ImageLookupOSVersion
+	if osVersion == "" {
+		osVersion = mrc.tinkerbellCluster.Spec.ImageLookupOSVersion
+	}
+
+	criteria := imageCriteria{
+		kubernetesVersion: *mrc.machine.Spec.Version,
+		osDistro:          osDistro,
+		osVersion:         osVersion,
+		arch:              hardwareArch(hardware),
+		firmware:          hardwareFirmware(hardware),
+	}
+
+	image := criteria.selectImage(catalog.Spec.Images)
+	if image == nil {
+		err := fmt.Errorf("%w %q for %s", ErrNoMatchingCatalogImage, namespacedName, criteria)
+
+		conditions.MarkFalse(mrc.tinkerbellMachine, infrastructurev1.ImageAvailableCondition,
+			infrastructurev1.ImageNotFoundReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		mrc.recorder.Event(mrc.tinkerbellMachine, corev1.EventTypeWarning, infrastructurev1.ImageNotFoundReason, err.Error())
+
+		if patchErr := mrc.patch(); patchErr != nil {
+			mrc.log.Error(patchErr, "Failed to patch TinkerbellMachine with image availability condition")
+		}
+
+		return nil, err
+	}
+
+	mrc.log.Info("Selected image from catalog", "catalog", namespacedName, "imageURL", image.URL)
+
+	return image, nil
+}
+
+type imageCriteria struct {
+	kubernetesVersion string
+	osDistro          string
+	osVersion         string
+	arch              string
+	firmware          infrastructurev1.Firmware
+}
+
+func (ic imageCriteria) String() string {
+	return fmt.Sprintf("kubernetes version %q, OS %q %q, arch %q, firmware %q",
+		ic.kubernetesVersion, ic.osDistro, ic.osVersion, ic.arch, ic.firmware)
+}
+
+// selectImage returns the first image matching the criteria. Images built for the hardware
+// firmware are preferred over images not specifying the firmware.
+func (ic imageCriteria) selectImage(images []infrastructurev1.OSImage) *infrastructurev1.OSImage {
+	var fallback *infrastructurev1.OSImage
+
+	for i := range images {
+		image := &images[i]
+
+		if !ic.matches(image) {
+			continue
+		}
+
+		if image.Firmware == ic.firmware {
+			return image
+		}
+
+		if image.Firmware == "" && fallback == nil {
+			fallback = image
+		}
+	}
+
+	return fallback
+}
+
+func (ic imageCriteria) matches(image *infrastructurev1.OSImage) bool {
+	if strings.TrimPrefix(image.KubernetesVersion, "v") != strings.TrimPrefix(ic.kubernetesVersion, "v") {
+		return false
+	}
+
+	if ic.osDistro != "" && !strings.EqualFold(image.OSDistro, ic.osDistro) {
+		return false
+	}
+
+	if ic.osVersion != "" && image.OSVersion != ic.osVersion {
+		return false
+	}
+
+	arch := image.Arch
+	if arch == "" {
+		arch = infrastructurev1.DefaultArch
+	}
+
+	return arch == ic.arch
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+)
+
+//nolint:funlen
+func Test_Selecting_image_from_catalog(t *testing.T) {
+	t.Parallel()
+
+	images := []infrastructurev1.OSImage{
+		{KubernetesVersion: "v1.20.11", OSDistro: "ubuntu", OSVersion: "20.04", URL: "ubuntu-2004-amd64"},
+		{KubernetesVersion: "v1.20.11", OSDistro: "ubuntu", OSVersion: "18.04", URL: "ubuntu-1804-amd64"},
+		{KubernetesVersion: "v1.20.11", OSDistro: "ubuntu", OSVersion: "20.04", Arch: "arm64", URL: "ubuntu-2004-arm64"},
+		{
+			KubernetesVersion: "v1.20.11", OSDistro: "ubuntu", OSVersion: "20.04", Arch: "arm64",
+			Firmware: infrastructurev1.FirmwareUEFI, URL: "ubuntu-2004-arm64-uefi",
+		},
+		{KubernetesVersion: "v1.21.5", OSDistro: "flatcar", URL: "flatcar-amd64"},
+	}
+
+	cases := map[string]struct {
+		criteria    imageCriteria
+		expectedURL string
+	}{
+		"selects_first_image_matching_kubernetes_version": {
+			criteria:    imageCriteria{kubernetesVersion: "v1.20.11", arch: "amd64", firmware: infrastructurev1.FirmwareBIOS},
+			expectedURL: "ubuntu-2004-amd64",
+		},
+		"ignores_v_prefix_in_kubernetes_version": {
+			criteria:    imageCriteria{kubernetesVersion: "1.21.5", arch: "amd64", firmware: infrastructurev1.FirmwareBIOS},
+			expectedURL: "flatcar-amd64",
+		},
+		"matches_OS_version": {
+			criteria: imageCriteria{
+				kubernetesVersion: "v1.20.11", osDistro: "Ubuntu", osVersion: "18.04",
+				arch: "amd64", firmware: infrastructurev1.FirmwareBIOS,
+			},
+			expectedURL: "ubuntu-1804-amd64",
+		},
+		"matches_architecture": {
+			criteria:    imageCriteria{kubernetesVersion: "v1.20.11", arch: "arm64", firmware: infrastructurev1.FirmwareBIOS},
+			expectedURL: "ubuntu-2004-arm64",
+		},
+		"prefers_image_with_matching_firmware": {
+			criteria:    imageCriteria{kubernetesVersion: "v1.20.11", arch: "arm64", firmware: infrastructurev1.FirmwareUEFI},
+			expectedURL: "ubuntu-2004-arm64-uefi",
+		},
+		"returns_nothing_when_no_image_matches": {
+			criteria: imageCriteria{kubernetesVersion: "v1.22.0", arch: "amd64", firmware: infrastructurev1.FirmwareBIOS},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			image := c.criteria.selectImage(images)
+
+			if c.expectedURL == "" {
+				g.Expect(image).To(BeNil())
+
+				return
+			}
+
+			g.Expect(image).NotTo(BeNil())
+			g.Expect(image.URL).To(Equal(c.expectedURL))
+		})
+	}
+}
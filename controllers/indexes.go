@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// Field indexes registered in the manager cache, so reconcilers can look up objects without
+// scanning all objects of given kind.
+//
+// Not every client supports field selectors, e.g. the fake client used in tests ignores them,
+// so lookups using the indexes also filter the results by the same criteria.
+const (
+	// HardwareOwnerIndex indexes Hardware by the owning TinkerbellMachine in namespace/name format.
+	// Hardware available for selection is indexed with an empty value.
+	HardwareOwnerIndex = "hardware.ownerName"
+
+	// TinkerbellMachineClusterIndex indexes TinkerbellMachines by the cluster in namespace/name format.
+	TinkerbellMachineClusterIndex = "tinkerbellmachine.clusterName"
+
+	// WorkflowHardwareIndex indexes Workflows by the referenced Hardware name.
+	WorkflowHardwareIndex = "spec.hardwareRef"
+
+	// TemplateOwnerIndex indexes Templates by UIDs of their owners.
+	TemplateOwnerIndex = "metadata.ownerReferences.uid"
+
+	// TinkerbellMachineWaitingForHardwareIndex indexes TinkerbellMachines waiting for Hardware with
+	// waitingForHardwareIndexValue. Other machines are not indexed.
+	TinkerbellMachineWaitingForHardwareIndex = "status.waitingForHardwareSince"
+
+	// TinkerbellMachineTemplateOverrideIndex indexes TinkerbellMachines by the name of the referenced
+	// template override.
+	TinkerbellMachineTemplateOverrideIndex = "spec.templateOverrideRef.name"
+)
+
+// SetupIndexes registers field indexes used by the reconcilers in the given indexer.
+func SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	indexes := []struct {
+		obj       client.Object
+		field     string
+		extractor client.IndexerFunc
+	}{
+		{&tinkv1.Hardware{}, HardwareOwnerIndex, hardwareOwnerIndexFunc},
+		{&infrastructurev1.TinkerbellMachine{}, TinkerbellMachineClusterIndex, tinkerbellMachineClusterIndexFunc},
+		{&tinkv1.Workflow{}, WorkflowHardwareIndex, workflowHardwareIndexFunc},
+		{&tinkv1.Template{}, TemplateOwnerIndex, templateOwnerIndexFunc},
+		{
+			&infrastructurev1.TinkerbellMachine{},
+			TinkerbellMachineWaitingForHardwareIndex,
+			tinkerbellMachineWaitingForHardwareIndexFunc,
+		},
+		{
+			&infrastructurev1.TinkerbellMachine{},
+			TinkerbellMachineTemplateOverrideIndex,
+			tinkerbellMachineTemplateOverrideIndexFunc,
+		},
+	}
+
+	for _, index := range indexes {
+		if err := indexer.IndexField(ctx, index.obj, index.field, index.extractor); err != nil {
+			return fmt.Errorf("registering %s index: %w", index.field, err)
+		}
+	}
+
+	return nil
+}
+
+// waitingForHardwareIndexValue is the value TinkerbellMachines waiting for Hardware are indexed with.
+const waitingForHardwareIndexValue = "true"
+
+// listMachinesWaitingForHardware returns all TinkerbellMachines waiting for Hardware.
+func listMachinesWaitingForHardware(ctx context.Context, k8sClient client.Client) ([]*infrastructurev1.TinkerbellMachine, error) { //nolint:lll
+	machines := &infrastructurev1.TinkerbellMachineList{}
+
+	if err := k8sClient.List(ctx, machines,
+		client.MatchingFields{TinkerbellMachineWaitingForHardwareIndex: waitingForHardwareIndexValue}); err != nil {
+		return nil, fmt.Errorf("listing TinkerbellMachines waiting for Hardware: %w", err)
+	}
+
+	waiting := []*infrastructurev1.TinkerbellMachine{}
+
+	for i := range machines.Items {
+		if waitingForHardware(&machines.Items[i]) {
+			waiting = append(waiting, &machines.Items[i])
+		}
+	}
+
+	return waiting, nil
+}
+
+func namespacedKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func hardwareOwnerIndexFunc(o client.Object) []string {
+	name, ok := o.GetLabels()[HardwareOwnerNameLabel]
+	if !ok {
+		return []string{""}
+	}
+
+	return []string{namespacedKey(o.GetLabels()[HardwareOwnerNamespaceLabel], name)}
+}
+
+func tinkerbellMachineClusterIndexFunc(o client.Object) []string {
+	name, ok := o.GetLabels()[clusterv1.ClusterLabelName]
+	if !ok {
+		return nil
+	}
+
+	return []string{namespacedKey(o.GetNamespace(), name)}
+}
+
+func workflowHardwareIndexFunc(o client.Object) []string {
+	workflow, ok := o.(*tinkv1.Workflow)
+	if !ok || workflow.Spec.HardwareRef == "" {
+		return nil
+	}
+
+	return []string{workflow.Spec.HardwareRef}
+}
+
+func templateOwnerIndexFunc(o client.Object) []string {
+	owners := make([]string, 0, len(o.GetOwnerReferences()))
+
+	for _, ownerRef := range o.GetOwnerReferences() {
+		owners = append(owners, string(ownerRef.UID))
+	}
+
+	return owners
+}
+
+func tinkerbellMachineWaitingForHardwareIndexFunc(o client.Object) []string {
+	machine, ok := o.(*infrastructurev1.TinkerbellMachine)
+	if !ok || !waitingForHardware(machine) {
+		return nil
+	}
+
+	return []string{waitingForHardwareIndexValue}
+}
+
+func tinkerbellMachineTemplateOverrideIndexFunc(o client.Object) []string {
+	machine, ok := o.(*infrastructurev1.TinkerbellMachine)
+	if !ok || machine.Spec.TemplateOverrideRef == nil {
+		return nil
+	}
+
+	return []string{machine.Spec.TemplateOverrideRef.Name}
+}
+
+// indexed reports whether the object is indexed with the given value by the index function.
+func indexed(o client.Object, indexFunc client.IndexerFunc, value string) bool {
+	for _, v := range indexFunc(o) {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
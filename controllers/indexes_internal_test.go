@@ -0,0 +1,218 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+//nolint:funlen
+func Test_Index_functions(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		indexFunc client.IndexerFunc
+		obj       client.Object
+		expected  []string
+	}{
+		"index_owned_hardware_by_owner": {
+			indexFunc: hardwareOwnerIndexFunc,
+			obj: &tinkv1.Hardware{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				HardwareOwnerNameLabel:      "machine",
+				HardwareOwnerNamespaceLabel: "default",
+			}}},
+			expected: []string{"default/machine"},
+		},
+		"index_available_hardware_with_empty_value": {
+			indexFunc: hardwareOwnerIndexFunc,
+			obj:       &tinkv1.Hardware{},
+			expected:  []string{""},
+		},
+		"index_machines_by_cluster": {
+			indexFunc: tinkerbellMachineClusterIndexFunc,
+			obj: &infrastructurev1.TinkerbellMachine{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster"},
+			}},
+			expected: []string{"default/cluster"},
+		},
+		"do_not_index_machines_without_cluster": {
+			indexFunc: tinkerbellMachineClusterIndexFunc,
+			obj:       &infrastructurev1.TinkerbellMachine{},
+		},
+		"index_workflows_by_hardware": {
+			indexFunc: workflowHardwareIndexFunc,
+			obj:       &tinkv1.Workflow{Spec: tinkv1.WorkflowSpec{HardwareRef: "hardware"}},
+			expected:  []string{"hardware"},
+		},
+		"index_templates_by_owner_uid": {
+			indexFunc: templateOwnerIndexFunc,
+			obj: &tinkv1.Template{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{
+				{UID: "foo"},
+				{UID: "bar"},
+			}}},
+			expected: []string{"foo", "bar"},
+		},
+		"index_machines_waiting_for_hardware": {
+			indexFunc: tinkerbellMachineWaitingForHardwareIndexFunc,
+			obj: &infrastructurev1.TinkerbellMachine{Status: infrastructurev1.TinkerbellMachineStatus{
+				WaitingForHardwareSince: &metav1.Time{},
+			}},
+			expected: []string{"true"},
+		},
+		"do_not_index_machines_with_hardware": {
+			indexFunc: tinkerbellMachineWaitingForHardwareIndexFunc,
+			obj: &infrastructurev1.TinkerbellMachine{
+				Spec:   infrastructurev1.TinkerbellMachineSpec{HardwareName: "hardware"},
+				Status: infrastructurev1.TinkerbellMachineStatus{WaitingForHardwareSince: &metav1.Time{}},
+			},
+		},
+		"index_machines_by_template_override": {
+			indexFunc: tinkerbellMachineTemplateOverrideIndexFunc,
+			obj: &infrastructurev1.TinkerbellMachine{Spec: infrastructurev1.TinkerbellMachineSpec{
+				TemplateOverrideRef: &infrastructurev1.TemplateOverrideReference{
+					Kind: infrastructurev1.TemplateOverrideKindConfigMap,
+					Name: "override",
+				},
+			}},
+			expected: []string{"override"},
+		},
+		"do_not_index_machines_without_template_override": {
+			indexFunc: tinkerbellMachineTemplateOverrideIndexFunc,
+			obj:       &infrastructurev1.TinkerbellMachine{},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			if c.expected == nil {
+				g.Expect(c.indexFunc(c.obj)).To(BeEmpty())
+
+				return
+			}
+
+			g.Expect(c.indexFunc(c.obj)).To(Equal(c.expected))
+		})
+	}
+}
+
+// indexedHardwareClient serves Hardware from a client-go indexer like the manager cache does. When
+// not indexed, all Hardware is listed, which is what happens without registered field indexes.
+type indexedHardwareClient struct {
+	client.Client
+	indexer cache.Indexer
+	indexed bool
+}
+
+func (c *indexedHardwareClient) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	hardwareList, ok := list.(*tinkv1.HardwareList)
+	if !ok {
+		return fmt.Errorf("unexpected list type %T", list) //nolint:goerr113
+	}
+
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	objs := c.indexer.List()
+
+	if c.indexed && listOpts.FieldSelector != nil {
+		requirement := listOpts.FieldSelector.Requirements()[0]
+
+		var err error
+
+		if objs, err = c.indexer.ByIndex(requirement.Field, requirement.Value); err != nil {
+			return fmt.Errorf("listing by index: %w", err)
+		}
+	}
+
+	for _, obj := range objs {
+		hardware, _ := obj.(*tinkv1.Hardware)
+		hardwareList.Items = append(hardwareList.Items, *hardware.DeepCopy())
+	}
+
+	return nil
+}
+
+func hardwareIndexer(b *testing.B, count int) cache.Indexer {
+	b.Helper()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		HardwareOwnerIndex: func(obj interface{}) ([]string, error) {
+			o, _ := obj.(client.Object)
+
+			return hardwareOwnerIndexFunc(o), nil
+		},
+	})
+
+	for i := 0; i < count; i++ {
+		hardware := &tinkv1.Hardware{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("hardware-%d", i)}}
+
+		// Keep most of the fleet in use, like in a busy environment.
+		if i%10 != 0 {
+			hardware.Labels = map[string]string{
+				HardwareOwnerNameLabel:      fmt.Sprintf("machine-%d", i),
+				HardwareOwnerNamespaceLabel: "default",
+			}
+		}
+
+		if err := indexer.Add(hardware); err != nil {
+			b.Fatalf("adding hardware to indexer: %v", err)
+		}
+	}
+
+	return indexer
+}
+
+func Benchmark_Hardware_selection(b *testing.B) {
+	for _, count := range []int{1000, 5000} {
+		indexer := hardwareIndexer(b, count)
+
+		for _, indexed := range []bool{false, true} {
+			k8sClient := &indexedHardwareClient{indexer: indexer, indexed: indexed}
+
+			b.Run(fmt.Sprintf("hardware=%d/indexed=%t", count, indexed), func(b *testing.B) {
+				ctx := context.Background()
+
+				for i := 0; i < b.N; i++ {
+					if _, err := listHardware(ctx, k8sClient, "default/machine-1"); err != nil {
+						b.Fatal(err)
+					}
+
+					if _, err := availableHardware(ctx, k8sClient); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -19,39 +19,62 @@ package controllers
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/images"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/metrics"
 	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
 	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 )
 
-const providerIDPlaceholder = "PROVIDER_ID"
+const (
+	providerIDPlaceholder = "PROVIDER_ID"
+	nodeLabelsPlaceholder = "NODE_LABELS"
+	nodeTaintsPlaceholder = "NODE_TAINTS"
+
+	// flatcarOEMPartitionNumber is the number of the OEM partition in Flatcar Container Linux images,
+	// where the Ignition config is written to.
+	flatcarOEMPartitionNumber = 6
+)
 
 type machineReconcileContext struct {
 	*baseMachineReconcileContext
 
-	machine              *clusterv1.Machine
-	tinkerbellCluster    *infrastructurev1.TinkerbellCluster
-	bootstrapCloudConfig string
+	machine           *clusterv1.Machine
+	tinkerbellCluster *infrastructurev1.TinkerbellCluster
+	bootstrapData     string
+	bootstrapFormat   templates.BootstrapFormat
+
+	// hardwareSelector restricts Hardware, which may be selected for members of machine pools.
+	hardwareSelector labels.Selector
 }
 
 // ErrHardwareMissingDiskConfiguration is returned when the referenced hardware is missing
 // disk configuration.
 var ErrHardwareMissingDiskConfiguration = fmt.Errorf("disk configuration is required")
 
+// ErrImageNotAvailable is returned when none of the machine image URLs point to an available image.
+var ErrImageNotAvailable = fmt.Errorf("machine image is not available")
+
 // MachineCreator is a subset of tinkerbellCluster used by machineReconcileContext.
 type MachineCreator interface {
 	// Template related functions.
@@ -83,11 +106,20 @@ func (mrc *machineReconcileContext) ensureDependencies() error {
 		return fmt.Errorf("ensuring hardware: %w", err)
 	}
 
+	if err := mrc.ensureHardwareInService(hardware); err != nil {
+		return fmt.Errorf("ensuring hardware is in service: %w", err)
+	}
+
+	// Adopted Hardware already runs a provisioned node, so there is nothing to install.
+	if mrc.adopting() {
+		return mrc.ensureAdopted(hardware)
+	}
+
 	if err := mrc.ensureTemplate(hardware); err != nil {
 		return fmt.Errorf("ensuring template: %w", err)
 	}
 
-	if err := mrc.ensureWorkflow(); err != nil {
+	if err := mrc.ensureWorkflow(hardware); err != nil {
 		return fmt.Errorf("ensuring workflow: %w", err)
 	}
 
@@ -122,24 +154,34 @@ func (mrc *machineReconcileContext) Reconcile() error {
 }
 
 func (mrc *machineReconcileContext) templateExists() (bool, error) {
-	namespacedName := types.NamespacedName{
-		Name: mrc.tinkerbellMachine.Name,
-	}
+	owner := string(mrc.tinkerbellMachine.UID)
+	templates := &tinkv1.TemplateList{}
 
-	err := mrc.client.Get(mrc.ctx, namespacedName, &tinkv1.Template{})
-	if err == nil {
-		return true, nil
+	if err := mrc.client.List(mrc.ctx, templates, client.MatchingFields{TemplateOwnerIndex: owner}); err != nil {
+		return false, fmt.Errorf("checking if template exists: %w", err)
 	}
 
-	if !apierrors.IsNotFound(err) {
-		return false, fmt.Errorf("checking if template exists: %w", err)
+	for i := range templates.Items {
+		template := &templates.Items[i]
+
+		if template.Name == mrc.tinkerbellMachine.Name && indexed(template, templateOwnerIndexFunc, owner) {
+			return true, nil
+		}
 	}
 
 	return false, nil
 }
 
-func (mrc *machineReconcileContext) imageURL() (string, error) {
-	imageLookupFormat := mrc.tinkerbellMachine.Spec.ImageLookupFormat
+// imageURL returns the URL of the machine image for the Hardware. If the image lookup format is empty,
+// the format of the machine or the cluster is used, including the fallback formats of the cluster.
+func (mrc *machineReconcileContext) imageURL(hardware *tinkv1.Hardware, imageLookupFormat string) (string, error) {
+	imageLookupFallbackFormats := []string{}
+
+	if imageLookupFormat == "" {
+		imageLookupFormat = mrc.tinkerbellMachine.Spec.ImageLookupFormat
+		imageLookupFallbackFormats = mrc.tinkerbellCluster.Spec.ImageLookupFallbackFormats
+	}
+
 	if imageLookupFormat == "" {
 		imageLookupFormat = mrc.tinkerbellCluster.Spec.ImageLookupFormat
 	}
@@ -159,49 +201,110 @@ func (mrc *machineReconcileContext) imageURL() (string, error) {
 		imageLookupOSVersion = mrc.tinkerbellCluster.Spec.ImageLookupOSVersion
 	}
 
-	return imageURL(
-		imageLookupFormat,
+	imageParams := newImage(
 		imageLookupBaseRegistry,
 		imageLookupOSDistro,
 		imageLookupOSVersion,
 		*mrc.machine.Spec.Version,
+		hardwareArch(hardware),
+		hardwareFirmware(hardware),
 	)
-}
 
-func (mrc *machineReconcileContext) createTemplate(hardware *tinkv1.Hardware) error {
-	if len(hardware.Status.Disks) < 1 {
-		return ErrHardwareMissingDiskConfiguration
+	if !mrc.tinkerbellCluster.Spec.ImageLookupVerify {
+		return imageParams.url(imageLookupFormat)
 	}
 
-	templateData := mrc.tinkerbellMachine.Spec.TemplateOverride
-	if templateData == "" {
-		targetDisk := hardware.Status.Disks[0].Device
-		targetDevice := firstPartitionFromDevice(targetDisk)
+	imageLookupFormats := append([]string{imageLookupFormat}, imageLookupFallbackFormats...)
 
-		imageURL, err := mrc.imageURL()
-		if err != nil {
-			return fmt.Errorf("failed to generate imageURL: %w", err)
+	imageURL, err := mrc.availableImageURL(imageParams, imageLookupFormats)
+	if err != nil {
+		reason := infrastructurev1.ImageLookupFailedReason
+		if errors.Is(err, ErrImageNotAvailable) {
+			reason = infrastructurev1.ImageNotFoundReason
 		}
 
-		metadataIP := os.Getenv("TINKERBELL_IP")
-		if metadataIP == "" {
-			metadataIP = "192.168.1.1"
+		conditions.MarkFalse(mrc.tinkerbellMachine, infrastructurev1.ImageAvailableCondition, reason,
+			clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		mrc.recorder.Event(mrc.tinkerbellMachine, corev1.EventTypeWarning, reason, err.Error())
+
+		if patchErr := mrc.patch(); patchErr != nil {
+			mrc.log.Error(patchErr, "Failed to patch TinkerbellMachine with image availability condition")
 		}
 
-		metadataURL := fmt.Sprintf("http://%s:50061", metadataIP)
+		return "", err
+	}
+
+	conditions.MarkTrue(mrc.tinkerbellMachine, infrastructurev1.ImageAvailableCondition)
+
+	return imageURL, nil
+}
+
+// availableImageURL returns the first available image URL rendered from given formats.
+func (mrc *machineReconcileContext) availableImageURL(imageParams image, imageLookupFormats []string) (string, error) {
+	credentials, err := mrc.imageLookupCredentials()
+	if err != nil {
+		return "", fmt.Errorf("getting image lookup credentials: %w", err)
+	}
+
+	imageURLs := []string{}
 
-		workflowTemplate := templates.WorkflowTemplate{
-			Name:          mrc.tinkerbellMachine.Name,
-			MetadataURL:   metadataURL,
-			ImageURL:      imageURL,
-			DestDisk:      targetDisk,
-			DestPartition: targetDevice,
+	for _, imageLookupFormat := range imageLookupFormats {
+		imageURL, err := imageParams.url(imageLookupFormat)
+		if err != nil {
+			return "", err
 		}
 
-		templateData, err = workflowTemplate.Render()
+		available, err := mrc.imageResolver.Available(mrc.ctx, imageURL, images.Options{
+			Credentials:         credentials,
+			PlainHTTPRegistries: mrc.tinkerbellCluster.Spec.ImageLookupPlainHTTPRegistries,
+		})
 		if err != nil {
-			return fmt.Errorf("rendering template: %w", err)
+			return "", fmt.Errorf("checking availability of image %q: %w", imageURL, err)
+		}
+
+		if available {
+			if len(imageURLs) > 0 {
+				mrc.log.Info("Falling back to available image", "imageURL", imageURL, "unavailable", imageURLs)
+			}
+
+			return imageURL, nil
 		}
+
+		imageURLs = append(imageURLs, imageURL)
+	}
+
+	return "", fmt.Errorf("%w: tried %s", ErrImageNotAvailable, strings.Join(imageURLs, ", "))
+}
+
+func (mrc *machineReconcileContext) imageLookupCredentials() (*images.Credentials, error) {
+	credentialsRef := mrc.tinkerbellCluster.Spec.ImageLookupCredentialsRef
+	if credentialsRef == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+
+	namespacedName := types.NamespacedName{
+		Namespace: mrc.tinkerbellCluster.Namespace,
+		Name:      credentialsRef.Name,
+	}
+
+	if err := mrc.client.Get(mrc.ctx, namespacedName, secret); err != nil {
+		return nil, fmt.Errorf("getting Secret %q: %w", namespacedName, err)
+	}
+
+	credentials, err := images.CredentialsFromSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Secret %q: %w", namespacedName, err)
+	}
+
+	return credentials, nil
+}
+
+func (mrc *machineReconcileContext) createTemplate(hardware *tinkv1.Hardware) error {
+	templateData, err := mrc.templateData(hardware)
+	if err != nil {
+		return err
 	}
 
 	templateObject := &tinkv1.Template{
@@ -225,18 +328,164 @@ func (mrc *machineReconcileContext) createTemplate(hardware *tinkv1.Hardware) er
 		return fmt.Errorf("creating Tinkerbell template: %w", err)
 	}
 
+	mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "TemplateRendered",
+		"Rendered Template %s for Hardware %s", templateObject.Name, hardware.Name)
+
 	return nil
 }
 
+// templateData returns the data of the Tinkerbell template provisioning the Hardware, which is either
+// the template override of the machine or the default template.
+func (mrc *machineReconcileContext) templateData(hardware *tinkv1.Hardware) (string, error) {
+	if len(hardware.Status.Disks) < 1 {
+		return "", ErrHardwareMissingDiskConfiguration
+	}
+
+	if templateOverride := mrc.tinkerbellMachine.Spec.TemplateOverride; templateOverride != "" {
+		return templateOverride, nil
+	}
+
+	workflowTemplate, err := mrc.workflowTemplate(hardware)
+	if err != nil {
+		return "", err
+	}
+
+	if ref := mrc.tinkerbellMachine.Spec.TemplateOverrideRef; ref != nil {
+		templateOverride, err := mrc.templateOverride(ref)
+		if err != nil {
+			return "", fmt.Errorf("getting template override: %w", err)
+		}
+
+		templateData, err := workflowTemplate.RenderOverride(templateOverride)
+		if err != nil {
+			return "", fmt.Errorf("rendering template override: %w", err)
+		}
+
+		return templateData, nil
+	}
+
+	templateData, err := workflowTemplate.Render()
+	if err != nil {
+		return "", fmt.Errorf("rendering template: %w", err)
+	}
+
+	return templateData, nil
+}
+
+// hardwareDisks returns the devices of the disks of the Hardware.
+func hardwareDisks(hardware *tinkv1.Hardware) []string {
+	disks := make([]string, 0, len(hardware.Status.Disks))
+
+	for _, disk := range hardware.Status.Disks {
+		disks = append(disks, disk.Device)
+	}
+
+	return disks
+}
+
+// workflowTemplate returns the data for rendering the Tinkerbell template provisioning the Hardware.
+func (mrc *machineReconcileContext) workflowTemplate(hardware *tinkv1.Hardware) (templates.WorkflowTemplate, error) {
+	targetDisk := hardware.Status.Disks[0].Device
+	if mrc.tinkerbellMachine.Spec.RAID != nil {
+		targetDisk = raidDevice
+	}
+
+	targetDevice := rootPartitionDevice(targetDisk, mrc.tinkerbellMachine.Spec.Storage)
+
+	var (
+		imageURL         string
+		imageSHA256      string
+		imageCompression infrastructurev1.ImageCompression
+		err              error
+	)
+
+	preserveDataPartitions := mrc.preserveDataPartitions(hardware)
+
+	switch {
+	case preserveDataPartitions:
+		imageURL, err = mrc.imageURL(hardware, mrc.tinkerbellMachine.Spec.HardwareReuse.RootPartitionImageLookupFormat)
+		if err != nil {
+			return templates.WorkflowTemplate{}, fmt.Errorf("failed to generate root partition imageURL: %w", err)
+		}
+	case mrc.tinkerbellMachine.Spec.ImageCatalogRef != nil:
+		image, err := mrc.catalogImage(hardware)
+		if err != nil {
+			return templates.WorkflowTemplate{}, fmt.Errorf("selecting image from catalog: %w", err)
+		}
+
+		imageURL, imageSHA256, imageCompression = image.URL, image.SHA256, image.Compression
+
+		conditions.MarkTrue(mrc.tinkerbellMachine, infrastructurev1.ImageAvailableCondition)
+	default:
+		imageURL, err = mrc.imageURL(hardware, "")
+		if err != nil {
+			return templates.WorkflowTemplate{}, fmt.Errorf("failed to generate imageURL: %w", err)
+		}
+	}
+
+	metadataIP := os.Getenv("TINKERBELL_IP")
+	if metadataIP == "" {
+		metadataIP = "192.168.1.1"
+	}
+
+	metadataURL := fmt.Sprintf("http://%s:50061", metadataIP)
+
+	workflowTemplate := templates.WorkflowTemplate{
+		Name:             mrc.tinkerbellMachine.Name,
+		MetadataURL:      metadataURL,
+		ImageURL:         imageURL,
+		ImageSHA256:      imageSHA256,
+		ImageCompression: string(imageCompression),
+		Arch:             hardwareArch(hardware),
+		DestDisk:         targetDisk,
+		DestPartition:    targetDevice,
+		BootstrapFormat:  mrc.bootstrapFormat,
+		BootMode:         templates.BootMode(mrc.tinkerbellMachine.Spec.BootMode),
+		OEMPartition:     partitionFromDevice(targetDisk, flatcarOEMPartitionNumber),
+		Hardware:         hardware,
+		Disks:            hardwareDisks(hardware),
+
+		PreserveDataPartitions: preserveDataPartitions,
+	}
+
+	applyStorageLayout(&workflowTemplate, mrc.tinkerbellMachine.Spec.Storage)
+
+	if raid := mrc.tinkerbellMachine.Spec.RAID; raid != nil {
+		workflowTemplate.RAIDDevices = raid.Devices
+		workflowTemplate.RAIDLevel = raid.Level
+	}
+
+	workflowTemplate.PreInstallActions, err = mrc.workflowActions(mrc.tinkerbellMachine.Spec.PreInstallActions)
+	if err != nil {
+		return templates.WorkflowTemplate{}, fmt.Errorf("preparing pre-install actions: %w", err)
+	}
+
+	workflowTemplate.PostInstallActions, err = mrc.workflowActions(mrc.tinkerbellMachine.Spec.PostInstallActions)
+	if err != nil {
+		return templates.WorkflowTemplate{}, fmt.Errorf("preparing post-install actions: %w", err)
+	}
+
+	if err := mrc.applyDefaultUser(&workflowTemplate); err != nil {
+		return templates.WorkflowTemplate{}, fmt.Errorf("configuring default user: %w", err)
+	}
+
+	return workflowTemplate, nil
+}
+
 func firstPartitionFromDevice(device string) string {
+	return partitionFromDevice(device, 1)
+}
+
+func partitionFromDevice(device string, partition int) string {
 	nvmeDevice := regexp.MustCompile(`^/dev/nvme\d+n\d+$`)
 	emmcDevice := regexp.MustCompile(`^/dev/mmcblk\d+$`)
+	mdDevice := regexp.MustCompile(`^/dev/md\d+$`)
 
 	switch {
-	case nvmeDevice.MatchString(device), emmcDevice.MatchString(device):
-		return fmt.Sprintf("%sp1", device)
+	case nvmeDevice.MatchString(device), emmcDevice.MatchString(device), mdDevice.MatchString(device):
+		return fmt.Sprintf("%sp%d", device, partition)
 	default:
-		return fmt.Sprintf("%s1", device)
+		return fmt.Sprintf("%s%d", device, partition)
 	}
 }
 
@@ -248,6 +497,10 @@ func (mrc *machineReconcileContext) ensureTemplate(hardware *tinkv1.Hardware) er
 	}
 
 	if templateExists {
+		if mrc.tinkerbellMachine.Spec.TemplateOverrideRef != nil {
+			return mrc.ensureTemplateOverrideRendered(hardware)
+		}
+
 		return nil
 	}
 
@@ -308,7 +561,26 @@ func (mrc *machineReconcileContext) setStatus(hardware *tinkv1.Hardware) error {
 }
 
 func (mrc *machineReconcileContext) ensureHardwareUserData(hardware *tinkv1.Hardware, providerID string) error {
-	userData := strings.ReplaceAll(mrc.bootstrapCloudConfig, providerIDPlaceholder, providerID)
+	placeholders, err := mrc.nodePlaceholders(hardware)
+	if err != nil {
+		conditions.MarkFalse(mrc.tinkerbellMachine, infrastructurev1.NodeLabelsAndTaintsValidCondition,
+			infrastructurev1.InvalidNodeLabelsOrTaintsReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		mrc.recorder.Event(mrc.tinkerbellMachine, corev1.EventTypeWarning,
+			infrastructurev1.InvalidNodeLabelsOrTaintsReason, err.Error())
+
+		if patchErr := mrc.patch(); patchErr != nil {
+			mrc.log.Error(patchErr, "Failed to patch TinkerbellMachine with Node labels and taints condition")
+		}
+
+		return err
+	}
+
+	placeholders[providerIDPlaceholder] = providerID
+
+	userData, err := userDataWithPlaceholders(mrc.bootstrapFormat, mrc.bootstrapData, placeholders)
+	if err != nil {
+		return fmt.Errorf("substituting placeholders in bootstrap data: %w", err)
+	}
 
 	if hardware.Spec.UserData == nil || *hardware.Spec.UserData != userData {
 		patchHelper, err := patch.NewHelper(hardware, mrc.client)
@@ -326,9 +598,59 @@ func (mrc *machineReconcileContext) ensureHardwareUserData(hardware *tinkv1.Hard
 	return nil
 }
 
+// nodePlaceholders returns the Node labels and taints placeholders with values taken from the Hardware.
+// Placeholders are substituted only if the machine takes labels or taints from the Hardware, so bootstrap
+// data of other machines is left as is.
+func (mrc *machineReconcileContext) nodePlaceholders(hardware *tinkv1.Hardware) (map[string]string, error) {
+	placeholders := map[string]string{}
+	spec := mrc.tinkerbellMachine.Spec
+
+	if len(spec.NodeLabelsFromHardware) == 0 && len(spec.NodeTaintsFromHardware) == 0 {
+		return placeholders, nil
+	}
+
+	if len(spec.NodeLabelsFromHardware) > 0 {
+		labels, err := nodeLabelsFromHardware(hardware, spec.NodeLabelsFromHardware)
+		if err != nil {
+			return nil, err
+		}
+
+		placeholders[nodeLabelsPlaceholder] = labels
+	}
+
+	if len(spec.NodeTaintsFromHardware) > 0 {
+		taints, err := nodeTaintsFromHardware(hardware, spec.NodeTaintsFromHardware)
+		if err != nil {
+			return nil, err
+		}
+
+		placeholders[nodeTaintsPlaceholder] = taints
+	}
+
+	conditions.MarkTrue(mrc.tinkerbellMachine, infrastructurev1.NodeLabelsAndTaintsValidCondition)
+
+	return placeholders, nil
+}
+
 func (mrc *machineReconcileContext) ensureHardware() (*tinkv1.Hardware, error) {
 	hardware, err := mrc.hardwareForMachine()
 	if err != nil {
+		if errors.Is(err, ErrNoHardwareAvailable) {
+			if err := mrc.waitForHardware(); err != nil {
+				return nil, fmt.Errorf("waiting for hardware: %w", err)
+			}
+
+			// Expiring reservations are not observed by the Hardware watch.
+			next, err := mrc.nextReservationExpiry()
+			if err != nil {
+				return nil, fmt.Errorf("checking Hardware reservations: %w", err)
+			}
+
+			if next > 0 {
+				return nil, &requeueAfterError{after: next}
+			}
+		}
+
 		return nil, fmt.Errorf("getting hardware: %w", err)
 	}
 
@@ -336,61 +658,137 @@ func (mrc *machineReconcileContext) ensureHardware() (*tinkv1.Hardware, error) {
 		return nil, fmt.Errorf("taking Hardware ownership: %w", err)
 	}
 
+	if mrc.adopting() && !conditions.IsTrue(mrc.tinkerbellMachine, infrastructurev1.HardwareSelectedCondition) {
+		mrc.log.Info("Adopted Hardware for machine", "Hardware name", hardware.Name)
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "HardwareAdopted",
+			"Adopted Hardware %s", hardware.Name)
+	}
+
 	if mrc.tinkerbellMachine.Spec.HardwareName == "" {
 		mrc.log.Info("Selected Hardware for machine", "Hardware name", hardware.Name)
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "HardwareClaimed",
+			"Claimed Hardware %s", hardware.Name)
+	}
+
+	if !conditions.IsTrue(mrc.tinkerbellMachine, infrastructurev1.HardwareSelectedCondition) {
+		conditions.MarkTrue(mrc.tinkerbellMachine, infrastructurev1.HardwareSelectedCondition)
 	}
 
 	mrc.tinkerbellMachine.Spec.HardwareName = hardware.Name
-	mrc.tinkerbellMachine.Spec.ProviderID = fmt.Sprintf("tinkerbell://%s", hardware.Spec.ID)
+	mrc.tinkerbellMachine.Status.WaitingForHardwareSince = nil
+
+	if !mrc.adopting() || mrc.tinkerbellMachine.Spec.ProviderID == "" {
+		mrc.tinkerbellMachine.Spec.ProviderID = fmt.Sprintf("tinkerbell://%s", hardware.Spec.ID)
+	}
 
-	if err := mrc.ensureHardwareUserData(hardware, mrc.tinkerbellMachine.Spec.ProviderID); err != nil {
-		return nil, fmt.Errorf("ensuring Hardware user data: %w", err)
+	if !mrc.adopting() {
+		if err := mrc.ensureHardwareUserData(hardware, mrc.tinkerbellMachine.Spec.ProviderID); err != nil {
+			return nil, fmt.Errorf("ensuring Hardware user data: %w", err)
+		}
 	}
 
 	return hardware, mrc.setStatus(hardware)
 }
 
 func (mrc *machineReconcileContext) hardwareForMachine() (*tinkv1.Hardware, error) {
-	alreadySelectedHardwareSelector := []string{
-		fmt.Sprintf("%s=%s", HardwareOwnerNameLabel, mrc.tinkerbellMachine.Name),
-		fmt.Sprintf("%s=%s", HardwareOwnerNamespaceLabel, mrc.tinkerbellMachine.Namespace),
-	}
+	owner := namespacedKey(mrc.tinkerbellMachine.Namespace, mrc.tinkerbellMachine.Name)
 
-	alreadySelectedHardware, err := nextHardware(mrc.ctx, mrc.client, alreadySelectedHardwareSelector)
+	alreadySelectedHardware, err := listHardware(mrc.ctx, mrc.client, owner)
 	if err != nil {
 		return nil, fmt.Errorf("checking if hardware has already been selected: %w", err)
 	}
 
 	// If we already selected Hardware but we failed to commit this information into TinkerbellMachine object,
 	// this allows to pick up the process from where we left.
-	if alreadySelectedHardware != nil {
-		return alreadySelectedHardware, nil
+	if len(alreadySelectedHardware) > 0 {
+		return &alreadySelectedHardware[0], nil
+	}
+
+	if mrc.adopting() {
+		return mrc.adoptedHardware()
+	}
+
+	group := mrc.hardwareReuseGroup()
+	filters := hardwareFilters(mrc.tinkerbellMachine, mrc.machine, mrc.tinkerbellCluster, mrc.hardwareSelector)
+
+	if group != "" {
+		hardware, err := mrc.selectReleasedHardware(group, filters)
+		if err != nil || hardware != nil {
+			return hardware, err
+		}
 	}
 
-	return nextAvailableHardware(mrc.ctx, mrc.client, nil)
+	return mrc.selectAvailableHardware(filters...)
 }
 
-func (mrc *machineReconcileContext) workflowExists() (bool, error) {
-	namespacedName := types.NamespacedName{
-		Name: mrc.tinkerbellMachine.Name,
+// hardwareFilters returns the filters of Hardware, which may be selected for the TinkerbellMachine of given
+// Machine, cluster and machine pool Hardware selector. They are shared by the Hardware selection and the
+// Hardware queue, so machines compete only for Hardware they may select.
+func hardwareFilters(
+	tinkerbellMachine *infrastructurev1.TinkerbellMachine,
+	machine *clusterv1.Machine,
+	tinkerbellCluster *infrastructurev1.TinkerbellCluster,
+	hardwareSelector labels.Selector,
+) []hardwareFilter {
+	group := ""
+	if tinkerbellMachine.Spec.HardwareReuse != nil {
+		group = hardwareReuseGroup(tinkerbellMachine, machine)
 	}
 
-	err := mrc.client.Get(mrc.ctx, namespacedName, &tinkv1.Workflow{})
-	if err == nil {
-		return true, nil
+	filters := []hardwareFilter{hardwareNotQuarantined, hardwareNotInMaintenance, hardwareNotReservedForOthers(group)}
+
+	if arch := tinkerbellMachine.Spec.Arch; arch != "" {
+		filters = append(filters, hardwareWithArch(arch))
 	}
 
-	if !apierrors.IsNotFound(err) {
-		return false, fmt.Errorf("checking if workflow exists: %w", err)
+	if hardwareSelector != nil {
+		filters = append(filters, hardwareMatchingSelector(hardwareSelector))
 	}
 
-	return false, nil
+	if raid := tinkerbellMachine.Spec.RAID; raid != nil {
+		filters = append(filters, hardwareWithDisks(raid.Devices))
+	}
+
+	if tinkerbellCluster != nil && tinkerbellCluster.Spec.FailureDomainLabelKey != "" &&
+		machine != nil && machine.Spec.FailureDomain != nil {
+		filters = append(filters, hardwareInFailureDomain(tinkerbellCluster.Spec.FailureDomainLabelKey,
+			*machine.Spec.FailureDomain))
+	}
+
+	if tinkerbellCluster != nil && tinkerbellCluster.Spec.BMC != nil {
+		filters = append(filters, hardwareNotBMCWorker(tinkerbellCluster.Spec.BMC.WorkerHardwareName))
+	}
+
+	return filters
 }
 
-func (mrc *machineReconcileContext) createWorkflow() error {
+// getWorkflow returns the workflow with given name running on the given hardware. If it does not exist,
+// nil is returned.
+func (mrc *machineReconcileContext) getWorkflow(name, hardwareName string) (*tinkv1.Workflow, error) {
+	workflows := &tinkv1.WorkflowList{}
+
+	if err := mrc.client.List(mrc.ctx, workflows, client.MatchingFields{WorkflowHardwareIndex: hardwareName}); err != nil {
+		return nil, fmt.Errorf("checking if workflow exists: %w", err)
+	}
+
+	for i := range workflows.Items {
+		workflow := &workflows.Items[i]
+
+		if workflow.Name == name && indexed(workflow, workflowHardwareIndexFunc, hardwareName) {
+			return workflow, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// createWorkflow creates workflow with given name and annotations running the template of the same name
+// on the given hardware.
+func (mrc *machineReconcileContext) createWorkflow(name, hardwareName string, annotations map[string]string) error {
 	workflow := &tinkv1.Workflow{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: mrc.tinkerbellMachine.Name,
+			Name:        name,
+			Annotations: annotations,
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1",
@@ -401,8 +799,8 @@ func (mrc *machineReconcileContext) createWorkflow() error {
 			},
 		},
 		Spec: tinkv1.WorkflowSpec{
-			TemplateRef: mrc.tinkerbellMachine.Name,
-			HardwareRef: mrc.tinkerbellMachine.Spec.HardwareName,
+			TemplateRef: name,
+			HardwareRef: hardwareName,
 		},
 	}
 
@@ -410,39 +808,198 @@ func (mrc *machineReconcileContext) createWorkflow() error {
 		return fmt.Errorf("creating workflow: %w", err)
 	}
 
+	mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "WorkflowCreated",
+		"Created Workflow %s", workflow.Name)
+
 	return nil
 }
 
-func (mrc *machineReconcileContext) ensureWorkflow() error {
-	workflowExists, err := mrc.workflowExists()
+func (mrc *machineReconcileContext) ensureWorkflow(hardware *tinkv1.Hardware) error {
+	workflow, err := mrc.getWorkflow(mrc.tinkerbellMachine.Name, mrc.tinkerbellMachine.Spec.HardwareName)
 	if err != nil {
-		return fmt.Errorf("checking if workflow exists: %w", err)
+		return fmt.Errorf("getting workflow: %w", err)
+	}
+
+	switch {
+	case workflow == nil:
+		mrc.log.Info("Workflow does not exist, creating")
+
+		// Netboot must be allowed for the hardware to pick up the workflow.
+		if err := mrc.ensureHardwareNetboot(hardware, true); err != nil {
+			return fmt.Errorf("enabling netboot: %w", err)
+		}
+
+		if mrc.reprovisionRequested() {
+			return mrc.createReprovisioningWorkflow()
+		}
+
+		if err := mrc.createWorkflow(mrc.tinkerbellMachine.Name, mrc.tinkerbellMachine.Spec.HardwareName, nil); err != nil {
+			return err
+		}
+
+		// Only initial provisioning is observed, as reprovisioning reuses already selected Hardware.
+		if !mrc.tinkerbellMachine.Status.Ready {
+			observePhaseSince(metrics.PhaseWorkflowCreation,
+				conditions.GetLastTransitionTime(mrc.tinkerbellMachine, infrastructurev1.HardwareSelectedCondition))
+		}
+	case !workflow.DeletionTimestamp.IsZero():
+		mrc.log.Info("Workflow is being removed, waiting")
+
+		return nil
+	case mrc.reprovisionRequested():
+		return mrc.reprovision(workflow)
+	case powerCyclePending(workflow):
+		return mrc.restartIntoWorkflow(hardware, workflow)
+	case workflowFailed(workflow):
+		return mrc.recordProvisioningFailure(hardware, workflow)
+	case workflow.Status.State == tinkv1.WorkflowStateSuccess, workflowHandedOver(workflow):
+		return mrc.ensureProvisioned(hardware, workflow)
+	}
+
+	return mrc.waitForBoot()
+}
+
+// ensureProvisioned boots the machine into the installed OS once the provisioning workflow succeeded. Provisioning
+// workflows restarting the machine themselves hand over without the boot workflow. Pending reboot is performed
+// only once the boot workflow handed over, as it would interrupt it.
+func (mrc *machineReconcileContext) ensureProvisioned(hardware *tinkv1.Hardware, workflow *tinkv1.Workflow) error {
+	// Netboot is disabled only once after the workflow succeeds, so it's a good point for
+	// observing the workflow execution.
+	if hardware.Spec.AllowPXE == nil || *hardware.Spec.AllowPXE {
+		observePhaseSince(metrics.PhaseWorkflowExecution, &workflow.CreationTimestamp)
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "WorkflowSucceeded",
+			"Workflow %s succeeded, disabling netboot for Hardware %s", workflow.Name, hardware.Name)
 	}
 
-	if workflowExists {
+	if !workflowHandedOver(workflow) {
+		booting, err := mrc.ensureBootWorkflow(hardware)
+		if err != nil {
+			return err
+		}
+
+		if !booting {
+			return mrc.waitForBoot()
+		}
+	}
+
+	// Once the machine boots into the installed OS, disable netboot and workflows, so rebooting machine
+	// does not re-enter the installation environment and pick up the workflow again.
+	if err := mrc.ensureHardwareNetboot(hardware, false); err != nil {
+		return fmt.Errorf("disabling netboot: %w", err)
+	}
+
+	if mrc.rebootRequested() {
+		return mrc.reboot(hardware)
+	}
+
+	return mrc.ensureBooted(hardware)
+}
+
+// ensureHardwareNetboot sets desired allowPXE and allowWorkflow settings on the Hardware, which are
+// then propagated into Tinkerbell by the Hardware controller.
+func (mrc *machineReconcileContext) ensureHardwareNetboot(hardware *tinkv1.Hardware, allow bool) error {
+	if hardware.Spec.AllowPXE != nil && *hardware.Spec.AllowPXE == allow &&
+		hardware.Spec.AllowWorkflow != nil && *hardware.Spec.AllowWorkflow == allow {
 		return nil
 	}
 
-	mrc.log.Info("Workflow does not exist, creating")
+	patchHelper, err := patch.NewHelper(hardware, mrc.client)
+	if err != nil {
+		return fmt.Errorf("initializing patch helper for selected hardware: %w", err)
+	}
+
+	hardware.Spec.AllowPXE = pointer.BoolPtr(allow)
+	hardware.Spec.AllowWorkflow = pointer.BoolPtr(allow)
+
+	if err := patchHelper.Patch(mrc.ctx, hardware); err != nil {
+		return fmt.Errorf("patching Hardware object: %w", err)
+	}
+
+	mrc.log.Info("Updated Hardware netboot settings", "Hardware name", hardware.Name, "allow", allow)
+
+	return nil
+}
+
+// observePhaseSince records duration of the provisioning phase started at given time.
+func observePhaseSince(phase string, start *metav1.Time) {
+	if start == nil || start.IsZero() {
+		return
+	}
+
+	metrics.ProvisioningPhaseDuration.WithLabelValues(phase).Observe(time.Since(start.Time).Seconds())
+}
+
+func (mrc *machineReconcileContext) reprovisionRequested() bool {
+	_, ok := mrc.tinkerbellMachine.Annotations[infrastructurev1.ReprovisionAnnotation]
 
-	return mrc.createWorkflow()
+	return ok
+}
+
+// reprovision removes existing workflow, so it gets recreated with netboot enabled on
+// the next reconciliation.
+func (mrc *machineReconcileContext) reprovision(workflow *tinkv1.Workflow) error {
+	mrc.log.Info("Reprovisioning requested, removing Workflow", "name", workflow.Name)
+	mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "Reprovisioning",
+		"Reprovisioning requested, removing Workflow %s", workflow.Name)
+
+	if err := mrc.client.Delete(mrc.ctx, workflow); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("removing workflow: %w", err)
+	}
+
+	return mrc.removeBootWorkflow()
+}
+
+// createReprovisioningWorkflow recreates the provisioning workflow marked for restarting the machine
+// into the installation environment, so the machine runs it. Pending reboot is abandoned, as the machine
+// gets reinstalled anyway.
+func (mrc *machineReconcileContext) createReprovisioningWorkflow() error {
+	annotations := map[string]string{powerCycleAnnotation: ""}
+
+	if err := mrc.createWorkflow(mrc.tinkerbellMachine.Name, mrc.tinkerbellMachine.Spec.HardwareName,
+		annotations); err != nil {
+		return err
+	}
+
+	delete(mrc.tinkerbellMachine.Annotations, infrastructurev1.ReprovisionAnnotation)
+
+	if mrc.rebootRequested() {
+		return mrc.finishReboot()
+	}
+
+	return mrc.patch()
 }
 
 type image struct {
-	BaseRegistry      string
-	OSDistro          string
-	OSVersion         string
-	KubernetesVersion string
+	BaseRegistry           string
+	OSDistro               string
+	OSVersion              string
+	KubernetesVersion      string
+	KubernetesMinorVersion string
+	Arch                   string
+	Firmware               infrastructurev1.Firmware
 }
 
-func imageURL(imageFormat, baseRegistry, osDistro, osVersion, kubernetesVersion string) (string, error) {
-	imageParams := image{
-		BaseRegistry:      baseRegistry,
-		OSDistro:          strings.ToLower(osDistro),
-		OSVersion:         strings.ReplaceAll(osVersion, ".", ""),
-		KubernetesVersion: kubernetesVersion,
+func newImage(
+	baseRegistry, osDistro, osVersion, kubernetesVersion, arch string,
+	firmware infrastructurev1.Firmware,
+) image {
+	kubernetesMinorVersion := kubernetesVersion
+	if parts := strings.SplitN(kubernetesVersion, ".", 3); len(parts) == 3 { //nolint:gomnd
+		kubernetesMinorVersion = parts[0] + "." + parts[1]
 	}
 
+	return image{
+		BaseRegistry:           baseRegistry,
+		OSDistro:               strings.ToLower(osDistro),
+		OSVersion:              strings.ReplaceAll(osVersion, ".", ""),
+		KubernetesVersion:      kubernetesVersion,
+		KubernetesMinorVersion: kubernetesMinorVersion,
+		Arch:                   arch,
+		Firmware:               firmware,
+	}
+}
+
+func (i image) url(imageFormat string) (string, error) {
 	var buf bytes.Buffer
 
 	template, err := template.New("image").Parse(imageFormat)
@@ -450,7 +1007,7 @@ func imageURL(imageFormat, baseRegistry, osDistro, osVersion, kubernetesVersion
 		return "", fmt.Errorf("failed to create template from string %q: %w", imageFormat, err)
 	}
 
-	if err := template.Execute(&buf, imageParams); err != nil {
+	if err := template.Execute(&buf, i); err != nil {
 		return "", fmt.Errorf("failed to populate template %q: %w", imageFormat, err)
 	}
 
@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	exputil "sigs.k8s.io/cluster-api/exp/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// tinkerbellMachinePoolKind is the kind of TinkerbellMachinePool controlling its TinkerbellMachines.
+const tinkerbellMachinePoolKind = "TinkerbellMachinePool"
+
+// hardwareMatchingSelector selects Hardware with labels matching the selector.
+func hardwareMatchingSelector(selector labels.Selector) hardwareFilter {
+	return func(hardware *tinkv1.Hardware) bool {
+		return selector.Matches(labels.Set(hardware.Labels))
+	}
+}
+
+// getTinkerbellMachinePool returns the TinkerbellMachinePool controlling given TinkerbellMachine. If the
+// machine is not a member of a machine pool, nil is returned.
+func getTinkerbellMachinePool(
+	ctx context.Context,
+	c client.Client,
+	tinkerbellMachine *infrastructurev1.TinkerbellMachine,
+) (*infrastructurev1.TinkerbellMachinePool, error) {
+	ref := metav1.GetControllerOf(tinkerbellMachine)
+	if ref == nil || ref.Kind != tinkerbellMachinePoolKind {
+		return nil, nil
+	}
+
+	pool := &infrastructurev1.TinkerbellMachinePool{}
+	key := client.ObjectKey{Namespace: tinkerbellMachine.Namespace, Name: ref.Name}
+
+	if err := c.Get(ctx, key, pool); err != nil {
+		return nil, fmt.Errorf("getting TinkerbellMachinePool: %w", err)
+	}
+
+	return pool, nil
+}
+
+// machinePoolHardwareSelector returns the selector of Hardware, which may be claimed by members of
+// the machine pool. If the pool does not restrict Hardware, nil is returned.
+func machinePoolHardwareSelector(pool *infrastructurev1.TinkerbellMachinePool) (labels.Selector, error) {
+	if pool == nil || pool.Spec.HardwareSelector == nil {
+		return nil, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.HardwareSelector)
+	if err != nil {
+		return nil, fmt.Errorf("parsing hardware selector of TinkerbellMachinePool %q: %w", pool.Name, err)
+	}
+
+	return selector, nil
+}
+
+// getReadyMachinePoolMachine returns Machine describing the member of given machine pool. Members
+// of machine pools do not have their own Machine, so it is built from the template of the MachinePool
+// owning the pool.
+//
+// If the MachinePool is not ready yet, nil is returned.
+func (bmrc *baseMachineReconcileContext) getReadyMachinePoolMachine(
+	pool *infrastructurev1.TinkerbellMachinePool,
+) (*clusterv1.Machine, error) {
+	machinePool, err := exputil.GetOwnerMachinePool(bmrc.ctx, bmrc.client, pool.ObjectMeta)
+	if err != nil {
+		return nil, fmt.Errorf("getting MachinePool object: %w", err)
+	}
+
+	if machinePool == nil {
+		bmrc.log.Info("machine pool is not ready yet", "reason", "MachinePool Controller has not yet set OwnerRef")
+
+		return nil, nil
+	}
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bmrc.tinkerbellMachine.Name,
+			Namespace: bmrc.tinkerbellMachine.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: machinePool.Spec.ClusterName,
+			},
+		},
+		Spec: *machinePool.Spec.Template.Spec.DeepCopy(),
+	}
+
+	reason, err := isMachineReady(machine)
+	if err != nil {
+		return nil, fmt.Errorf("validating MachinePool object: %w", err)
+	}
+
+	if reason != "" {
+		bmrc.log.Info("machine pool is not ready yet", "reason", reason)
+
+		return nil, nil
+	}
+
+	return machine, nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/metrics"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+const (
+	hardwareStateFree  = "free"
+	hardwareStateOwned = "owned"
+
+	collectTimeout = 10 * time.Second
+)
+
+// HardwarePoolCollector collects Hardware inventory metrics on every scrape.
+type HardwarePoolCollector struct {
+	client    client.Reader
+	labelKeys []string
+	log       logr.Logger
+
+	total *prometheus.Desc
+	free  *prometheus.Desc
+	owned *prometheus.Desc
+	label *prometheus.Desc
+}
+
+// NewHardwarePoolCollector returns collector reporting total, free and per cluster owned Hardware.
+// For each of given label keys, Hardware is additionally counted by the label value.
+func NewHardwarePoolCollector(reader client.Reader, log logr.Logger, labelKeys []string) *HardwarePoolCollector {
+	return &HardwarePoolCollector{
+		client:    reader,
+		labelKeys: labelKeys,
+		log:       log,
+		total: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "hardware", "total"),
+			"Number of Hardware objects.",
+			nil, nil,
+		),
+		free: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "hardware", "free"),
+			"Number of Hardware objects not owned by any TinkerbellMachine.",
+			nil, nil,
+		),
+		owned: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "hardware", "owned"),
+			"Number of Hardware objects owned by TinkerbellMachines, by cluster.",
+			[]string{"namespace", "cluster"}, nil,
+		),
+		label: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "hardware", "by_label"),
+			"Number of Hardware objects by label value and ownership state.",
+			[]string{"label", "value", "state"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *HardwarePoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.total
+	ch <- c.free
+	ch <- c.owned
+	ch <- c.label
+}
+
+type labelCount struct {
+	label string
+	value string
+	state string
+}
+
+// Collect implements prometheus.Collector.
+func (c *HardwarePoolCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	hardwareList := &tinkv1.HardwareList{}
+	if err := c.client.List(ctx, hardwareList); err != nil {
+		c.log.Error(err, "Failed to list Hardware for metrics")
+
+		return
+	}
+
+	free := 0
+	owned := map[types.NamespacedName]int{}
+	byLabel := map[labelCount]int{}
+
+	for i := range hardwareList.Items {
+		hardware := &hardwareList.Items[i]
+
+		state := hardwareStateFree
+
+		if ownerName, ok := hardware.Labels[HardwareOwnerNameLabel]; ok {
+			state = hardwareStateOwned
+			owned[c.ownerCluster(ctx, ownerName, hardware.Labels[HardwareOwnerNamespaceLabel])]++
+		} else {
+			free++
+		}
+
+		for _, key := range c.labelKeys {
+			if value, ok := hardware.Labels[key]; ok {
+				byLabel[labelCount{label: key, value: value, state: state}]++
+			}
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(len(hardwareList.Items)))
+	ch <- prometheus.MustNewConstMetric(c.free, prometheus.GaugeValue, float64(free))
+
+	for cluster, count := range owned {
+		ch <- prometheus.MustNewConstMetric(c.owned, prometheus.GaugeValue, float64(count),
+			cluster.Namespace, cluster.Name)
+	}
+
+	for lc, count := range byLabel {
+		ch <- prometheus.MustNewConstMetric(c.label, prometheus.GaugeValue, float64(count), lc.label, lc.value, lc.state)
+	}
+}
+
+// ownerCluster returns the cluster of the TinkerbellMachine owning Hardware. If it can't be
+// determined, empty cluster name is returned.
+func (c *HardwarePoolCollector) ownerCluster(ctx context.Context, name, namespace string) types.NamespacedName {
+	cluster := types.NamespacedName{Namespace: namespace}
+
+	tinkerbellMachine := &infrastructurev1.TinkerbellMachine{}
+
+	key := types.NamespacedName{Name: name, Namespace: namespace}
+	if err := c.client.Get(ctx, key, tinkerbellMachine); err == nil {
+		cluster.Name = tinkerbellMachine.Labels[clusterv1.ClusterLabelName]
+	}
+
+	return cluster
+}
+
+const otherReconcileErrorReason = "Other"
+
+// reconcileErrorReasons maps sentinel errors to reasons reported by the reconcile errors metric.
+//
+//nolint:gochecknoglobals
+var reconcileErrorReasons = []struct {
+	err    error
+	reason string
+}{
+	{ErrNoHardwareAvailable, "NoHardwareAvailable"},
+	{ErrHardwareMissingDiskConfiguration, "HardwareMissingDiskConfiguration"},
+	{ErrHardwareIsNil, "HardwareIsNil"},
+	{ErrHardwareMissingInterfaces, "HardwareMissingInterfaces"},
+	{ErrHardwareFirstInterfaceNotDHCP, "HardwareFirstInterfaceNotDHCP"},
+	{ErrHardwareFirstInterfaceDHCPMissingIP, "HardwareFirstInterfaceDHCPMissingIP"},
+	{ErrImageNotAvailable, "ImageNotAvailable"},
+	{ErrNoMatchingCatalogImage, "NoMatchingCatalogImage"},
+	{ErrMachineVersionEmpty, "MachineVersionEmpty"},
+	{ErrMissingBootstrapDataSecretValueKey, "MissingBootstrapDataSecretValueKey"},
+	{ErrBootstrapUserDataEmpty, "BootstrapUserDataEmpty"},
+	{ErrUnsupportedBootstrapDataFormat, "UnsupportedBootstrapDataFormat"},
+	{ErrClusterNotReady, "ClusterNotReady"},
+	{ErrControlPlaneEndpointNotSet, "ControlPlaneEndpointNotSet"},
+	{ErrConfigurationNil, "ConfigurationNil"},
+	{ErrMissingClient, "MissingClient"},
+}
+
+// reconcileErrorReason returns the reason of the reconciliation error based on the wrapped sentinel error.
+func reconcileErrorReason(err error) string {
+	for _, r := range reconcileErrorReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+
+	return otherReconcileErrorReason
+}
+
+// recordReconcileError increments the reconcile errors metric if reconciliation failed.
+func recordReconcileError(controller string, err error) {
+	if err == nil {
+		return
+	}
+
+	metrics.ReconcileErrors.WithLabelValues(controller, reconcileErrorReason(err)).Inc()
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+func Test_Reconcile_error_reason(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		err    error
+		reason string
+	}{
+		"is_taken_from_wrapped_sentinel_error": {
+			err:    fmt.Errorf("ensuring hardware: %w", ErrNoHardwareAvailable),
+			reason: "NoHardwareAvailable",
+		},
+		"is_other_for_unknown_error": {
+			err:    fmt.Errorf("foo"), //nolint:goerr113
+			reason: otherReconcileErrorReason,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			g.Expect(reconcileErrorReason(c.err)).To(Equal(c.reason))
+		})
+	}
+}
+
+//nolint:funlen
+func Test_Hardware_pool_collector(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(infrastructurev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(tinkv1.AddToScheme(scheme)).To(Succeed())
+
+	objects := []runtime.Object{
+		&tinkv1.Hardware{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "free",
+				Labels: map[string]string{"rack": "a"},
+			},
+		},
+		&tinkv1.Hardware{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "owned",
+				Labels: map[string]string{
+					"rack":                      "a",
+					HardwareOwnerNameLabel:      "machine",
+					HardwareOwnerNamespaceLabel: "default",
+				},
+			},
+		},
+		&infrastructurev1.TinkerbellMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "machine",
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster"},
+			},
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+
+	collector := NewHardwarePoolCollector(client, logr.Discard(), []string{"rack"})
+
+	expected := `
+# HELP capt_hardware_by_label Number of Hardware objects by label value and ownership state.
+# TYPE capt_hardware_by_label gauge
+capt_hardware_by_label{label="rack",state="free",value="a"} 1
+capt_hardware_by_label{label="rack",state="owned",value="a"} 1
+# HELP capt_hardware_free Number of Hardware objects not owned by any TinkerbellMachine.
+# TYPE capt_hardware_free gauge
+capt_hardware_free 1
+# HELP capt_hardware_owned Number of Hardware objects owned by TinkerbellMachines, by cluster.
+# TYPE capt_hardware_owned gauge
+capt_hardware_owned{cluster="cluster",namespace="default"} 1
+# HELP capt_hardware_total Number of Hardware objects.
+# TYPE capt_hardware_total gauge
+capt_hardware_total 2
+`
+
+	g.Expect(testutil.CollectAndCompare(collector, strings.NewReader(expected))).To(Succeed())
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// HardwareBMCAddressAnnotation is set on Hardware to the address of its BMC, which is used for power
+// cycling the machine running on it.
+const HardwareBMCAddressAnnotation = "v1alpha1.tinkerbell.org/bmc-address"
+
+// powerPollInterval is the interval of checking the state of the power workflow. The workflow runs on
+// the BMC worker instead of the Hardware of the machine, so its changes do not trigger reconciliation.
+const powerPollInterval = 30 * time.Second
+
+var (
+	// ErrBMCNotConfigured is returned when the machine cannot be power cycled, as the cluster does not
+	// configure BMC access or the Hardware has no BMC address.
+	ErrBMCNotConfigured = fmt.Errorf("BMC is not configured")
+
+	// ErrPowerCycleFailed is returned when the power workflow fails.
+	ErrPowerCycleFailed = fmt.Errorf("power cycle failed")
+)
+
+// hardwareNotBMCWorker filters out the Hardware running the power workflows, so it is not provisioned.
+func hardwareNotBMCWorker(name string) hardwareFilter {
+	return func(hardware *tinkv1.Hardware) bool {
+		return hardware.Name != name
+	}
+}
+
+// powerCycle power cycles the machine through its BMC by running the power workflow with given name on
+// the BMC worker. With pxe, the machine boots from the network once. It returns true once the workflow
+// succeeded. Removing the workflow is left to the caller, so it is not run again.
+func (mrc *machineReconcileContext) powerCycle(hardware *tinkv1.Hardware, name string, pxe bool) (bool, error) {
+	bmc := mrc.tinkerbellCluster.Spec.BMC
+	if bmc == nil {
+		return false, fmt.Errorf("%w for TinkerbellCluster %s", ErrBMCNotConfigured, mrc.tinkerbellCluster.Name)
+	}
+
+	workflow, err := mrc.getWorkflow(name, bmc.WorkerHardwareName)
+	if err != nil {
+		return false, fmt.Errorf("getting power Workflow: %w", err)
+	}
+
+	switch {
+	case workflow == nil:
+		created, err := mrc.ensurePowerTemplate(hardware, name, pxe)
+		if err != nil || !created {
+			return false, err
+		}
+
+		if err := mrc.createWorkflow(name, bmc.WorkerHardwareName, nil); err != nil {
+			return false, err
+		}
+
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "PowerCycling",
+			"Power cycling Hardware %s through its BMC using Workflow %s", hardware.Name, name)
+
+		return false, nil
+	case !workflow.DeletionTimestamp.IsZero():
+		mrc.log.Info("Power Workflow of previous power cycle is being removed, waiting")
+
+		return false, nil
+	case workflowFailed(workflow):
+		return false, fmt.Errorf("%w: Workflow %s finished with state %s", ErrPowerCycleFailed, name,
+			workflow.Status.State)
+	case workflow.Status.State == tinkv1.WorkflowStateSuccess:
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// removePowerWorkflow removes the power workflow with given name and its template.
+func (mrc *machineReconcileContext) removePowerWorkflow(name string) error {
+	if err := mrc.removeWorkflow(name); err != nil {
+		return fmt.Errorf("removing power Workflow: %w", err)
+	}
+
+	if err := mrc.removeTemplate(name); err != nil {
+		return fmt.Errorf("removing power Template: %w", err)
+	}
+
+	return nil
+}
+
+// ensurePowerTemplate creates the Template power cycling the machine, unless it already exists. It
+// returns false while the Template of the previous power cycle is being removed.
+func (mrc *machineReconcileContext) ensurePowerTemplate(
+	hardware *tinkv1.Hardware,
+	name string,
+	pxe bool,
+) (bool, error) {
+	existing := &tinkv1.Template{}
+
+	err := mrc.client.Get(mrc.ctx, types.NamespacedName{Name: name}, existing)
+	if err == nil {
+		return existing.DeletionTimestamp.IsZero(), nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("getting power Template: %w", err)
+	}
+
+	address := hardware.Annotations[HardwareBMCAddressAnnotation]
+	if address == "" {
+		return false, fmt.Errorf("%w: Hardware %s has no %s annotation", ErrBMCNotConfigured, hardware.Name,
+			HardwareBMCAddressAnnotation)
+	}
+
+	username, password, err := mrc.bmcCredentials()
+	if err != nil {
+		return false, err
+	}
+
+	powerTemplate := templates.PowerCycleWorkflowTemplate{
+		Name:       name,
+		Image:      mrc.tinkerbellCluster.Spec.BMC.Image,
+		BMCAddress: address,
+		Username:   username,
+		Password:   password,
+		PXE:        pxe,
+	}
+
+	templateData, err := powerTemplate.Render()
+	if err != nil {
+		return false, fmt.Errorf("rendering power template: %w", err)
+	}
+
+	templateObject := &tinkv1.Template{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1",
+					Kind:       "TinkerbellMachine",
+					Name:       mrc.tinkerbellMachine.Name,
+					UID:        mrc.tinkerbellMachine.ObjectMeta.UID,
+				},
+			},
+		},
+		Spec: tinkv1.TemplateSpec{
+			Data: &templateData,
+		},
+	}
+
+	if err := mrc.client.Create(mrc.ctx, templateObject); err != nil {
+		return false, fmt.Errorf("creating power template: %w", err)
+	}
+
+	return true, nil
+}
+
+// bmcCredentials returns the username and password for accessing the BMCs of the cluster machines.
+func (mrc *machineReconcileContext) bmcCredentials() (string, string, error) {
+	secret := &corev1.Secret{}
+
+	namespacedName := types.NamespacedName{
+		Namespace: mrc.tinkerbellCluster.Namespace,
+		Name:      mrc.tinkerbellCluster.Spec.BMC.CredentialsRef.Name,
+	}
+
+	if err := mrc.client.Get(mrc.ctx, namespacedName, secret); err != nil {
+		return "", "", fmt.Errorf("getting Secret %q: %w", namespacedName, err)
+	}
+
+	username, hasUsername := secret.Data[corev1.BasicAuthUsernameKey]
+	password, hasPassword := secret.Data[corev1.BasicAuthPasswordKey]
+
+	if !hasUsername || !hasPassword {
+		return "", "", fmt.Errorf("%w: Secret %q must have %q and %q keys", ErrBMCNotConfigured, namespacedName,
+			corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+	}
+
+	return string(username), string(password), nil
+}
+
+// powerCycleAnnotation is set on the provisioning workflow recreated for reprovisioning until the machine
+// is power cycled into the installation environment to run it.
+const powerCycleAnnotation = "infrastructure.cluster.x-k8s.io/power-cycle"
+
+// reprovisionWorkflowName returns the name of the Template and Workflow power cycling given machine into
+// the installation environment.
+func reprovisionWorkflowName(tinkerbellMachine *infrastructurev1.TinkerbellMachine) string {
+	return tinkerbellMachine.Name + "-reprovision"
+}
+
+// powerCyclePending reports whether the machine must be power cycled to run the workflow.
+func powerCyclePending(workflow *tinkv1.Workflow) bool {
+	_, ok := workflow.Annotations[powerCycleAnnotation]
+
+	return ok
+}
+
+// restartIntoWorkflow power cycles the machine into the installation environment, so it runs the
+// provisioning workflow recreated for reprovisioning. The machine is restarted only once the workflow and
+// netboot are propagated into Tinkerbell, as otherwise it would boot from the disk again. Failed power
+// cycle is retried. Without BMC access, the machine must be restarted manually.
+func (mrc *machineReconcileContext) restartIntoWorkflow(hardware *tinkv1.Hardware, workflow *tinkv1.Workflow) error {
+	if workflow.TinkID() == "" || !hardwareNetbootAllowed(hardware) {
+		mrc.log.Info("Waiting for Workflow and netboot to be propagated into Tinkerbell")
+
+		return nil
+	}
+
+	name := reprovisionWorkflowName(mrc.tinkerbellMachine)
+
+	restarted, err := mrc.powerCycle(hardware, name, true)
+
+	switch {
+	case errors.Is(err, ErrBMCNotConfigured):
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeWarning, "PowerCycleFailed",
+			"Hardware %s must be restarted into the installation environment manually: %v", hardware.Name, err)
+	case errors.Is(err, ErrPowerCycleFailed):
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeWarning, "PowerCycleFailed",
+			"Restarting Hardware %s into the installation environment failed, retrying: %v", hardware.Name, err)
+
+		if removeErr := mrc.removePowerWorkflow(name); removeErr != nil {
+			return removeErr
+		}
+
+		return err
+	case err != nil:
+		return err
+	case !restarted:
+		return &requeueAfterError{after: powerPollInterval}
+	default:
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "PowerCycled",
+			"Restarted Hardware %s into the installation environment to run Workflow %s", hardware.Name, workflow.Name)
+	}
+
+	if err := mrc.removePowerWorkflow(name); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(workflow.DeepCopy())
+
+	delete(workflow.Annotations, powerCycleAnnotation)
+
+	if err := mrc.client.Patch(mrc.ctx, workflow, patch); err != nil {
+		return fmt.Errorf("marking Workflow as power cycled: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// rebootWorkflowName returns the name of the Template and Workflow rebooting given machine.
+func rebootWorkflowName(tinkerbellMachine *infrastructurev1.TinkerbellMachine) string {
+	return tinkerbellMachine.Name + "-reboot"
+}
+
+func (mrc *machineReconcileContext) rebootRequested() bool {
+	_, ok := mrc.tinkerbellMachine.Annotations[infrastructurev1.RebootAnnotation]
+
+	return ok
+}
+
+// reboot power cycles already provisioned hardware through its BMC without reinstalling it. Netboot
+// stays disabled, so the machine boots the installed OS from the disk. Once the power workflow finishes
+// or the machine cannot be power cycled, the workflow is removed and the reboot annotation is cleared.
+func (mrc *machineReconcileContext) reboot(hardware *tinkv1.Hardware) error {
+	rebooted, err := mrc.powerCycle(hardware, rebootWorkflowName(mrc.tinkerbellMachine), false)
+
+	switch {
+	case errors.Is(err, ErrBMCNotConfigured), errors.Is(err, ErrPowerCycleFailed):
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeWarning, "RebootFailed",
+			"Rebooting Hardware %s failed: %v", hardware.Name, err)
+	case err != nil:
+		return err
+	case !rebooted:
+		return &requeueAfterError{after: powerPollInterval}
+	default:
+		mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "Rebooted",
+			"Rebooted Hardware %s through its BMC", hardware.Name)
+	}
+
+	return mrc.finishReboot()
+}
+
+// hardwareNetbootAllowed reports whether Tinkerbell allows all interfaces of the hardware to netboot.
+func hardwareNetbootAllowed(hardware *tinkv1.Hardware) bool {
+	if len(hardware.Status.Interfaces) == 0 {
+		return false
+	}
+
+	for _, iface := range hardware.Status.Interfaces {
+		if iface.Netboot == nil || iface.Netboot.AllowPXE == nil || !*iface.Netboot.AllowPXE {
+			return false
+		}
+	}
+
+	return true
+}
+
+// finishReboot removes the reboot workflow and its template and clears the reboot annotation.
+func (mrc *machineReconcileContext) finishReboot() error {
+	if err := mrc.removePowerWorkflow(rebootWorkflowName(mrc.tinkerbellMachine)); err != nil {
+		return err
+	}
+
+	delete(mrc.tinkerbellMachine.Annotations, infrastructurev1.RebootAnnotation)
+
+	return mrc.patch()
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ErrMissingReferencedKey is returned when a Secret or ConfigMap referenced by the machine is missing
+// the referenced key.
+var ErrMissingReferencedKey = fmt.Errorf("referenced key is missing")
+
+// secretKeyValue returns the value of the key of the Secret in the namespace of the machine. Missing
+// optional Secrets and keys are reported as not found.
+func (mrc *machineReconcileContext) secretKeyValue(ref *corev1.SecretKeySelector) (string, bool, error) {
+	optional := ref.Optional != nil && *ref.Optional
+
+	namespacedName := types.NamespacedName{
+		Namespace: mrc.tinkerbellMachine.Namespace,
+		Name:      ref.Name,
+	}
+
+	secret := &corev1.Secret{}
+
+	if err := mrc.client.Get(mrc.ctx, namespacedName, secret); err != nil {
+		if apierrors.IsNotFound(err) && optional {
+			return "", false, nil
+		}
+
+		return "", false, fmt.Errorf("getting Secret %q: %w", namespacedName, err)
+	}
+
+	value, ok := secret.Data[ref.Key]
+	if !ok && !optional {
+		return "", false, fmt.Errorf("%w: Secret %q has no key %q", ErrMissingReferencedKey, namespacedName, ref.Key)
+	}
+
+	return string(value), ok, nil
+}
+
+// configMapKeyValue returns the value of the key of the ConfigMap in the namespace of the machine.
+// Missing optional ConfigMaps and keys are reported as not found.
+func (mrc *machineReconcileContext) configMapKeyValue(ref *corev1.ConfigMapKeySelector) (string, bool, error) {
+	optional := ref.Optional != nil && *ref.Optional
+
+	namespacedName := types.NamespacedName{
+		Namespace: mrc.tinkerbellMachine.Namespace,
+		Name:      ref.Name,
+	}
+
+	configMap := &corev1.ConfigMap{}
+
+	if err := mrc.client.Get(mrc.ctx, namespacedName, configMap); err != nil {
+		if apierrors.IsNotFound(err) && optional {
+			return "", false, nil
+		}
+
+		return "", false, fmt.Errorf("getting ConfigMap %q: %w", namespacedName, err)
+	}
+
+	value, ok := configMap.Data[ref.Key]
+	if !ok && !optional {
+		return "", false, fmt.Errorf("%w: ConfigMap %q has no key %q", ErrMissingReferencedKey, namespacedName, ref.Key)
+	}
+
+	return value, ok, nil
+}
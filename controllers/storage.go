@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+// raidDevice is the device of the software RAID array the machine image is written to.
+const raidDevice = "/dev/md0"
+
+// hardwareWithDisks selects Hardware with all given disks.
+func hardwareWithDisks(devices []string) hardwareFilter {
+	return func(hardware *tinkv1.Hardware) bool {
+		disks := map[string]bool{}
+
+		for _, disk := range hardware.Status.Disks {
+			disks[disk.Device] = true
+		}
+
+		for _, device := range devices {
+			if !disks[device] {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// rootPartitionDevice returns the device of the root partition of the machine image written to the
+// target disk. Partitions selected by label are found using the udev symlinks.
+func rootPartitionDevice(targetDisk string, layout *infrastructurev1.StorageLayout) string {
+	if layout == nil || layout.RootPartition == nil {
+		return firstPartitionFromDevice(targetDisk)
+	}
+
+	root := layout.RootPartition
+
+	switch {
+	case root.Label != "":
+		return "/dev/disk/by-partlabel/" + root.Label
+	case root.Number > 0:
+		return partitionFromDevice(targetDisk, root.Number)
+	default:
+		return firstPartitionFromDevice(targetDisk)
+	}
+}
+
+// applyStorageLayout configures the workflow template to create the partitions, volume groups and
+// filesystems of the storage layout.
+func applyStorageLayout(workflowTemplate *templates.WorkflowTemplate, layout *infrastructurev1.StorageLayout) {
+	if layout == nil {
+		return
+	}
+
+	if layout.RootPartition != nil {
+		workflowTemplate.RootFilesystem = string(layout.RootPartition.Filesystem)
+	}
+
+	for _, p := range layout.Partitions {
+		workflowTemplate.Partitions = append(workflowTemplate.Partitions, templates.Partition{
+			Label:       p.Label,
+			SizeMiB:     sizeMiB(p.Size),
+			Filesystem:  string(p.Filesystem),
+			MountPoint:  p.MountPoint,
+			VolumeGroup: p.VolumeGroup,
+		})
+	}
+
+	for _, vg := range layout.VolumeGroups {
+		volumeGroup := templates.VolumeGroup{Name: vg.Name}
+
+		for _, lv := range vg.LogicalVolumes {
+			volumeGroup.LogicalVolumes = append(volumeGroup.LogicalVolumes, templates.LogicalVolume{
+				Name:       lv.Name,
+				SizeMiB:    sizeMiB(lv.Size),
+				Filesystem: string(lv.Filesystem),
+				MountPoint: lv.MountPoint,
+			})
+		}
+
+		workflowTemplate.VolumeGroups = append(workflowTemplate.VolumeGroups, volumeGroup)
+	}
+}
+
+// sizeMiB returns the size rounded down to MiB. If the size is not set, zero is returned.
+func sizeMiB(size *resource.Quantity) int64 {
+	if size == nil {
+		return 0
+	}
+
+	return size.Value() >> 20 //nolint:gomnd
+}
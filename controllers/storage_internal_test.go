@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/templates"
+)
+
+func Test_Selecting_root_partition_device(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		disk           string
+		layout         *infrastructurev1.StorageLayout
+		expectedDevice string
+	}{
+		"uses_first_partition_by_default": {
+			disk:           "/dev/sda",
+			expectedDevice: "/dev/sda1",
+		},
+		"uses_partition_with_given_number": {
+			disk: "/dev/nvme0n1",
+			layout: &infrastructurev1.StorageLayout{
+				RootPartition: &infrastructurev1.RootPartition{Number: 3},
+			},
+			expectedDevice: "/dev/nvme0n1p3",
+		},
+		"uses_partition_with_given_label": {
+			disk: "/dev/sda",
+			layout: &infrastructurev1.StorageLayout{
+				RootPartition: &infrastructurev1.RootPartition{Label: "root"},
+			},
+			expectedDevice: "/dev/disk/by-partlabel/root",
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			g.Expect(rootPartitionDevice(c.disk, c.layout)).To(Equal(c.expectedDevice))
+		})
+	}
+}
+
+func Test_Applying_storage_layout_to_workflow_template(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	size := resource.MustParse("100Gi")
+
+	workflowTemplate := &templates.WorkflowTemplate{}
+
+	applyStorageLayout(workflowTemplate, &infrastructurev1.StorageLayout{
+		RootPartition: &infrastructurev1.RootPartition{Filesystem: infrastructurev1.FilesystemTypeXFS},
+		Partitions: []infrastructurev1.Partition{
+			{
+				Label: "containerd", Size: &size,
+				Filesystem: infrastructurev1.FilesystemTypeXFS, MountPoint: "/var/lib/containerd",
+			},
+			{Label: "lvm", VolumeGroup: "data"},
+		},
+		VolumeGroups: []infrastructurev1.VolumeGroup{
+			{
+				Name: "data",
+				LogicalVolumes: []infrastructurev1.LogicalVolume{
+					{Name: "kubelet", Filesystem: infrastructurev1.FilesystemTypeExt4, MountPoint: "/var/lib/kubelet"},
+				},
+			},
+		},
+	})
+
+	g.Expect(workflowTemplate.RootFilesystem).To(Equal("xfs"))
+	g.Expect(workflowTemplate.Partitions).To(Equal([]templates.Partition{
+		{Label: "containerd", SizeMiB: 102400, Filesystem: "xfs", MountPoint: "/var/lib/containerd"},
+		{Label: "lvm", VolumeGroup: "data"},
+	}))
+	g.Expect(workflowTemplate.VolumeGroups).To(Equal([]templates.VolumeGroup{
+		{
+			Name: "data",
+			LogicalVolumes: []templates.LogicalVolume{
+				{Name: "kubelet", Filesystem: "ext4", MountPoint: "/var/lib/kubelet"},
+			},
+		},
+	}))
+}
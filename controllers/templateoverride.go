@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/patch"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+var (
+	// ErrTemplateOverrideMissingData is returned when the Template referenced as template override has no data.
+	ErrTemplateOverrideMissingData = fmt.Errorf("referenced Template has no data")
+
+	// ErrTemplateOverrideManaged is returned when the Template referenced as template override is pushed to
+	// Tinkerbell unrendered, as it is not annotated as unmanaged.
+	ErrTemplateOverrideManaged = fmt.Errorf("referenced Template is pushed to Tinkerbell, annotate it with %s=true",
+		tinkv1.TemplateUnmanagedAnnotation)
+)
+
+// templateOverride returns the template override held by the referenced ConfigMap or Template.
+func (mrc *machineReconcileContext) templateOverride(ref *infrastructurev1.TemplateOverrideReference) (string, error) {
+	if ref.Kind == infrastructurev1.TemplateOverrideKindConfigMap {
+		key := ref.Key
+		if key == "" {
+			key = infrastructurev1.DefaultTemplateOverrideKey
+		}
+
+		value, _, err := mrc.configMapKeyValue(&corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+			Key:                  key,
+		})
+
+		return value, err
+	}
+
+	template := &tinkv1.Template{}
+
+	if err := mrc.client.Get(mrc.ctx, types.NamespacedName{Name: ref.Name}, template); err != nil {
+		return "", fmt.Errorf("getting Template %q: %w", ref.Name, err)
+	}
+
+	if template.Managed() {
+		return "", fmt.Errorf("%w: %q", ErrTemplateOverrideManaged, ref.Name)
+	}
+
+	if template.Spec.Data == nil {
+		return "", fmt.Errorf("%w: %q", ErrTemplateOverrideMissingData, ref.Name)
+	}
+
+	return *template.Spec.Data, nil
+}
+
+// ensureTemplateOverrideRendered re-renders the template of the machine from the referenced template
+// override until the workflow is handed over to the Hardware. When the rendered data changes, the
+// workflow not yet created in Tinkerbell is removed, so it is created again from the updated template.
+func (mrc *machineReconcileContext) ensureTemplateOverrideRendered(hardware *tinkv1.Hardware) error {
+	workflow, err := mrc.getWorkflow(mrc.tinkerbellMachine.Name, mrc.tinkerbellMachine.Spec.HardwareName)
+	if err != nil {
+		return fmt.Errorf("getting workflow: %w", err)
+	}
+
+	if workflow != nil && workflowHandedToHardware(workflow) {
+		return nil
+	}
+
+	template := &tinkv1.Template{}
+
+	if err := mrc.client.Get(mrc.ctx, types.NamespacedName{Name: mrc.tinkerbellMachine.Name}, template); err != nil {
+		return fmt.Errorf("getting Template: %w", err)
+	}
+
+	templateData, err := mrc.templateData(hardware)
+	if err != nil {
+		return err
+	}
+
+	if template.Spec.Data != nil && *template.Spec.Data == templateData {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(template, mrc.client)
+	if err != nil {
+		return fmt.Errorf("initializing patch helper for Template: %w", err)
+	}
+
+	template.Spec.Data = &templateData
+
+	if err := patchHelper.Patch(mrc.ctx, template); err != nil {
+		return fmt.Errorf("patching Template: %w", err)
+	}
+
+	mrc.log.Info("Template override changed, re-rendered Template", "name", template.Name)
+	mrc.recorder.Eventf(mrc.tinkerbellMachine, corev1.EventTypeNormal, "TemplateRendered",
+		"Re-rendered Template %s for Hardware %s after template override changed", template.Name, hardware.Name)
+
+	if workflow == nil || !workflow.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	if err := mrc.client.Delete(mrc.ctx, workflow); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("removing workflow: %w", err)
+	}
+
+	return nil
+}
+
+// workflowHandedToHardware reports whether the workflow was created in Tinkerbell. Netboot is allowed
+// before the workflow is created, so the Hardware may pick it up from then on. Workflow status is only
+// refreshed periodically, so a pending state does not confirm that no action has started.
+func workflowHandedToHardware(workflow *tinkv1.Workflow) bool {
+	return workflow.TinkID() != ""
+}
@@ -19,13 +19,14 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
@@ -39,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
 	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 )
 
@@ -46,6 +48,10 @@ import (
 type TinkerbellClusterReconciler struct {
 	client.Client
 	WatchFilterValue string
+
+	// Recorder is used for emitting events about TinkerbellCluster lifecycle. If nil, events
+	// are discarded.
+	Recorder record.EventRecorder
 }
 
 // validate validates if context configuration has all required fields properly populated.
@@ -78,6 +84,7 @@ func (tcr *TinkerbellClusterReconciler) newReconcileContext(ctx context.Context,
 		tinkerbellCluster: &infrastructurev1.TinkerbellCluster{},
 		client:            tcr.Client,
 		namespacedName:    namespacedName,
+		recorder:          eventRecorderOrDiscard(tcr.Recorder),
 	}
 
 	if err := crc.client.Get(crc.ctx, namespacedName, crc.tinkerbellCluster); err != nil {
@@ -122,6 +129,7 @@ type clusterReconcileContext struct {
 	log               logr.Logger
 	client            client.Client
 	namespacedName    types.NamespacedName
+	recorder          record.EventRecorder
 }
 
 const (
@@ -163,42 +171,61 @@ var (
 	ErrControlPlaneEndpointNotSet = fmt.Errorf("controlplane endpoint is not set")
 )
 
-func nextAvailableHardware(ctx context.Context, k8sClient client.Client, extraSelectors []string) (*tinkv1.Hardware, error) { //nolint:lll
-	hardware, err := nextHardware(ctx, k8sClient, append(extraSelectors, fmt.Sprintf("!%s", HardwareOwnerNameLabel)))
-	if err != nil {
-		return nil, fmt.Errorf("getting next Hardware object: %w", err)
+// hardwareFilter reports whether the Hardware can be selected. It is used for criteria, which
+// can't be expressed using label selectors.
+type hardwareFilter func(*tinkv1.Hardware) bool
+
+// hardwareWithArch selects Hardware with given CPU architecture.
+func hardwareWithArch(arch string) hardwareFilter {
+	return func(hardware *tinkv1.Hardware) bool {
+		return hardwareArch(hardware) == arch
 	}
+}
 
-	if hardware == nil {
-		return nil, ErrNoHardwareAvailable
+// hardwareInFailureDomain selects Hardware, which has the label with given key set to the failure domain.
+func hardwareInFailureDomain(labelKey, failureDomain string) hardwareFilter {
+	return func(hardware *tinkv1.Hardware) bool {
+		return hardware.Labels[labelKey] == failureDomain
+	}
+}
+
+// availableHardware returns all Hardware, which is not owned by any machine and matches given filters.
+func availableHardware(ctx context.Context, k8sClient client.Client, filters ...hardwareFilter) ([]tinkv1.Hardware, error) { //nolint:lll
+	hardware, err := listHardware(ctx, k8sClient, "", filters...)
+	if err != nil {
+		return nil, fmt.Errorf("listing available Hardware objects: %w", err)
 	}
 
 	return hardware, nil
 }
 
-func nextHardware(ctx context.Context, k8sClient client.Client, selectors []string) (*tinkv1.Hardware, error) { //nolint:lll
-	availableHardwares := &tinkv1.HardwareList{}
-
-	selectorsRaw := strings.Join(selectors, ",")
+// listHardware returns Hardware owned by the TinkerbellMachine with given namespace/name key or, if the key
+// is empty, Hardware available for selection, which matches given filters.
+func listHardware(ctx context.Context, k8sClient client.Client, owner string, filters ...hardwareFilter) ([]tinkv1.Hardware, error) { //nolint:lll
+	hardwareList := &tinkv1.HardwareList{}
 
-	selector, err := labels.Parse(selectorsRaw)
-	if err != nil {
-		return nil, fmt.Errorf("parsing raw labels selector %q: %w", selectorsRaw, err)
+	if err := k8sClient.List(ctx, hardwareList, client.MatchingFields{HardwareOwnerIndex: owner}); err != nil {
+		return nil, fmt.Errorf("listing Hardware objects: %w", err)
 	}
 
-	options := client.MatchingLabelsSelector{
-		Selector: selector,
-	}
+	result := []tinkv1.Hardware{}
 
-	if err := k8sClient.List(ctx, availableHardwares, options); err != nil {
-		return nil, fmt.Errorf("listing hardware without owner: %w", err)
-	}
+hardware:
+	for i := range hardwareList.Items {
+		if !indexed(&hardwareList.Items[i], hardwareOwnerIndexFunc, owner) {
+			continue
+		}
 
-	if len(availableHardwares.Items) == 0 {
-		return nil, nil
+		for _, filter := range filters {
+			if !filter(&hardwareList.Items[i]) {
+				continue hardware
+			}
+		}
+
+		result = append(result, hardwareList.Items[i])
 	}
 
-	return &availableHardwares.Items[0], nil
+	return result, nil
 }
 
 func hardwareIP(hardware *tinkv1.Hardware) (string, error) {
@@ -262,11 +289,52 @@ func (crc *clusterReconcileContext) controlPlaneEndpoint() (clusterv1.APIEndpoin
 	return endpoint, nil
 }
 
+// failureDomains derives failure domains from the values of the Hardware label configured in the
+// TinkerbellCluster. Hardware owned by other clusters is included, as it may be released later.
+func (crc *clusterReconcileContext) failureDomains() (clusterv1.FailureDomains, error) {
+	labelKey := crc.tinkerbellCluster.Spec.FailureDomainLabelKey
+	if labelKey == "" {
+		return nil, nil
+	}
+
+	hardwareList := &tinkv1.HardwareList{}
+
+	if err := crc.client.List(crc.ctx, hardwareList, client.HasLabels{labelKey}); err != nil {
+		return nil, fmt.Errorf("listing Hardware objects: %w", err)
+	}
+
+	controlPlaneFailureDomains := map[string]bool{}
+
+	for _, failureDomain := range crc.tinkerbellCluster.Spec.ControlPlaneFailureDomains {
+		controlPlaneFailureDomains[failureDomain] = true
+	}
+
+	failureDomains := clusterv1.FailureDomains{}
+
+	for i := range hardwareList.Items {
+		failureDomain := hardwareList.Items[i].Labels[labelKey]
+		if failureDomain == "" {
+			continue
+		}
+
+		failureDomains[failureDomain] = clusterv1.FailureDomainSpec{
+			ControlPlane: len(controlPlaneFailureDomains) == 0 || controlPlaneFailureDomains[failureDomain],
+		}
+	}
+
+	return failureDomains, nil
+}
+
 // Reconcile implements ReconcileContext interface by ensuring that all TinkerbellCluster object
 // fields are properly populated.
 func (crc *clusterReconcileContext) reconcile() error {
 	controlPlaneEndpoint, err := crc.controlPlaneEndpoint()
 	if err != nil {
+		if errors.Is(err, ErrControlPlaneEndpointNotSet) {
+			crc.recorder.Event(crc.tinkerbellCluster, corev1.EventTypeWarning, "ControlPlaneEndpointNotSet",
+				"Control plane endpoint is not set")
+		}
+
 		return err
 	}
 
@@ -275,6 +343,18 @@ func (crc *clusterReconcileContext) reconcile() error {
 	crc.tinkerbellCluster.Spec.ControlPlaneEndpoint.Host = controlPlaneEndpoint.Host
 	crc.tinkerbellCluster.Spec.ControlPlaneEndpoint.Port = controlPlaneEndpoint.Port
 
+	failureDomains, err := crc.failureDomains()
+	if err != nil {
+		return fmt.Errorf("getting failure domains: %w", err)
+	}
+
+	crc.tinkerbellCluster.Status.FailureDomains = failureDomains
+
+	if !crc.tinkerbellCluster.Status.Ready {
+		crc.recorder.Eventf(crc.tinkerbellCluster, corev1.EventTypeNormal, "ClusterReady",
+			"Cluster is ready with control plane endpoint %s:%d", controlPlaneEndpoint.Host, controlPlaneEndpoint.Port)
+	}
+
 	crc.tinkerbellCluster.Status.Ready = true
 
 	crc.log.Info("Setting cluster status to ready")
@@ -292,10 +372,18 @@ func (crc *clusterReconcileContext) reconcileDelete() error {
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellclustertemplates,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile ensures state of Tinkerbell clusters.
-func (tcr *TinkerbellClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (tcr *TinkerbellClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "tinkerbellcluster", req)
+	defer func() {
+		recordReconcileError("tinkerbellcluster", reterr)
+		tracing.End(span, reterr)
+	}()
+
 	crc, err := tcr.newReconcileContext(ctx, req.NamespacedName)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("creating reconciliation context: %w", err)
@@ -351,6 +439,10 @@ func (tcr *TinkerbellClusterReconciler) SetupWithManager(
 			builder.WithPredicates(
 				predicates.ClusterUnpaused(log),
 			),
+		).
+		Watches(
+			&source.Kind{Type: &tinkv1.Hardware{}},
+			handler.EnqueueRequestsFromMapFunc(tcr.HardwareToTinkerbellClusters(ctx)),
 		)
 
 	if err := builder.Complete(tcr); err != nil {
@@ -359,3 +451,33 @@ func (tcr *TinkerbellClusterReconciler) SetupWithManager(
 
 	return nil
 }
+
+// HardwareToTinkerbellClusters is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// of TinkerbellClusters deriving failure domains from the labels of the Hardware, so failure domains are
+// updated when labeled Hardware is added, relabeled or removed.
+func (tcr *TinkerbellClusterReconciler) HardwareToTinkerbellClusters(ctx context.Context) handler.MapFunc {
+	log := ctrl.LoggerFrom(ctx)
+
+	return func(o client.Object) []ctrl.Request {
+		clusters := &infrastructurev1.TinkerbellClusterList{}
+
+		if err := tcr.Client.List(ctx, clusters); err != nil {
+			log.Error(err, "failed to list TinkerbellClusters for Hardware", "Hardware", o.GetName())
+
+			return nil
+		}
+
+		var result []ctrl.Request
+
+		for i := range clusters.Items {
+			labelKey := clusters.Items[i].Spec.FailureDomainLabelKey
+			if labelKey == "" || o.GetLabels()[labelKey] == "" {
+				continue
+			}
+
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&clusters.Items[i])})
+		}
+
+		return result
+	}
+}
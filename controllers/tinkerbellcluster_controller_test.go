@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -136,6 +137,50 @@ func Test_Cluster_reconciliation_when_controlplane_endpoint_set_on_tinkerbellClu
 	g.Expect(updatedTinkerbellCluster.Status.Ready).To(BeTrue(), "Expected infrastructure to be ready")
 }
 
+func Test_Cluster_reconciliation_with_failure_domain_label_key(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	tinkCluster := unreadyTinkerbellCluster(clusterName, clusterNamespace)
+	tinkCluster.Spec.ControlPlaneEndpoint.Host = "192.168.1.10"
+	tinkCluster.Spec.ControlPlaneEndpoint.Port = 443
+	tinkCluster.Spec.FailureDomainLabelKey = "rack"
+	tinkCluster.Spec.ControlPlaneFailureDomains = []string{"rack-1", "rack-2"}
+
+	rack1Hardware := validHardware("rack-1-hardware", uuid.New().String(), hardwareIP)
+	rack1Hardware.Labels = map[string]string{"rack": "rack-1"}
+
+	rack3Hardware := validHardware("rack-3-hardware", uuid.New().String(), "10.10.10.11")
+	rack3Hardware.Labels = map[string]string{"rack": "rack-3"}
+
+	objects := []runtime.Object{
+		rack1Hardware,
+		rack3Hardware,
+		validHardware("unlabeled-hardware", uuid.New().String(), "10.10.10.12"),
+		validCluster(clusterName, clusterNamespace),
+		tinkCluster.DeepCopy(),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileClusterWithClient(client, clusterName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	namespacedName := types.NamespacedName{
+		Name:      clusterName,
+		Namespace: clusterNamespace,
+	}
+
+	updatedTinkerbellCluster := &infrastructurev1.TinkerbellCluster{}
+
+	g.Expect(client.Get(context.Background(), namespacedName, updatedTinkerbellCluster)).To(Succeed())
+
+	g.Expect(updatedTinkerbellCluster.Status.FailureDomains).To(Equal(clusterv1.FailureDomains{
+		"rack-1": clusterv1.FailureDomainSpec{ControlPlane: true},
+		"rack-3": clusterv1.FailureDomainSpec{ControlPlane: false},
+	}), "Expected failure domains to be derived from Hardware labels")
+}
+
 func Test_Cluster_reconciliation(t *testing.T) {
 	t.Parallel()
 
@@ -192,6 +237,7 @@ func kubernetesClientWithObjects(t *testing.T, objects []runtime.Object) client.
 	g.Expect(tinkv1.AddToScheme(scheme)).To(Succeed(), "Adding Tinkerbell objects to scheme should succeed")
 	g.Expect(infrastructurev1.AddToScheme(scheme)).To(Succeed(), "Adding Tinkerbell CAPI objects to scheme should succeed")
 	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed(), "Adding CAPI objects to scheme should succeed")
+	g.Expect(expv1.AddToScheme(scheme)).To(Succeed(), "Adding CAPI experimental objects to scheme should succeed")
 	g.Expect(corev1.AddToScheme(scheme)).To(Succeed(), "Adding Core V1 objects to scheme should succeed")
 
 	return fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
@@ -18,12 +18,16 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
-	"sigs.k8s.io/cluster-api/util/collections"
 	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -33,21 +37,75 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/hosts"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/images"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 )
 
+// ImageResolver checks availability of machine images.
+type ImageResolver interface {
+	Available(ctx context.Context, imageURL string, options images.Options) (bool, error)
+}
+
+// HostProber checks reachability of already provisioned hosts adopted by machines.
+type HostProber interface {
+	Reachable(ctx context.Context, address string) (bool, error)
+}
+
 // TinkerbellMachineReconciler implements Reconciler interface by managing Tinkerbell machines.
 type TinkerbellMachineReconciler struct {
 	client.Client
 	WatchFilterValue string
+
+	// ImageResolver is used for verifying machine image availability. If nil, new resolver
+	// without shared cache is used for each reconciliation.
+	ImageResolver ImageResolver
+
+	// HostProber is used for verifying the nodes running on adopted Hardware, or booting from the disk
+	// after provisioning, are reachable. If nil, prober checking the kubelet port is used.
+	HostProber HostProber
+
+	// ClusterClient returns clients of workload clusters, which are used for linking the Nodes running
+	// on adopted Hardware to their machines. If nil, clients are created from the kubeconfig secrets.
+	ClusterClient hosts.ClusterClientFunc
+
+	// Recorder is used for emitting events about TinkerbellMachine lifecycle. If nil, events
+	// are discarded.
+	Recorder record.EventRecorder
+
+	// HardwareQuarantineThreshold is the number of failed provisioning workflows, after which the
+	// Hardware is quarantined and no longer selected for machines. If zero, Hardware is never
+	// quarantined.
+	HardwareQuarantineThreshold int
+
+	// EvictFromHardwareInMaintenance enables deleting Machines running on Hardware annotated for
+	// maintenance, so they get replaced on other Hardware. Machines are deleted once they have been
+	// running on Hardware in maintenance for HardwareMaintenanceGracePeriod.
+	EvictFromHardwareInMaintenance bool
+	HardwareMaintenanceGracePeriod time.Duration
+
+	// MachinePools enables watching MachinePools, so machines of TinkerbellMachinePools are reconciled
+	// when the bootstrap data or version of their MachinePool changes.
+	MachinePools bool
 }
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellmachines,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellmachines/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellimages,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets;,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile ensures that all Tinkerbell machines are aligned with a given spec.
-func (tmr *TinkerbellMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (tmr *TinkerbellMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "tinkerbellmachine", req)
+	defer func() {
+		recordReconcileError("tinkerbellmachine", reterr)
+		tracing.End(span, reterr)
+	}()
+
 	bmrc, result, err := tmr.newReconcileContext(ctx, req.NamespacedName)
 	if err != nil {
 		return result, fmt.Errorf("creating reconciliation context: %w", err)
@@ -70,7 +128,27 @@ func (tmr *TinkerbellMachineReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, nil
 	}
 
-	return ctrl.Result{}, mrc.Reconcile() //nolint:wrapcheck
+	if err := mrc.Reconcile(); err != nil {
+		// Machine waiting for Hardware is enqueued by the Hardware watch, so there is no need to
+		// retry with a backoff.
+		if errors.Is(err, ErrNoHardwareAvailable) {
+			return ctrl.Result{}, nil
+		}
+
+		// Machine waiting for the maintenance of its Hardware to finish is enqueued by the Hardware watch.
+		if errors.Is(err, ErrHardwareInMaintenance) {
+			return ctrl.Result{}, nil
+		}
+
+		var requeue *requeueAfterError
+		if errors.As(err, &requeue) {
+			return ctrl.Result{RequeueAfter: requeue.after}, nil
+		}
+
+		return ctrl.Result{}, err //nolint:wrapcheck
+	}
+
+	return ctrl.Result{}, nil
 }
 
 // SetupWithManager configures reconciler with a given manager.
@@ -108,7 +186,37 @@ func (tmr *TinkerbellMachineReconciler) SetupWithManager(
 			&source.Kind{Type: &clusterv1.Cluster{}},
 			handler.EnqueueRequestsFromMapFunc(clusterToObjectFunc),
 			builder.WithPredicates(predicates.ClusterUnpausedAndInfrastructureReady(log)),
+		).
+		Watches(
+			&source.Kind{Type: &tinkv1.Workflow{}},
+			handler.EnqueueRequestsFromMapFunc(tmr.WorkflowToTinkerbellMachine(ctx)),
+		).
+		Watches(
+			&source.Kind{Type: &tinkv1.Hardware{}},
+			handler.EnqueueRequestsFromMapFunc(tmr.HardwareToWaitingTinkerbellMachines(ctx)),
+		).
+		// Only names of ConfigMaps are needed to find the machines referencing them, so their data
+		// is not cached.
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			handler.EnqueueRequestsFromMapFunc(
+				tmr.TemplateOverrideToTinkerbellMachines(ctx, infrastructurev1.TemplateOverrideKindConfigMap),
+			),
+			builder.OnlyMetadata,
+		).
+		Watches(
+			&source.Kind{Type: &tinkv1.Template{}},
+			handler.EnqueueRequestsFromMapFunc(
+				tmr.TemplateOverrideToTinkerbellMachines(ctx, infrastructurev1.TemplateOverrideKindTemplate),
+			),
+		)
+
+	if tmr.MachinePools {
+		builder = builder.Watches(
+			&source.Kind{Type: &expv1.MachinePool{}},
+			handler.EnqueueRequestsFromMapFunc(tmr.MachinePoolToTinkerbellMachines(ctx)),
 		)
+	}
 
 	if err := builder.Complete(tmr); err != nil {
 		return fmt.Errorf("failed to create controller: %w", err)
@@ -155,23 +263,193 @@ func (tmr *TinkerbellMachineReconciler) TinkerbellClusterToTinkerbellMachines(ct
 			return nil
 		}
 
-		machines, err := collections.GetFilteredMachinesForCluster(ctx, tmr.Client, cluster)
+		clusterKey := namespacedKey(cluster.Namespace, cluster.Name)
+		machines := &infrastructurev1.TinkerbellMachineList{}
+
+		if err := tmr.Client.List(ctx, machines, client.InNamespace(cluster.Namespace),
+			client.MatchingFields{TinkerbellMachineClusterIndex: clusterKey}); err != nil {
+			log.Error(err, "failed to get TinkerbellMachines for Cluster")
+
+			return nil
+		}
+
+		var result []ctrl.Request
+
+		for i := range machines.Items {
+			if !indexed(&machines.Items[i], tinkerbellMachineClusterIndexFunc, clusterKey) {
+				continue
+			}
+
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&machines.Items[i])})
+		}
+
+		return result
+	}
+}
+
+// WorkflowToTinkerbellMachine is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// of TinkerbellMachine owning the Hardware referenced by the Workflow.
+func (tmr *TinkerbellMachineReconciler) WorkflowToTinkerbellMachine(ctx context.Context) handler.MapFunc {
+	log := ctrl.LoggerFrom(ctx)
+
+	return func(o client.Object) []ctrl.Request {
+		w, ok := o.(*tinkv1.Workflow)
+		if !ok {
+			log.Error(
+				fmt.Errorf("expected a Workflow but got a %T", o), //nolint:goerr113
+				"failed to get TinkerbellMachine for Workflow",
+			)
+
+			return nil
+		}
+
+		if w.Spec.HardwareRef == "" {
+			return nil
+		}
+
+		hardware := &tinkv1.Hardware{}
+
+		if err := tmr.Client.Get(ctx, client.ObjectKey{Name: w.Spec.HardwareRef}, hardware); err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Error(err, "failed to get Hardware for Workflow", "Workflow", w.Name)
+			}
+
+			return nil
+		}
+
+		name, ok := hardware.ObjectMeta.Labels[HardwareOwnerNameLabel]
+		if !ok {
+			return nil
+		}
+
+		namespace := hardware.ObjectMeta.Labels[HardwareOwnerNamespaceLabel]
+
+		return []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: namespace, Name: name}}}
+	}
+}
+
+// HardwareToWaitingTinkerbellMachines is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// of TinkerbellMachines waiting for Hardware, when the Hardware is available for selection, e.g. when it has been
+// added or released by other machine, and of the TinkerbellMachine owning the Hardware on any change, e.g. when
+// its maintenance starts or finishes.
+func (tmr *TinkerbellMachineReconciler) HardwareToWaitingTinkerbellMachines(ctx context.Context) handler.MapFunc {
+	log := ctrl.LoggerFrom(ctx)
+
+	return func(o client.Object) []ctrl.Request {
+		hardware, ok := o.(*tinkv1.Hardware)
+		if !ok {
+			log.Error(
+				fmt.Errorf("expected a Hardware but got a %T", o), //nolint:goerr113
+				"failed to get TinkerbellMachines for Hardware",
+			)
+
+			return nil
+		}
+
+		name, owned := hardware.Labels[HardwareOwnerNameLabel]
+
+		// Machine owning the Hardware is reconciled on any change, e.g. when maintenance is requested or finished.
+		if owned {
+			namespace := hardware.Labels[HardwareOwnerNamespaceLabel]
+
+			return []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: namespace, Name: name}}}
+		}
+
+		if !hardwareNotQuarantined(hardware) || hardwareInMaintenance(hardware) {
+			return nil
+		}
+
+		machines, err := listMachinesWaitingForHardware(ctx, tmr.Client)
 		if err != nil {
-			log.Error(err, "failed to get Machines for Cluster")
+			log.Error(err, "failed to list TinkerbellMachines waiting for Hardware", "Hardware", o.GetName())
+
+			return nil
+		}
+
+		var result []ctrl.Request
+
+		for _, machine := range machines {
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+		}
+
+		return result
+	}
+}
+
+// TemplateOverrideToTinkerbellMachines is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// of TinkerbellMachines referencing the object of given kind as template override, so the template is re-rendered.
+// ConfigMaps may be watched with metadata only.
+func (tmr *TinkerbellMachineReconciler) TemplateOverrideToTinkerbellMachines(
+	ctx context.Context,
+	kind infrastructurev1.TemplateOverrideKind,
+) handler.MapFunc {
+	log := ctrl.LoggerFrom(ctx)
+
+	return func(o client.Object) []ctrl.Request {
+		opts := []client.ListOption{client.MatchingFields{TinkerbellMachineTemplateOverrideIndex: o.GetName()}}
+
+		// ConfigMaps are referenced only from the same namespace.
+		if kind == infrastructurev1.TemplateOverrideKindConfigMap {
+			opts = append(opts, client.InNamespace(o.GetNamespace()))
+		}
+
+		machines := &infrastructurev1.TinkerbellMachineList{}
+
+		if err := tmr.Client.List(ctx, machines, opts...); err != nil {
+			log.Error(err, "failed to list TinkerbellMachines for template override", string(kind), o.GetName())
 
 			return nil
 		}
 
 		var result []ctrl.Request
 
-		for _, m := range machines.UnsortedList() {
-			if m.Spec.InfrastructureRef.Name == "" {
+		for i := range machines.Items {
+			ref := machines.Items[i].Spec.TemplateOverrideRef
+			if ref == nil || ref.Kind != kind || ref.Name != o.GetName() {
 				continue
 			}
 
-			name := client.ObjectKey{Namespace: m.Namespace, Name: m.Spec.InfrastructureRef.Name}
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&machines.Items[i])})
+		}
+
+		return result
+	}
+}
+
+// MachinePoolToTinkerbellMachines is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// of TinkerbellMachines of the TinkerbellMachinePool referenced by the MachinePool.
+func (tmr *TinkerbellMachineReconciler) MachinePoolToTinkerbellMachines(ctx context.Context) handler.MapFunc {
+	log := ctrl.LoggerFrom(ctx)
+
+	return func(o client.Object) []ctrl.Request {
+		m, ok := o.(*expv1.MachinePool)
+		if !ok {
+			log.Error(
+				fmt.Errorf("expected a MachinePool but got a %T", o), //nolint:goerr113
+				"failed to get TinkerbellMachines for MachinePool",
+			)
+
+			return nil
+		}
+
+		ref := m.Spec.Template.Spec.InfrastructureRef
+		if ref.GroupVersionKind().GroupKind() != infrastructurev1.GroupVersion.WithKind(tinkerbellMachinePoolKind).GroupKind() { //nolint:lll
+			return nil
+		}
+
+		machines := &infrastructurev1.TinkerbellMachineList{}
+
+		if err := tmr.Client.List(ctx, machines, client.InNamespace(m.Namespace),
+			client.MatchingLabels{infrastructurev1.MachinePoolNameLabel: ref.Name}); err != nil {
+			log.Error(err, "failed to list TinkerbellMachines for MachinePool", "MachinePool", m.Name)
+
+			return nil
+		}
+
+		var result []ctrl.Request
 
-			result = append(result, ctrl.Request{NamespacedName: name})
+		for i := range machines.Items {
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&machines.Items[i])})
 		}
 
 		return result
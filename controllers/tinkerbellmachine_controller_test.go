@@ -18,21 +18,27 @@ package controllers_test
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
 	"github.com/tinkerbell/cluster-api-provider-tinkerbell/controllers"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/images"
 	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 )
 
@@ -119,7 +125,8 @@ func validMachine(name, namespace, clusterName string) *clusterv1.Machine {
 			},
 		},
 		Spec: clusterv1.MachineSpec{
-			Version: pointer.StringPtr("1.19.4"),
+			ClusterName: clusterName,
+			Version:     pointer.StringPtr("1.19.4"),
 			Bootstrap: clusterv1.Bootstrap{
 				DataSecretName: pointer.StringPtr(name),
 			},
@@ -299,204 +306,2608 @@ func Test_Machine_reconciliation_with_available_hardware(t *testing.T) {
 			"Expected owner namespace label to be set on Hardware")
 	})
 
+	// So selected hardware is able to pick up created workflow.
+	t.Run("enables_netboot_on_selected_hardware", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		updatedHardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, updatedHardware)).To(Succeed())
+
+		g.Expect(updatedHardware.Spec.AllowPXE).To(Equal(pointer.BoolPtr(true)), "Expected PXE to be allowed")
+		g.Expect(updatedHardware.Spec.AllowWorkflow).To(Equal(pointer.BoolPtr(true)), "Expected workflow to be allowed")
+	})
+
 	// Ensure idempotency of reconcile operation. E.g. we shouldn't try to create the template with the same name
 	// on every iteration.
 	t.Run("succeeds_when_executed_twice", func(t *testing.T) {
 		t.Parallel()
 		g := NewWithT(t)
 
-		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
-		g.Expect(err).NotTo(HaveOccurred(), "Unexpected reconciliation error")
-	})
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred(), "Unexpected reconciliation error")
+	})
+
+	// Status should be updated on every run.
+	//
+	// Don't execute this test in parallel, as we reset status here.
+	t.Run("refreshes_status_when_machine_is_already_provisioned", func(t *testing.T) { //nolint:paralleltest
+		updatedMachine.Status.Addresses = nil
+		g := NewWithT(t)
+
+		g.Expect(client.Update(context.Background(), updatedMachine)).To(Succeed())
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		updatedMachine = &infrastructurev1.TinkerbellMachine{}
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(updatedMachine.Status.Addresses).NotTo(BeEmpty(), "Machine status should be updated on every reconciliation")
+	})
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("is_not_requeued_when", func(t *testing.T) {
+		t.Parallel()
+
+		// Requeue will be handled when resource is created.
+		t.Run("is_requeued_when_machine_object_is_missing",
+			machineReconciliationIsRequeuedWhenTinkerbellMachineObjectIsMissing)
+
+		// From https://cluster-api.sigs.k8s.io/developer/providers/cluster-infrastructure.html#behavior
+		// Requeue will be handled when ownerRef is set
+		t.Run("machine_has_no_owner_set", machineReconciliationIsRequeuedWhenTinkerbellMachineHasNoOwnerSet)
+
+		// From https://cluster-api.sigs.k8s.io/developer/providers/cluster-infrastructure.html#behavior
+		// Requeue will be handled when bootstrap secret is set through the Watch on Machines
+		t.Run("bootstrap_secret_is_not_ready", machineReconciliationIsRequeuedWhenBootstrapSecretIsNotReady)
+
+		// From https://cluster-api.sigs.k8s.io/developer/providers/cluster-infrastructure.html#behavior
+		// Requeue will be handled when bootstrap secret is set through the Watch on Clusters
+		t.Run("cluster_infrastructure_is_not_ready", machineReconciliationIsRequeuedWhenClusterInfrastructureIsNotReady)
+
+		// Requeue will be handled when Hardware becomes available through the Watch on Hardware.
+		t.Run("there_is_no_hardware_available", machineReconciliationWaitsWhenThereIsNoHardwareAvailable)
+	})
+
+	t.Run("fails_when", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("reconciler_is_nil", machineReconciliationFailsWhenReconcilerIsNil)
+		t.Run("reconciler_has_no_client_set", machineReconciliationFailsWhenReconcilerHasNoClientSet)
+
+		// CAPI spec says this is optional, but @detiber says it's effectively required, so treat it as so.
+		t.Run("machine_has_no_version_set", machineReconciliationFailsWhenMachineHasNoVersionSet)
+
+		t.Run("associated_cluster_object_does_not_exist",
+			machineReconciliationFailsWhenAssociatedClusterObjectDoesNotExist)
+
+		t.Run("associated_tinkerbell_cluster_object_does_not_exist",
+			machineReconciliationFailsWhenAssociatedTinkerbellClusterObjectDoesNotExist)
+
+		// If for example CAPI changes key used to store bootstrap date, we shouldn't try to create machines
+		// with empty bootstrap config, we should fail early instead.
+		t.Run("bootstrap_config_is_empty", machineReconciliationFailsWhenBootstrapConfigIsEmpty)
+		t.Run("bootstrap_config_has_no_value_key", machineReconciliationFailsWhenBootstrapConfigHasNoValueKey)
+		t.Run("bootstrap_config_has_unsupported_format", machineReconciliationFailsWhenBootstrapConfigHasUnsupportedFormat)
+
+		t.Run("selected_hardware_has_no_ip_address_set", machineReconciliationFailsWhenSelectedHardwareHasNoIPAddressSet)
+	})
+
+	// Single hardware should only ever be used for a single machine.
+	t.Run("selects_unique_and_available_hardware_for_each_machine", //nolint:paralleltest
+		machineReconciliationSelectsUniqueAndAvailablehardwareForEachMachine)
+
+	// Patching Hardware and TinkerbellMachine are not atomic operations, so we should handle situation, when
+	// misspelling process is aborted in the middle.
+	//
+	// Without that, new Hardware will be selected each time.
+	t.Run("uses_already_selected_hardware_if_patching_tinkerbell_machine_failed", //nolint:paralleltest
+		machineReconciliationUsesAlreadySelectedHardwareIfPatchingTinkerbellMachineFailed)
+
+	t.Run("when_machine_is_scheduled_for_removal_it", func(t *testing.T) {
+		t.Parallel()
+
+		// From https://cluster-api.sigs.k8s.io/developer/providers/machine-infrastructure.html#behavior
+		t.Run("removes_tinkerbell_finalizer", notImplemented)
+
+		// Removing machine should release used hardware.
+		t.Run("marks_hardware_as_available_for_other_machines", notImplemented)
+	})
+}
+
+func Test_Machine_reconciliation_when_machine_is_scheduled_for_removal_it(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	objects := []runtime.Object{
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, ""),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validHardware(hardwareName, uuid.New().String(), hardwareIP),
+		validMachine(machineName, clusterNamespace, clusterName),
+		validSecret(machineName, clusterNamespace),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ctx := context.Background()
+
+	tinkerbellMachineNamespacedName := types.NamespacedName{
+		Name:      tinkerbellMachineName,
+		Namespace: clusterNamespace,
+	}
+
+	updatedMachine := &infrastructurev1.TinkerbellMachine{}
+	g.Expect(client.Get(ctx, tinkerbellMachineNamespacedName, updatedMachine)).To(Succeed())
+
+	now := metav1.Now()
+	updatedMachine.ObjectMeta.DeletionTimestamp = &now
+
+	g.Expect(client.Update(ctx, updatedMachine)).To(Succeed())
+	_, err = reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	hardwareNamespacedName := types.NamespacedName{
+		Name: hardwareName,
+	}
+
+	updatedHardware := &tinkv1.Hardware{}
+	g.Expect(client.Get(ctx, hardwareNamespacedName, updatedHardware)).To(Succeed())
+
+	t.Run("removes_tinkerbell_machine_finalizer_from_hardware", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		g.Expect(updatedHardware.ObjectMeta.GetFinalizers()).To(BeEmpty())
+	})
+
+	t.Run("makes_hardware_available_for_other_machines", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		g.Expect(updatedHardware.ObjectMeta.Labels).NotTo(HaveKey(controllers.HardwareOwnerNameLabel),
+			"Found hardware owner name label")
+		g.Expect(updatedHardware.ObjectMeta.Labels).NotTo(HaveKey(controllers.HardwareOwnerNamespaceLabel),
+			"Found hardware owner namespace label")
+	})
+}
+
+func Test_Machine_reconciliation_with_Ignition_bootstrap_data(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardwareUUID := uuid.New().String()
+	secret := validSecret(machineName, clusterNamespace)
+	secret.Data["value"] = []byte(`{"systemd":{"units":[{"contents":"--provider-id=PROVIDER_ID"}]}}`)
+	secret.Data["format"] = []byte("ignition")
+
+	objects := []runtime.Object{
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validHardware(hardwareName, hardwareUUID, hardwareIP),
+		validMachine(machineName, clusterNamespace, clusterName),
+		secret,
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ctx := context.Background()
+
+	t.Run("creates_template_writing_Ignition_config", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+		g.Expect(*template.Spec.Data).To(ContainSubstring("/config.ign"))
+	})
+
+	t.Run("sets_user_data_with_provider_ID_on_hardware", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+		g.Expect(hardware.Spec.UserData).NotTo(BeNil())
+		g.Expect(*hardware.Spec.UserData).To(ContainSubstring("--provider-id=tinkerbell://" + hardwareUUID))
+	})
+}
+
+func Test_Machine_reconciliation_with_Ignition_bootstrap_data_when_workflow_reboots(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardwareUUID := uuid.New().String()
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+
+	secret := validSecret(machineName, clusterNamespace)
+	secret.Data["value"] = []byte(`{"systemd":{"units":[{"contents":"--provider-id=PROVIDER_ID"}]}}`)
+	secret.Data["format"] = []byte("ignition")
+
+	objects := []runtime.Object{
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validHardware(hardwareName, hardwareUUID, hardwareIP),
+		validMachine(machineName, clusterNamespace, clusterName),
+		secret,
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+	ctx := context.Background()
+
+	reconcile := func() {
+		machineController := &controllers.TinkerbellMachineReconciler{
+			Client:     client,
+			HostProber: &fakeHostProber{reachable: false},
+		}
+
+		_, err := machineController.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	reconcile()
+
+	template := &tinkv1.Template{}
+	g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+	g.Expect(*template.Spec.Data).NotTo(ContainSubstring("reboot-image"),
+		"Expected machine to be rebooted by the boot workflow")
+
+	setWorkflowState(t, client, tinkerbellMachineName, tinkv1.WorkflowStateSuccess)
+
+	reconcile()
+	propagateNetboot(t, client, hardwareName)
+	reconcile()
+
+	bootTemplate := &tinkv1.Template{}
+	g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName + "-boot"}, bootTemplate)).To(Succeed())
+	g.Expect(*bootTemplate.Spec.Data).To(ContainSubstring("echo b > /proc/sysrq-trigger"),
+		"Expected Ignition to run on reboot")
+
+	startBootAction(t, client, tinkerbellMachineName+"-boot", "reboot-image")
+
+	reconcile()
+
+	hardware := &tinkv1.Hardware{}
+	g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+	g.Expect(hardware.Spec.AllowPXE).To(Equal(pointer.BoolPtr(false)), "Expected netboot to be disabled")
+
+	// Without boot timeout, the node is not required to be reachable from the management cluster.
+	updatedMachine := &infrastructurev1.TinkerbellMachine{}
+	g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+	g.Expect(updatedMachine.Status.Ready).To(BeTrue(), "Expected machine to be ready once workflow handed over")
+	g.Expect(updatedMachine.Status.ErrorReason).To(BeNil())
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_with_image_catalog(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardwareUUID := uuid.New().String()
+	imageSHA256 := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID)
+	tinkerbellMachine.Spec.ImageCatalogRef = &corev1.LocalObjectReference{Name: "images"}
+
+	hardware := validHardware(hardwareName, hardwareUUID, hardwareIP)
+	hardware.Status.Interfaces[0].DHCP.Arch = "aarch64"
+
+	catalog := &infrastructurev1.TinkerbellImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "images",
+			Namespace: clusterNamespace,
+		},
+		Spec: infrastructurev1.TinkerbellImageSpec{
+			Images: []infrastructurev1.OSImage{
+				{
+					KubernetesVersion: "v1.19.4",
+					URL:               "http://images/ubuntu-amd64.raw",
+					SHA256:            imageSHA256,
+				},
+				{
+					KubernetesVersion: "v1.19.4",
+					Arch:              "arm64",
+					URL:               "http://images/ubuntu-arm64.raw",
+					SHA256:            imageSHA256,
+					Compression:       infrastructurev1.ImageCompressionNone,
+				},
+			},
+		},
+	}
+
+	objects := []runtime.Object{
+		tinkerbellMachine,
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		hardware,
+		validMachine(machineName, clusterNamespace, clusterName),
+		validSecret(machineName, clusterNamespace),
+		catalog,
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	template := &tinkv1.Template{}
+	g.Expect(client.Get(context.Background(), types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+
+	t.Run("uses_image_matching_hardware_architecture", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		g.Expect(*template.Spec.Data).To(ContainSubstring("IMG_URL: http://images/ubuntu-arm64.raw\n"))
+		g.Expect(*template.Spec.Data).To(ContainSubstring("tee /tmp/image | cat |"))
+	})
+
+	t.Run("verifies_image_digest", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		g.Expect(*template.Spec.Data).To(ContainSubstring("IMG_SHA256: " + imageSHA256))
+	})
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_with_image_catalog_without_matching_image(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardwareUUID := uuid.New().String()
+
+	tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID)
+	tinkerbellMachine.Spec.ImageCatalogRef = &corev1.LocalObjectReference{Name: "images"}
+
+	catalog := &infrastructurev1.TinkerbellImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "images",
+			Namespace: clusterNamespace,
+		},
+		Spec: infrastructurev1.TinkerbellImageSpec{
+			Images: []infrastructurev1.OSImage{
+				{
+					KubernetesVersion: "v1.20.11",
+					URL:               "http://images/ubuntu-amd64.raw",
+				},
+			},
+		},
+	}
+
+	objects := []runtime.Object{
+		tinkerbellMachine,
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validHardware(hardwareName, hardwareUUID, hardwareIP),
+		validMachine(machineName, clusterNamespace, clusterName),
+		validSecret(machineName, clusterNamespace),
+		catalog,
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).To(MatchError(controllers.ErrNoMatchingCatalogImage))
+
+	updatedMachine := &infrastructurev1.TinkerbellMachine{}
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+	g.Expect(client.Get(context.Background(), namespacedName, updatedMachine)).To(Succeed())
+	g.Expect(conditions.GetReason(updatedMachine, infrastructurev1.ImageAvailableCondition)).To(
+		Equal(infrastructurev1.ImageNotFoundReason))
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_with_requested_architecture(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardwareUUID := uuid.New().String()
+
+	tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID)
+	tinkerbellMachine.Spec.Arch = "arm64"
+
+	tinkerbellCluster := validTinkerbellCluster(clusterName, clusterNamespace)
+	tinkerbellCluster.Spec.ImageLookupFormat = "http://images/ubuntu-{{.Arch}}-{{.Firmware}}.gz"
+
+	intelHardware := validHardware("aa-intel", uuid.New().String(), "10.10.10.10")
+
+	ampereHardware := validHardware("ampere", uuid.New().String(), "10.10.10.11")
+	ampereHardware.Status.Interfaces[0].DHCP.Arch = "aarch64"
+	ampereHardware.Status.Interfaces[0].DHCP.UEFI = true
+
+	objects := []runtime.Object{
+		tinkerbellMachine,
+		validCluster(clusterName, clusterNamespace),
+		tinkerbellCluster,
+		intelHardware,
+		ampereHardware,
+		validMachine(machineName, clusterNamespace, clusterName),
+		validSecret(machineName, clusterNamespace),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ctx := context.Background()
+
+	t.Run("selects_hardware_with_requested_architecture", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(updatedMachine.Spec.HardwareName).To(Equal(ampereHardware.Name))
+	})
+
+	t.Run("renders_image_URL_and_action_images_for_hardware_architecture", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+		g.Expect(*template.Spec.Data).To(ContainSubstring("IMG_URL: http://images/ubuntu-arm64-uefi.gz"))
+		g.Expect(*template.Spec.Data).To(ContainSubstring("image: oci2disk:v1.0.0-arm64"))
+	})
+}
+
+func Test_Machine_reconciliation_with_failure_domain(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardwareUUID := uuid.New().String()
+
+	tinkerbellCluster := validTinkerbellCluster(clusterName, clusterNamespace)
+	tinkerbellCluster.Spec.FailureDomainLabelKey = "rack"
+
+	machine := validMachine(machineName, clusterNamespace, clusterName)
+	machine.Spec.FailureDomain = pointer.StringPtr("rack-2")
+
+	rack1Hardware := validHardware("aa-rack-1", uuid.New().String(), "10.10.10.10")
+	rack1Hardware.Labels = map[string]string{"rack": "rack-1"}
+
+	rack2Hardware := validHardware("rack-2", uuid.New().String(), "10.10.10.11")
+	rack2Hardware.Labels = map[string]string{"rack": "rack-2"}
+
+	objects := []runtime.Object{
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+		validCluster(clusterName, clusterNamespace),
+		tinkerbellCluster,
+		rack1Hardware,
+		rack2Hardware,
+		machine,
+		validSecret(machineName, clusterNamespace),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	updatedMachine := &infrastructurev1.TinkerbellMachine{}
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+	g.Expect(client.Get(context.Background(), namespacedName, updatedMachine)).To(Succeed())
+	g.Expect(updatedMachine.Spec.HardwareName).To(Equal(rack2Hardware.Name),
+		"Expected Hardware from the machine failure domain to be selected")
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_when_waiting_for_hardware(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	waitingSince := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	newerMachineName := "newerMachineName"
+	newerTinkerbellMachineName := "newerTinkerbellMachineName"
+	newerTinkerbellMachine := validTinkerbellMachine(newerTinkerbellMachineName, clusterNamespace, newerMachineName,
+		uuid.New().String())
+
+	olderTinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName,
+		uuid.New().String())
+	olderTinkerbellMachine.Status.WaitingForHardwareSince = &waitingSince
+
+	objects := []runtime.Object{
+		newerTinkerbellMachine,
+		olderTinkerbellMachine,
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validHardware(hardwareName, uuid.New().String(), hardwareIP),
+		validMachine(machineName, clusterNamespace, clusterName),
+		validMachine(newerMachineName, clusterNamespace, clusterName),
+		validSecret(machineName, clusterNamespace),
+		validSecret(newerMachineName, clusterNamespace),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+	ctx := context.Background()
+
+	// Newer machine is reconciled first, but the only Hardware is left for the machine waiting longer.
+	_, err := reconcileMachineWithClient(client, newerTinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	t.Run("assigns_hardware_to_machine_waiting_longest", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		olderMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(ctx, namespacedName, olderMachine)).To(Succeed())
+		g.Expect(olderMachine.Spec.HardwareName).To(Equal(hardwareName))
+		g.Expect(olderMachine.Status.WaitingForHardwareSince).To(BeNil())
+		g.Expect(conditions.IsTrue(olderMachine, infrastructurev1.HardwareSelectedCondition)).To(BeTrue())
+	})
+
+	t.Run("keeps_other_machines_waiting", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		newerMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: newerTinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(ctx, namespacedName, newerMachine)).To(Succeed())
+		g.Expect(newerMachine.Spec.HardwareName).To(BeEmpty())
+		g.Expect(newerMachine.Status.WaitingForHardwareSince).NotTo(BeNil())
+	})
+
+	t.Run("enqueues_waiting_machines_when_hardware_is_released", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		machineController := &controllers.TinkerbellMachineReconciler{Client: client}
+		mapFunc := machineController.HardwareToWaitingTinkerbellMachines(ctx)
+
+		g.Expect(mapFunc(validHardware("released", uuid.New().String(), hardwareIP))).To(ConsistOf(
+			ctrl.Request{NamespacedName: types.NamespacedName{Name: newerTinkerbellMachineName, Namespace: clusterNamespace}},
+		))
+
+		// Owner is enqueued instead of waiting machines, e.g. when maintenance of its Hardware is finished.
+		ownedHardware := validHardware("owned", uuid.New().String(), hardwareIP)
+		ownedHardware.Labels = map[string]string{
+			controllers.HardwareOwnerNameLabel:      "other",
+			controllers.HardwareOwnerNamespaceLabel: clusterNamespace,
+		}
+		g.Expect(mapFunc(ownedHardware)).To(ConsistOf(
+			ctrl.Request{NamespacedName: types.NamespacedName{Name: "other", Namespace: clusterNamespace}},
+		))
+	})
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_with_node_labels_and_taints_from_hardware(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	bootstrapData := "provider-id: PROVIDER_ID\nnode-labels: NODE_LABELS\nregister-with-taints: NODE_TAINTS"
+
+	reconcileWithHardware := func(t *testing.T, tinkerbellMachine *infrastructurev1.TinkerbellMachine,
+		hardware *tinkv1.Hardware,
+	) (client.Client, error) {
+		t.Helper()
+
+		secret := validSecret(machineName, clusterNamespace)
+		secret.Data["value"] = []byte(bootstrapData)
+
+		objects := []runtime.Object{
+			tinkerbellMachine,
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			hardware,
+			validMachine(machineName, clusterNamespace, clusterName),
+			secret,
+		}
+
+		client := kubernetesClientWithObjects(t, objects)
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+
+		return client, err
+	}
+
+	userData := func(t *testing.T, client client.Client) string {
+		t.Helper()
+		g := NewWithT(t)
+
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+		g.Expect(hardware.Spec.UserData).NotTo(BeNil())
+
+		return *hardware.Spec.UserData
+	}
+
+	t.Run("leaves_bootstrap_data_as_is_without_labels_or_taints_from_hardware", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		hardwareUUID := uuid.New().String()
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID)
+
+		client, err := reconcileWithHardware(t, tinkerbellMachine, validHardware(hardwareName, hardwareUUID, hardwareIP))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(userData(t, client)).To(Equal("provider-id: tinkerbell://" + hardwareUUID +
+			"\nnode-labels: NODE_LABELS\nregister-with-taints: NODE_TAINTS"))
+	})
+
+	t.Run("substitutes_labels_and_taints_from_hardware", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		hardwareUUID := uuid.New().String()
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID)
+		tinkerbellMachine.Spec.NodeLabelsFromHardware = []string{"rack"}
+		tinkerbellMachine.Spec.NodeTaintsFromHardware = []infrastructurev1.NodeTaintFromHardware{
+			{Key: "hardware-class", Effect: corev1.TaintEffectNoSchedule},
+		}
+
+		hardware := validHardware(hardwareName, hardwareUUID, hardwareIP)
+		hardware.Labels = map[string]string{"rack": "r1"}
+		hardware.Annotations = map[string]string{"hardware-class": "gpu"}
+
+		client, err := reconcileWithHardware(t, tinkerbellMachine, hardware)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(userData(t, client)).To(Equal("provider-id: tinkerbell://" + hardwareUUID +
+			"\nnode-labels: rack=r1\nregister-with-taints: hardware-class=gpu:NoSchedule"))
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(conditions.IsTrue(updatedMachine, infrastructurev1.NodeLabelsAndTaintsValidCondition)).To(BeTrue())
+	})
+
+	t.Run("reports_invalid_taint_from_hardware_annotation", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		hardwareUUID := uuid.New().String()
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID)
+		tinkerbellMachine.Spec.NodeTaintsFromHardware = []infrastructurev1.NodeTaintFromHardware{
+			{Key: "hardware-class", Effect: corev1.TaintEffectNoSchedule},
+		}
+
+		hardware := validHardware(hardwareName, hardwareUUID, hardwareIP)
+		hardware.Annotations = map[string]string{"hardware-class": "not a valid value"}
+
+		client, err := reconcileWithHardware(t, tinkerbellMachine, hardware)
+		g.Expect(err).To(MatchError(controllers.ErrInvalidNodeLabelsOrTaints))
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(conditions.GetReason(updatedMachine, infrastructurev1.NodeLabelsAndTaintsValidCondition)).To(
+			Equal(infrastructurev1.InvalidNodeLabelsOrTaintsReason))
+
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+		g.Expect(hardware.Spec.UserData).To(BeNil(), "Expected user data not to be set with invalid taint")
+	})
+}
+
+type fakeImageResolver struct {
+	available map[string]bool
+	err       error
+}
+
+func (f *fakeImageResolver) Available(_ context.Context, imageURL string, _ images.Options) (bool, error) {
+	return f.available[imageURL], f.err
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_with_image_verification(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		resolver         *fakeImageResolver
+		expectedError    error
+		expectedImageURL string
+		expectedReason   string
+	}{
+		"uses_image_rendered_from_lookup_format_when_available": {
+			resolver: &fakeImageResolver{
+				available: map[string]bool{"http://images/ubuntu-1.19.4.gz": true},
+			},
+			expectedImageURL: "http://images/ubuntu-1.19.4.gz",
+		},
+		"falls_back_to_first_available_image": {
+			resolver: &fakeImageResolver{
+				available: map[string]bool{"http://images/ubuntu-1.19.gz": true},
+			},
+			expectedImageURL: "http://images/ubuntu-1.19.gz",
+		},
+		"fails_when_no_image_is_available": {
+			resolver:       &fakeImageResolver{},
+			expectedError:  controllers.ErrImageNotAvailable,
+			expectedReason: infrastructurev1.ImageNotFoundReason,
+		},
+		"fails_when_image_lookup_fails": {
+			resolver: &fakeImageResolver{
+				err: fmt.Errorf("connection refused"), //nolint:goerr113
+			},
+			expectedReason: infrastructurev1.ImageLookupFailedReason,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			hardwareUUID := uuid.New().String()
+
+			tinkerbellCluster := validTinkerbellCluster(clusterName, clusterNamespace)
+			tinkerbellCluster.Spec.ImageLookupFormat = "http://images/ubuntu-{{.KubernetesVersion}}.gz"
+			tinkerbellCluster.Spec.ImageLookupFallbackFormats = []string{
+				"http://images/ubuntu-{{.KubernetesMinorVersion}}.gz",
+			}
+			tinkerbellCluster.Spec.ImageLookupVerify = true
+
+			objects := []runtime.Object{
+				validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+				validCluster(clusterName, clusterNamespace),
+				tinkerbellCluster,
+				validHardware(hardwareName, hardwareUUID, hardwareIP),
+				validMachine(machineName, clusterNamespace, clusterName),
+				validSecret(machineName, clusterNamespace),
+			}
+
+			client := kubernetesClientWithObjects(t, objects)
+
+			machineController := &controllers.TinkerbellMachineReconciler{
+				Client:        client,
+				ImageResolver: c.resolver,
+			}
+
+			request := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      tinkerbellMachineName,
+					Namespace: clusterNamespace,
+				},
+			}
+
+			ctx := context.Background()
+
+			_, err := machineController.Reconcile(ctx, request)
+
+			tinkerbellMachine := &infrastructurev1.TinkerbellMachine{}
+			g.Expect(client.Get(ctx, request.NamespacedName, tinkerbellMachine)).To(Succeed())
+
+			if c.expectedReason != "" {
+				g.Expect(err).To(HaveOccurred())
+
+				if c.expectedError != nil {
+					g.Expect(err).To(MatchError(c.expectedError))
+				}
+
+				condition := conditions.Get(tinkerbellMachine, infrastructurev1.ImageAvailableCondition)
+				g.Expect(condition).NotTo(BeNil())
+				g.Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+				g.Expect(condition.Reason).To(Equal(c.expectedReason))
+
+				template := &tinkv1.Template{}
+				err := client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, template)
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected template not to be created")
+
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(conditions.IsTrue(tinkerbellMachine, infrastructurev1.ImageAvailableCondition)).To(BeTrue())
+
+			template := &tinkv1.Template{}
+			g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+			g.Expect(*template.Spec.Data).To(ContainSubstring("IMG_URL: " + c.expectedImageURL + "\n"))
+		})
+	}
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_when_workflow_succeeded(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardwareUUID := uuid.New().String()
+
+	objects := []runtime.Object{
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validHardware(hardwareName, hardwareUUID, hardwareIP),
+		validMachine(machineName, clusterNamespace, clusterName),
+		validSecret(machineName, clusterNamespace),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ctx := context.Background()
+
+	workflow := &tinkv1.Workflow{}
+	g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, workflow)).To(Succeed())
+
+	workflow.Status.State = tinkv1.WorkflowStateSuccess
+	g.Expect(client.Update(ctx, workflow)).To(Succeed())
+
+	_, err = reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Otherwise rebooted machine would run the workflow again.
+	t.Run("disables_netboot_on_hardware", func(t *testing.T) { //nolint:paralleltest
+		g := NewWithT(t)
+
+		updatedHardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, updatedHardware)).To(Succeed())
+
+		g.Expect(updatedHardware.Spec.AllowPXE).To(Equal(pointer.BoolPtr(false)), "Expected PXE to be disallowed")
+		g.Expect(updatedHardware.Spec.AllowWorkflow).To(Equal(pointer.BoolPtr(false)),
+			"Expected workflow to be disallowed")
+	})
+
+	t.Run("recreates_workflow_and_enables_netboot_when_reprovisioning_is_requested", func(t *testing.T) { //nolint:paralleltest,lll
+		g := NewWithT(t)
+
+		namespacedName := types.NamespacedName{
+			Name:      tinkerbellMachineName,
+			Namespace: clusterNamespace,
+		}
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+
+		updatedMachine.Annotations = map[string]string{infrastructurev1.ReprovisionAnnotation: ""}
+		g.Expect(client.Update(ctx, updatedMachine)).To(Succeed())
+
+		// First reconciliation removes the workflow, second one recreates it.
+		for i := 0; i < 2; i++ {
+			_, err = reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+
+		recreatedWorkflow := &tinkv1.Workflow{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, recreatedWorkflow)).To(Succeed())
+		g.Expect(recreatedWorkflow.Status.State).To(BeEmpty(), "Expected workflow to be recreated")
+
+		updatedHardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, updatedHardware)).To(Succeed())
+		g.Expect(updatedHardware.Spec.AllowPXE).To(Equal(pointer.BoolPtr(true)), "Expected PXE to be allowed")
+
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(updatedMachine.Annotations).NotTo(HaveKey(infrastructurev1.ReprovisionAnnotation),
+			"Expected reprovision annotation to be removed")
+	})
+}
+
+const (
+	machineName           = "myMachineName"
+	tinkerbellMachineName = "myTinkerbellMachineName"
+)
+
+//nolint:funlen
+func Test_Machine_reconciliation_when_workflow_failed(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardwareUUID := uuid.New().String()
+
+	objects := []runtime.Object{
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validHardware(hardwareName, hardwareUUID, hardwareIP),
+		validMachine(machineName, clusterNamespace, clusterName),
+		validSecret(machineName, clusterNamespace),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	machineController := &controllers.TinkerbellMachineReconciler{
+		Client:                      client,
+		HardwareQuarantineThreshold: 2,
+	}
+
+	request := ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace},
+	}
+	ctx := context.Background()
+
+	_, err := machineController.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	failWorkflow := func(g *WithT, uid types.UID) {
+		workflow := &tinkv1.Workflow{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, workflow)).To(Succeed())
+
+		workflow.UID = uid
+		workflow.Status.State = tinkv1.WorkflowStateFailed
+		g.Expect(client.Update(ctx, workflow)).To(Succeed())
+
+		// Reconciling twice to verify the workflow is counted only once.
+		for i := 0; i < 2; i++ {
+			_, err = machineController.Reconcile(ctx, request)
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+	}
+
+	t.Run("counts_provisioning_failures_of_hardware", func(t *testing.T) { //nolint:paralleltest
+		g := NewWithT(t)
+
+		failWorkflow(g, "first")
+
+		updatedHardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, updatedHardware)).To(Succeed())
+		g.Expect(updatedHardware.Annotations).To(HaveKeyWithValue(controllers.HardwareProvisioningFailuresAnnotation, "1"))
+		g.Expect(updatedHardware.Labels).NotTo(HaveKey(controllers.HardwareQuarantinedLabel),
+			"Expected Hardware not to be quarantined before reaching the threshold")
+	})
+
+	t.Run("quarantines_hardware_when_failures_reach_threshold", func(t *testing.T) { //nolint:paralleltest
+		g := NewWithT(t)
+
+		failWorkflow(g, "second")
+
+		updatedHardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, updatedHardware)).To(Succeed())
+		g.Expect(updatedHardware.Annotations).To(HaveKeyWithValue(controllers.HardwareProvisioningFailuresAnnotation, "2"))
+		g.Expect(updatedHardware.Labels).To(HaveKey(controllers.HardwareQuarantinedLabel))
+	})
+
+	t.Run("clears_quarantine_when_requested", func(t *testing.T) { //nolint:paralleltest
+		g := NewWithT(t)
+
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+
+		hardware.Annotations[controllers.HardwareClearQuarantineAnnotation] = ""
+		g.Expect(client.Update(ctx, hardware)).To(Succeed())
+
+		quarantineController := &controllers.HardwareQuarantineReconciler{Client: client}
+
+		_, err := quarantineController.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: hardwareName}})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		updatedHardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, updatedHardware)).To(Succeed())
+		g.Expect(updatedHardware.Labels).NotTo(HaveKey(controllers.HardwareQuarantinedLabel))
+		g.Expect(updatedHardware.Annotations).NotTo(HaveKey(controllers.HardwareProvisioningFailuresAnnotation))
+		g.Expect(updatedHardware.Annotations).NotTo(HaveKey(controllers.HardwareClearQuarantineAnnotation))
+	})
+}
+
+func Test_Machine_reconciliation_with_quarantined_hardware(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	quarantinedHardware := validHardware("aa-quarantined", uuid.New().String(), "10.10.10.10")
+	quarantinedHardware.Labels = map[string]string{controllers.HardwareQuarantinedLabel: "true"}
+
+	hardware := validHardware(hardwareName, uuid.New().String(), hardwareIP)
+
+	objects := []runtime.Object{
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, uuid.New().String()),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		quarantinedHardware,
+		hardware,
+		validMachine(machineName, clusterNamespace, clusterName),
+		validSecret(machineName, clusterNamespace),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	updatedMachine := &infrastructurev1.TinkerbellMachine{}
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+	g.Expect(client.Get(context.Background(), namespacedName, updatedMachine)).To(Succeed())
+	g.Expect(updatedMachine.Spec.HardwareName).To(Equal(hardware.Name), "Expected quarantined Hardware to be skipped")
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_with_workflow_actions(t *testing.T) {
+	t.Parallel()
+
+	tinkerbellMachineWithActions := func() *infrastructurev1.TinkerbellMachine {
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, "")
+		tinkerbellMachine.Spec.PreInstallActions = []infrastructurev1.WorkflowAction{
+			{
+				Name:  "update-firmware",
+				Image: "quay.io/example/firmware:v1",
+				Env: []infrastructurev1.WorkflowActionEnvVar{
+					{Name: "BMC_USER", Value: "admin"},
+					{
+						Name: "BMC_PASSWORD",
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "bmc"},
+							Key:                  "password",
+						},
+					},
+				},
+			},
+		}
+		tinkerbellMachine.Spec.PostInstallActions = []infrastructurev1.WorkflowAction{
+			{Name: "register", Image: "quay.io/example/register:v1", Timeout: &metav1.Duration{Duration: time.Minute}},
+		}
+
+		return tinkerbellMachine
+	}
+
+	objects := func(tinkerbellMachine *infrastructurev1.TinkerbellMachine) []runtime.Object {
+		return []runtime.Object{
+			tinkerbellMachine,
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			validHardware(hardwareName, uuid.New().String(), hardwareIP),
+			validMachine(machineName, clusterNamespace, clusterName),
+			validSecret(machineName, clusterNamespace),
+		}
+	}
+
+	t.Run("adds_actions_with_environment_from_secrets", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		bmcSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "bmc", Namespace: clusterNamespace},
+			Data:       map[string][]byte{"password": []byte("s3cr3t")},
+		}
+
+		client := kubernetesClientWithObjects(t, append(objects(tinkerbellMachineWithActions()), bmcSecret))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(context.Background(), types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+
+		data := *template.Spec.Data
+		g.Expect(data).To(ContainSubstring(`"BMC_USER": "admin"`))
+		g.Expect(data).To(ContainSubstring(`"BMC_PASSWORD": "s3cr3t"`))
+		g.Expect(strings.Index(data, `"update-firmware"`)).To(BeNumerically("<", strings.Index(data, `"stream-image"`)))
+		g.Expect(strings.Index(data, `"register"`)).To(BeNumerically("<", strings.Index(data, `"kexec-image"`)))
+		g.Expect(data).To(ContainSubstring("timeout: 60\n"))
+	})
+
+	t.Run("fails_when_secret_key_is_missing", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		bmcSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "bmc", Namespace: clusterNamespace},
+			Data:       map[string][]byte{"user": []byte("admin")},
+		}
+
+		client := kubernetesClientWithObjects(t, append(objects(tinkerbellMachineWithActions()), bmcSecret))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).To(MatchError(ContainSubstring(controllers.ErrMissingReferencedKey.Error())))
+
+		template := &tinkv1.Template{}
+		err = client.Get(context.Background(), types.NamespacedName{Name: tinkerbellMachineName}, template)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected no Template to be created")
+	})
+
+	t.Run("skips_optional_environment_when_secret_is_missing", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		tinkerbellMachine := tinkerbellMachineWithActions()
+		tinkerbellMachine.Spec.PreInstallActions[0].Env[1].SecretKeyRef.Optional = pointer.BoolPtr(true)
+
+		client := kubernetesClientWithObjects(t, objects(tinkerbellMachine))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(context.Background(), types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+		g.Expect(*template.Spec.Data).NotTo(ContainSubstring("BMC_PASSWORD"))
+	})
+}
+
+func Test_Machine_reconciliation_with_default_user(t *testing.T) {
+	t.Parallel()
+
+	sshKeys := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ssh-keys", Namespace: clusterNamespace},
+		Data:       map[string]string{"authorized_keys": "# admins\nssh-ed25519 AAAA alice@example.com\n\n"},
+	}
+
+	objects := func(
+		tinkerbellMachine *infrastructurev1.TinkerbellMachine,
+		tinkerbellCluster *infrastructurev1.TinkerbellCluster,
+	) []runtime.Object {
+		return []runtime.Object{
+			tinkerbellMachine,
+			tinkerbellCluster,
+			validCluster(clusterName, clusterNamespace),
+			validHardware(hardwareName, uuid.New().String(), hardwareIP),
+			validMachine(machineName, clusterNamespace, clusterName),
+			validSecret(machineName, clusterNamespace),
+			sshKeys,
+		}
+	}
+
+	clusterWithDefaultUser := func() *infrastructurev1.TinkerbellCluster {
+		tinkerbellCluster := validTinkerbellCluster(clusterName, clusterNamespace)
+		tinkerbellCluster.Spec.DefaultUser = &infrastructurev1.DefaultUser{
+			Name: "core",
+			Sudo: infrastructurev1.SudoPolicyNone,
+			SSHAuthorizedKeysFrom: &infrastructurev1.SSHAuthorizedKeysSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ssh-keys"},
+					Key:                  "authorized_keys",
+				},
+			},
+		}
+
+		return tinkerbellCluster
+	}
+
+	t.Run("uses_default_user_of_the_cluster", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, "")
+		client := kubernetesClientWithObjects(t, objects(tinkerbellMachine, clusterWithDefaultUser()))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(context.Background(), types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+
+		data := *template.Spec.Data
+		g.Expect(data).To(ContainSubstring(`name: "core"`))
+		g.Expect(data).To(ContainSubstring("sudo: false"))
+		g.Expect(data).To(ContainSubstring(`- "ssh-ed25519 AAAA alice@example.com"`))
+		g.Expect(data).NotTo(ContainSubstring("# admins"))
+	})
+
+	t.Run("prefers_default_user_of_the_machine", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, "")
+		tinkerbellMachine.Spec.DefaultUser = &infrastructurev1.DefaultUser{Disabled: true}
+
+		client := kubernetesClientWithObjects(t, objects(tinkerbellMachine, clusterWithDefaultUser()))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(context.Background(), types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+
+		g.Expect(*template.Spec.Data).To(ContainSubstring("users: []"))
+		g.Expect(*template.Spec.Data).NotTo(ContainSubstring(`name: "core"`))
+	})
+
+	t.Run("fails_when_referenced_key_is_missing", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		tinkerbellCluster := clusterWithDefaultUser()
+		tinkerbellCluster.Spec.DefaultUser.SSHAuthorizedKeysFrom.ConfigMapKeyRef.Key = "missing"
+
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, "")
+		client := kubernetesClientWithObjects(t, objects(tinkerbellMachine, tinkerbellCluster))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).To(MatchError(ContainSubstring(controllers.ErrMissingReferencedKey.Error())))
+	})
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_with_template_override_reference(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName}
+	configMapName := types.NamespacedName{Name: "override", Namespace: clusterNamespace}
+
+	templateOverride := func(image string) string {
+		return `version: "0.1"
+name: {{.Name}}
+global_timeout: 6000
+tasks:
+  - name: "{{.Name}}"
+    worker: "{{.device_1}}"
+    actions:
+      - name: "stream-image"
+        image: ` + image + `
+        environment:
+          DEST_DISK: {{index .Disks 0}}
+          IMG_URL: {{.ImageURL}}
+          HARDWARE: {{.Hardware.Name}}
+`
+	}
+
+	objects := func(tinkerbellMachine *infrastructurev1.TinkerbellMachine, override client.Object) []runtime.Object {
+		return []runtime.Object{
+			tinkerbellMachine,
+			override,
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			validHardware(hardwareName, uuid.New().String(), hardwareIP),
+			validMachine(machineName, clusterNamespace, clusterName),
+			validSecret(machineName, clusterNamespace),
+		}
+	}
+
+	machineWithConfigMapOverride := func() *infrastructurev1.TinkerbellMachine {
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, "")
+		tinkerbellMachine.Spec.TemplateOverrideRef = &infrastructurev1.TemplateOverrideReference{
+			Kind: infrastructurev1.TemplateOverrideKindConfigMap,
+			Name: "override",
+		}
+
+		return tinkerbellMachine
+	}
+
+	overrideConfigMap := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configMapName.Name, Namespace: configMapName.Namespace},
+			Data:       map[string]string{"template": templateOverride("image2disk:v1")},
+		}
+	}
+
+	t.Run("renders_template_override_from_ConfigMap", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		client := kubernetesClientWithObjects(t, objects(machineWithConfigMapOverride(), overrideConfigMap()))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(ctx, namespacedName, template)).To(Succeed())
+
+		data := *template.Spec.Data
+		g.Expect(data).To(ContainSubstring("name: " + tinkerbellMachineName + "\n"))
+		g.Expect(data).To(ContainSubstring(`worker: "{{.device_1}}"`))
+		g.Expect(data).To(ContainSubstring("DEST_DISK: /dev/sda\n"))
+		g.Expect(data).To(ContainSubstring("HARDWARE: " + hardwareName + "\n"))
+		g.Expect(data).NotTo(ContainSubstring("IMG_URL: \n"))
+	})
+
+	t.Run("renders_template_override_from_Template", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, "")
+		tinkerbellMachine.Spec.TemplateOverrideRef = &infrastructurev1.TemplateOverrideReference{
+			Kind: infrastructurev1.TemplateOverrideKindTemplate,
+			Name: "shared",
+		}
+
+		shared := &tinkv1.Template{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "shared",
+				Annotations: map[string]string{tinkv1.TemplateUnmanagedAnnotation: "true"},
+			},
+			Spec: tinkv1.TemplateSpec{Data: pointer.StringPtr(templateOverride("image2disk:shared"))},
+		}
+
+		client := kubernetesClientWithObjects(t, objects(tinkerbellMachine, shared))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(ctx, namespacedName, template)).To(Succeed())
+		g.Expect(*template.Spec.Data).To(ContainSubstring("image: image2disk:shared"))
+		g.Expect(*template.Spec.Data).To(ContainSubstring("DEST_DISK: /dev/sda\n"))
+	})
+
+	t.Run("fails_when_template_override_is_pushed_to_tinkerbell", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, "")
+		tinkerbellMachine.Spec.TemplateOverrideRef = &infrastructurev1.TemplateOverrideReference{
+			Kind: infrastructurev1.TemplateOverrideKindTemplate,
+			Name: "shared",
+		}
+
+		shared := &tinkv1.Template{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+			Spec:       tinkv1.TemplateSpec{Data: pointer.StringPtr(templateOverride("image2disk:shared"))},
+		}
+
+		client := kubernetesClientWithObjects(t, objects(tinkerbellMachine, shared))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).To(MatchError(controllers.ErrTemplateOverrideManaged))
+
+		template := &tinkv1.Template{}
+		err = client.Get(ctx, namespacedName, template)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected no template to be rendered")
+	})
+
+	t.Run("fails_when_template_override_is_invalid", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		configMap := overrideConfigMap()
+		configMap.Data["template"] = "name: {{.Unknown}}"
+
+		client := kubernetesClientWithObjects(t, objects(machineWithConfigMapOverride(), configMap))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).To(MatchError(ContainSubstring("rendering template override")))
+	})
+
+	t.Run("re-renders_template_when_override_changes_before_workflow_starts", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		configMap := overrideConfigMap()
+		client := kubernetesClientWithObjects(t, objects(machineWithConfigMapOverride(), configMap))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		// Marks the workflow, so its recreation can be observed.
+		workflow := &tinkv1.Workflow{}
+		g.Expect(client.Get(ctx, namespacedName, workflow)).To(Succeed())
+		workflow.Labels = map[string]string{"rendered": "v1"}
+		g.Expect(client.Update(ctx, workflow)).To(Succeed())
+
+		g.Expect(client.Get(ctx, configMapName, configMap)).To(Succeed())
+		configMap.Data["template"] = templateOverride("image2disk:v2")
+		g.Expect(client.Update(ctx, configMap)).To(Succeed())
+
+		machineController := &controllers.TinkerbellMachineReconciler{Client: client}
+		g.Expect(machineController.TemplateOverrideToTinkerbellMachines(ctx,
+			infrastructurev1.TemplateOverrideKindConfigMap)(configMap)).To(ConsistOf(
+			ctrl.Request{NamespacedName: types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}},
+		))
+
+		_, err = reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(ctx, namespacedName, template)).To(Succeed())
+		g.Expect(*template.Spec.Data).To(ContainSubstring("image: image2disk:v2"))
+
+		recreatedWorkflow := &tinkv1.Workflow{}
+		g.Expect(client.Get(ctx, namespacedName, recreatedWorkflow)).To(Succeed())
+		g.Expect(recreatedWorkflow.Labels).NotTo(HaveKey("rendered"), "Expected workflow to be recreated")
+	})
+
+	t.Run("keeps_template_and_workflow_once_workflow_is_created_in_Tinkerbell", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		configMap := overrideConfigMap()
+		client := kubernetesClientWithObjects(t, objects(machineWithConfigMapOverride(), configMap))
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		// Workflow status may still be pending while the Hardware already runs the workflow.
+		workflow := &tinkv1.Workflow{}
+		g.Expect(client.Get(ctx, namespacedName, workflow)).To(Succeed())
+		workflow.SetTinkID(uuid.New().String())
+		workflow.Status.State = tinkv1.WorkflowStatePending
+		g.Expect(client.Update(ctx, workflow)).To(Succeed())
+
+		g.Expect(client.Get(ctx, configMapName, configMap)).To(Succeed())
+		configMap.Data["template"] = templateOverride("image2disk:v2")
+		g.Expect(client.Update(ctx, configMap)).To(Succeed())
+
+		_, err = reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(ctx, namespacedName, template)).To(Succeed())
+		g.Expect(*template.Spec.Data).To(ContainSubstring("image: image2disk:v1"))
+
+		g.Expect(client.Get(ctx, namespacedName, workflow)).To(Succeed())
+		g.Expect(workflow.DeletionTimestamp.IsZero()).To(BeTrue(), "Expected workflow to be kept")
+	})
+}
+
+func Test_Machine_reconciliation_with_RAID(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, "")
+	tinkerbellMachine.Spec.RAID = &infrastructurev1.RAID{
+		Level:   1,
+		Devices: []string{"/dev/sda", "/dev/sdb"},
+	}
+
+	mirroredHardware := validHardware(hardwareName, uuid.New().String(), hardwareIP)
+	mirroredHardware.Status.Disks = append(mirroredHardware.Status.Disks, tinkv1.Disk{Device: "/dev/sdb"})
+
+	objects := []runtime.Object{
+		tinkerbellMachine,
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		// Sorted before the Hardware with member disks, but having a single disk.
+		validHardware("aa-single-disk", uuid.New().String(), "10.10.10.10"),
+		mirroredHardware,
+		validMachine(machineName, clusterNamespace, clusterName),
+		validSecret(machineName, clusterNamespace),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ctx := context.Background()
+
+	updatedMachine := &infrastructurev1.TinkerbellMachine{}
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+	g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+	g.Expect(updatedMachine.Spec.HardwareName).To(Equal(hardwareName), "Expected Hardware with member disks")
+
+	template := &tinkv1.Template{}
+	g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+	g.Expect(*template.Spec.Data).To(ContainSubstring("--level=1 --raid-devices=2 /dev/sda /dev/sdb"))
+	g.Expect(*template.Spec.Data).To(ContainSubstring("DEST_DISK: /dev/md0\n"))
+	g.Expect(*template.Spec.Data).To(ContainSubstring("BLOCK_DEVICE: /dev/md0p1\n"))
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_when_RAID_machine_waits_for_hardware(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	waitingSince := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	raidMachineName := "raidMachineName"
+	raidTinkerbellMachineName := "raidTinkerbellMachineName"
+	raidTinkerbellMachine := validTinkerbellMachine(raidTinkerbellMachineName, clusterNamespace, raidMachineName,
+		uuid.New().String())
+	raidTinkerbellMachine.Spec.RAID = &infrastructurev1.RAID{
+		Level:   1,
+		Devices: []string{"/dev/sda", "/dev/sdb"},
+	}
+	raidTinkerbellMachine.Status.WaitingForHardwareSince = &waitingSince
+
+	objects := []runtime.Object{
+		raidTinkerbellMachine,
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, uuid.New().String()),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validHardware(hardwareName, uuid.New().String(), hardwareIP),
+		validMachine(raidMachineName, clusterNamespace, clusterName),
+		validMachine(machineName, clusterNamespace, clusterName),
+		validSecret(raidMachineName, clusterNamespace),
+		validSecret(machineName, clusterNamespace),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+	ctx := context.Background()
+
+	// Machine waiting longer for Hardware with member disks does not hold back the single disk Hardware.
+	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = reconcileMachineWithClient(client, raidTinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	updatedMachine := &infrastructurev1.TinkerbellMachine{}
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+	g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+	g.Expect(updatedMachine.Spec.HardwareName).To(Equal(hardwareName),
+		"Expected single disk Hardware to be selected for machine without RAID")
+
+	raidMachine := &infrastructurev1.TinkerbellMachine{}
+	namespacedName = types.NamespacedName{Name: raidTinkerbellMachineName, Namespace: clusterNamespace}
+	g.Expect(client.Get(ctx, namespacedName, raidMachine)).To(Succeed())
+	g.Expect(raidMachine.Spec.HardwareName).To(BeEmpty())
+	g.Expect(raidMachine.Status.WaitingForHardwareSince).NotTo(BeNil(), "Expected RAID machine to keep waiting")
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_when_machines_with_different_filters_wait_for_hardware(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// waitingMachine returns TinkerbellMachine with its Machine and bootstrap Secret, waiting for Hardware
+	// for given duration.
+	waitingMachine := func(name string, waitingFor time.Duration) (*infrastructurev1.TinkerbellMachine, []runtime.Object) {
+		waitingSince := metav1.NewTime(time.Now().Add(-waitingFor))
+
+		tinkerbellMachine := validTinkerbellMachine(name, clusterNamespace, name, uuid.New().String())
+		tinkerbellMachine.Status.WaitingForHardwareSince = &waitingSince
+
+		return tinkerbellMachine, []runtime.Object{
+			validMachine(name, clusterNamespace, clusterName),
+			validSecret(name, clusterNamespace),
+		}
+	}
+
+	hardwareOf := func(t *testing.T, client client.Client, name string) string {
+		t.Helper()
+		g := NewWithT(t)
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: name, Namespace: clusterNamespace}, updatedMachine)).To(Succeed())
+
+		return updatedMachine.Spec.HardwareName
+	}
+
+	t.Run("leaves_hardware_other_machines_can_not_select_to_newer_machine", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		// Oldest machine may only select the ampere Hardware, which is sorted last.
+		ampereMachine, ampereObjects := waitingMachine("ampere-machine", 2*time.Hour)
+		ampereMachine.Spec.Arch = "arm64"
+		anyMachine, anyObjects := waitingMachine("any-machine", time.Hour)
+
+		ampereHardware := validHardware("zz-ampere", uuid.New().String(), "10.10.10.11")
+		ampereHardware.Status.Interfaces[0].DHCP.Arch = "aarch64"
+
+		objects := []runtime.Object{
+			ampereMachine,
+			anyMachine,
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			validHardware("aa-intel", uuid.New().String(), "10.10.10.10"),
+			ampereHardware,
+		}
+		objects = append(objects, ampereObjects...)
+		objects = append(objects, anyObjects...)
+
+		client := kubernetesClientWithObjects(t, objects)
+
+		// Newer machine is reconciled first, but must not take the only Hardware of the older machine.
+		_, err := reconcileMachineWithClient(client, anyMachine.Name, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		_, err = reconcileMachineWithClient(client, ampereMachine.Name, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(hardwareOf(t, client, anyMachine.Name)).To(Equal("aa-intel"))
+		g.Expect(hardwareOf(t, client, ampereMachine.Name)).To(Equal(ampereHardware.Name))
+	})
+
+	t.Run("does_not_leave_hardware_to_paused_machines", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		pausedMachine, pausedObjects := waitingMachine("paused-machine", 2*time.Hour)
+		pausedMachine.Annotations = map[string]string{clusterv1.PausedAnnotation: ""}
+		newerMachine, newerObjects := waitingMachine("newer-machine", time.Hour)
+
+		objects := []runtime.Object{
+			pausedMachine,
+			newerMachine,
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			validHardware(hardwareName, uuid.New().String(), hardwareIP),
+		}
+		objects = append(objects, pausedObjects...)
+		objects = append(objects, newerObjects...)
+
+		client := kubernetesClientWithObjects(t, objects)
+
+		_, err := reconcileMachineWithClient(client, newerMachine.Name, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(hardwareOf(t, client, newerMachine.Name)).To(Equal(hardwareName))
+	})
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_with_hardware_reuse(t *testing.T) {
+	t.Parallel()
+
+	group := clusterNamespace + "/MachineDeployment/md-0"
+	reservedUntil := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	reservedHardware := func(name, group string) *tinkv1.Hardware {
+		hardware := validHardware(name, uuid.New().String(), "10.10.10.10")
+		hardware.Annotations = map[string]string{
+			controllers.HardwareReuseGroupAnnotation:    group,
+			controllers.HardwareReservedUntilAnnotation: reservedUntil,
+		}
+
+		return hardware
+	}
+
+	reconcileMachine := func(t *testing.T, reuse *infrastructurev1.HardwareReuse) (client.Client, string) {
+		t.Helper()
+		g := NewWithT(t)
+
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, "")
+		tinkerbellMachine.Spec.HardwareReuse = reuse
+
+		machine := validMachine(machineName, clusterNamespace, clusterName)
+		machine.Labels[clusterv1.MachineDeploymentLabelName] = "md-0"
+
+		objects := []runtime.Object{
+			tinkerbellMachine,
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			reservedHardware("aa-reserved", clusterNamespace+"/MachineDeployment/md-1"),
+			validHardware("ab-available", uuid.New().String(), hardwareIP),
+			reservedHardware("zz-released", group),
+			machine,
+			validSecret(machineName, clusterNamespace),
+		}
+
+		client := kubernetesClientWithObjects(t, objects)
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(context.Background(), namespacedName, updatedMachine)).To(Succeed())
+
+		return client, updatedMachine.Spec.HardwareName
+	}
+
+	t.Run("selects_hardware_released_by_machine_of_the_same_group", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		_, hardwareName := reconcileMachine(t, &infrastructurev1.HardwareReuse{})
+		g.Expect(hardwareName).To(Equal("zz-released"))
+	})
+
+	t.Run("does_not_select_hardware_reserved_for_other_groups", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		_, hardwareName := reconcileMachine(t, nil)
+		g.Expect(hardwareName).To(Equal("ab-available"))
+	})
+
+	t.Run("reserves_hardware_for_the_group_when_machine_is_removed", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		client, hardwareName := reconcileMachine(t, &infrastructurev1.HardwareReuse{
+			ReservationPeriod: &metav1.Duration{Duration: 2 * time.Hour},
+		})
+		ctx := context.Background()
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+
+		now := metav1.Now()
+		updatedMachine.DeletionTimestamp = &now
+		g.Expect(client.Update(ctx, updatedMachine)).To(Succeed())
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+		g.Expect(hardware.Labels).NotTo(HaveKey(controllers.HardwareOwnerNameLabel), "Expected Hardware to be released")
+		g.Expect(hardware.Annotations).To(HaveKeyWithValue(controllers.HardwareReuseGroupAnnotation, group))
+
+		reservedUntil, err := time.Parse(time.RFC3339, hardware.Annotations[controllers.HardwareReservedUntilAnnotation])
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(reservedUntil).To(BeTemporally(">", now.Add(time.Hour)))
+	})
+}
+
+type fakeHostProber struct {
+	reachable bool
+}
+
+func (f *fakeHostProber) Reachable(_ context.Context, _ string) (bool, error) {
+	return f.reachable, nil
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_when_adopting_hardware(t *testing.T) {
+	t.Parallel()
+
+	hardwareUUID := uuid.New().String()
+
+	adoptedNode := func(providerID string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "adopted-node"},
+			Spec:       corev1.NodeSpec{ProviderID: providerID},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: hardwareIP}},
+			},
+		}
+	}
+
+	reconcileAdoptingMachine := func(
+		t *testing.T,
+		reachable bool,
+		providerID string,
+		nodes ...runtime.Object,
+	) (client.Client, client.Client, ctrl.Result) {
+		t.Helper()
+		g := NewWithT(t)
+
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID)
+		tinkerbellMachine.Spec.Provisioning = infrastructurev1.ProvisioningModeAdopt
+		tinkerbellMachine.Spec.HardwareName = hardwareName
+		tinkerbellMachine.Spec.ProviderID = providerID
+
+		objects := []runtime.Object{
+			tinkerbellMachine,
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			// Sorted before the adopted Hardware, so it would be selected for installation.
+			validHardware("aa-available", uuid.New().String(), "10.10.10.10"),
+			validHardware(hardwareName, hardwareUUID, hardwareIP),
+			validMachine(machineName, clusterNamespace, clusterName),
+			validSecret(machineName, clusterNamespace),
+		}
+
+		workloadClient := kubernetesClientWithObjects(t, nodes)
+		clusterClient := func(_ context.Context, cluster client.ObjectKey) (client.Client, error) {
+			g.Expect(cluster).To(Equal(client.ObjectKey{Namespace: clusterNamespace, Name: clusterName}))
+
+			return workloadClient, nil
+		}
+
+		client := kubernetesClientWithObjects(t, objects)
+
+		machineController := &controllers.TinkerbellMachineReconciler{
+			Client:        client,
+			HostProber:    &fakeHostProber{reachable: reachable},
+			ClusterClient: clusterClient,
+		}
+
+		request := ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace},
+		}
+
+		result, err := machineController.Reconcile(context.Background(), request)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return client, workloadClient, result
+	}
+
+	getMachine := func(t *testing.T, client client.Client) *infrastructurev1.TinkerbellMachine {
+		t.Helper()
+		g := NewWithT(t)
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(context.Background(), namespacedName, updatedMachine)).To(Succeed())
+
+		return updatedMachine
+	}
+
+	getNode := func(t *testing.T, client client.Client) *corev1.Node {
+		t.Helper()
+		g := NewWithT(t)
+
+		node := &corev1.Node{}
+		g.Expect(client.Get(context.Background(), types.NamespacedName{Name: "adopted-node"}, node)).To(Succeed())
+
+		return node
+	}
+
+	t.Run("marks_machine_ready_without_installing_hardware", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		client, _, _ := reconcileAdoptingMachine(t, true, "", adoptedNode("tinkerbell://"+hardwareUUID))
+		ctx := context.Background()
+
+		updatedMachine := getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeTrue(), "Expected machine to be ready")
+		g.Expect(updatedMachine.Spec.ProviderID).To(Equal("tinkerbell://" + hardwareUUID))
+		g.Expect(updatedMachine.Status.Addresses).To(ConsistOf(corev1.NodeAddress{
+			Type:    corev1.NodeInternalIP,
+			Address: hardwareIP,
+		}))
+		g.Expect(conditions.IsTrue(updatedMachine, infrastructurev1.HostReachableCondition)).To(BeTrue())
+		g.Expect(conditions.IsTrue(updatedMachine, infrastructurev1.NodeProviderIDMatchesCondition)).To(BeTrue())
+
+		template := &tinkv1.Template{}
+		err := client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, template)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected no template to be created")
+
+		workflow := &tinkv1.Workflow{}
+		err = client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, workflow)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected no workflow to be created")
+
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+		g.Expect(hardware.Labels).To(HaveKeyWithValue(controllers.HardwareOwnerNameLabel, tinkerbellMachineName))
+		g.Expect(hardware.Spec.AllowPXE).To(Equal(pointer.BoolPtr(false)), "Expected netboot to be disabled")
+		g.Expect(hardware.Spec.UserData).To(BeNil(), "Expected user data not to be written")
+	})
+
+	t.Run("waits_until_host_is_reachable", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		client, _, result := reconcileAdoptingMachine(t, false, "")
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected reachability to be checked again")
+
+		updatedMachine := getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeFalse(), "Expected machine not to be ready")
+		g.Expect(conditions.GetReason(updatedMachine, infrastructurev1.HostReachableCondition)).
+			To(Equal(infrastructurev1.HostUnreachableReason))
+	})
+
+	// Cluster API links the Machine to the Node with the provider ID of the TinkerbellMachine.
+	t.Run("links_node_without_provider_id_by_setting_provider_id", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		client, workloadClient, _ := reconcileAdoptingMachine(t, true, "", adoptedNode(""))
+
+		updatedMachine := getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeTrue(), "Expected machine to be ready")
+		g.Expect(getNode(t, workloadClient).Spec.ProviderID).To(Equal(updatedMachine.Spec.ProviderID))
+		g.Expect(conditions.IsTrue(updatedMachine, infrastructurev1.NodeProviderIDMatchesCondition)).To(BeTrue())
+	})
+
+	t.Run("links_node_with_provider_id_from_machine_spec", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		providerID := "kubeadm://existing-node"
+
+		client, workloadClient, _ := reconcileAdoptingMachine(t, true, providerID, adoptedNode(providerID))
+
+		updatedMachine := getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeTrue(), "Expected machine to be ready")
+		g.Expect(updatedMachine.Spec.ProviderID).To(Equal(providerID), "Expected provider ID to be kept")
+		g.Expect(getNode(t, workloadClient).Spec.ProviderID).To(Equal(providerID))
+	})
+
+	t.Run("waits_until_node_provider_id_matches", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		client, workloadClient, result := reconcileAdoptingMachine(t, true, "", adoptedNode("kubeadm://existing-node"))
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected Node to be checked again")
+
+		updatedMachine := getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeFalse(), "Expected machine not to be ready")
+		g.Expect(conditions.GetReason(updatedMachine, infrastructurev1.NodeProviderIDMatchesCondition)).
+			To(Equal(infrastructurev1.ProviderIDMismatchReason))
+		g.Expect(getNode(t, workloadClient).Spec.ProviderID).To(Equal("kubeadm://existing-node"),
+			"Expected provider ID of Node not to be changed")
+	})
+
+	t.Run("waits_until_node_is_registered", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		client, _, result := reconcileAdoptingMachine(t, true, "")
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected Node to be checked again")
+
+		updatedMachine := getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeFalse(), "Expected machine not to be ready")
+		g.Expect(conditions.GetReason(updatedMachine, infrastructurev1.NodeProviderIDMatchesCondition)).
+			To(Equal(infrastructurev1.NodeNotFoundReason))
+	})
+}
+
+//nolint:funlen
+func Test_Machine_reconciliation_when_hardware_is_in_maintenance(t *testing.T) {
+	t.Parallel()
+
+	hardwareUUID := uuid.New().String()
+
+	reconcileMachineOnHardwareInMaintenance := func(t *testing.T, gracePeriod time.Duration) (client.Client, ctrl.Result) {
+		t.Helper()
+		g := NewWithT(t)
+
+		machine := validMachine(machineName, clusterNamespace, clusterName)
+		machine.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "MachineSet",
+				Name:       "machineset",
+				Controller: pointer.BoolPtr(true),
+			},
+		}
+
+		objects := []runtime.Object{
+			validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			validHardware(hardwareName, hardwareUUID, hardwareIP),
+			machine,
+			validSecret(machineName, clusterNamespace),
+		}
+
+		client := kubernetesClientWithObjects(t, objects)
+
+		machineController := &controllers.TinkerbellMachineReconciler{
+			Client:                         client,
+			EvictFromHardwareInMaintenance: true,
+			HardwareMaintenanceGracePeriod: gracePeriod,
+		}
+
+		request := ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace},
+		}
+		ctx := context.Background()
+
+		_, err := machineController.Reconcile(ctx, request)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+
+		hardware.Annotations = map[string]string{controllers.HardwareMaintenanceAnnotation: "firmware upgrade"}
+		g.Expect(client.Update(ctx, hardware)).To(Succeed())
+
+		result, err := machineController.Reconcile(ctx, request)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return client, result
+	}
+
+	t.Run("marks_hardware_not_in_service_and_waits_for_grace_period", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		client, result := reconcileMachineOnHardwareInMaintenance(t, time.Hour)
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected requeue after the grace period")
+
+		ctx := context.Background()
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(conditions.IsFalse(updatedMachine, infrastructurev1.HardwareInServiceCondition)).To(BeTrue())
+		g.Expect(conditions.GetReason(updatedMachine, infrastructurev1.HardwareInServiceCondition)).
+			To(Equal(infrastructurev1.HardwareMaintenanceReason))
+
+		machine := &clusterv1.Machine{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: machineName, Namespace: clusterNamespace}, machine)).
+			To(Succeed(), "Expected Machine not to be deleted before the grace period elapses")
+	})
+
+	t.Run("deletes_machine_when_grace_period_elapses", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		client, _ := reconcileMachineOnHardwareInMaintenance(t, 0)
+
+		machine := &clusterv1.Machine{}
+		err := client.Get(context.Background(), types.NamespacedName{Name: machineName, Namespace: clusterNamespace}, machine)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected Machine to be deleted")
+	})
+
+	t.Run("does_not_provision_on_hardware_in_maintenance_without_eviction", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		hardware := validHardware(hardwareName, hardwareUUID, hardwareIP)
+		hardware.Labels = map[string]string{
+			controllers.HardwareOwnerNameLabel:      tinkerbellMachineName,
+			controllers.HardwareOwnerNamespaceLabel: clusterNamespace,
+		}
+		hardware.Annotations = map[string]string{controllers.HardwareMaintenanceAnnotation: "disk replacement"}
+
+		objects := []runtime.Object{
+			validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			hardware,
+			validMachine(machineName, clusterNamespace, clusterName),
+			validSecret(machineName, clusterNamespace),
+		}
+
+		client := kubernetesClientWithObjects(t, objects)
+		ctx := context.Background()
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+
+		result, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(ctrl.Result{}), "Expected machine to be enqueued by the Hardware watch")
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(updatedMachine.Status.Ready).To(BeFalse())
+		g.Expect(conditions.IsFalse(updatedMachine, infrastructurev1.HardwareInServiceCondition)).To(BeTrue())
+
+		template := &tinkv1.Template{}
+		err = client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, template)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected no Template to be created")
+
+		workflow := &tinkv1.Workflow{}
+		err = client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, workflow)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected no Workflow to be created")
+
+		// Provisioning continues once the maintenance is over.
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+		delete(hardware.Annotations, controllers.HardwareMaintenanceAnnotation)
+		g.Expect(client.Update(ctx, hardware)).To(Succeed())
+
+		_, err = reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName}, workflow)).To(Succeed())
+		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(conditions.IsTrue(updatedMachine, infrastructurev1.HardwareInServiceCondition)).To(BeTrue())
+	})
+
+	t.Run("does_not_select_hardware_in_maintenance", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		hardwareInMaintenance := validHardware("aa-maintenance", uuid.New().String(), "10.10.10.10")
+		hardwareInMaintenance.Annotations = map[string]string{controllers.HardwareMaintenanceAnnotation: ""}
+
+		objects := []runtime.Object{
+			validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			hardwareInMaintenance,
+			validHardware(hardwareName, hardwareUUID, hardwareIP),
+			validMachine(machineName, clusterNamespace, clusterName),
+			validSecret(machineName, clusterNamespace),
+		}
+
+		client := kubernetesClientWithObjects(t, objects)
+
+		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+		g.Expect(client.Get(context.Background(), namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(updatedMachine.Spec.HardwareName).To(Equal(hardwareName))
+	})
+}
+
+func machineReconciliationFailsWhenReconcilerIsNil(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	var machineController *controllers.TinkerbellMachineReconciler
+
+	request := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: clusterNamespace,
+			Name:      tinkerbellMachineName,
+		},
+	}
+
+	_, err := machineController.Reconcile(context.TODO(), request)
+	g.Expect(err).To(MatchError(controllers.ErrConfigurationNil))
+}
+
+func machineReconciliationFailsWhenReconcilerHasNoClientSet(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	machineController := &controllers.TinkerbellMachineReconciler{}
+
+	request := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: clusterNamespace,
+			Name:      tinkerbellMachineName,
+		},
+	}
+
+	_, err := machineController.Reconcile(context.TODO(), request)
+	g.Expect(err).To(MatchError(controllers.ErrMissingClient))
+}
+
+//nolint:unparam
+//nolint:funlen
+func Test_Machine_reconciliation_with_reboot_boot_mode(t *testing.T) {
+	t.Parallel()
+
+	hardwareUUID := uuid.New().String()
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+	bootName := types.NamespacedName{Name: tinkerbellMachineName + "-boot"}
+
+	reconcile := func(t *testing.T, client client.Client, reachable bool) ctrl.Result {
+		t.Helper()
+		g := NewWithT(t)
+
+		machineController := &controllers.TinkerbellMachineReconciler{
+			Client:     client,
+			HostProber: &fakeHostProber{reachable: reachable},
+		}
+
+		result, err := machineController.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return result
+	}
+
+	getMachine := func(t *testing.T, client client.Client) *infrastructurev1.TinkerbellMachine {
+		t.Helper()
+		g := NewWithT(t)
+
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		g.Expect(client.Get(context.Background(), namespacedName, updatedMachine)).To(Succeed())
+
+		return updatedMachine
+	}
+
+	// provisionedClient returns a client with the machine, which boot workflow started rebooting the machine.
+	provisionedClient := func(t *testing.T) client.Client {
+		t.Helper()
+		g := NewWithT(t)
+
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID)
+		tinkerbellMachine.Spec.BootMode = infrastructurev1.BootModeReboot
+		tinkerbellMachine.Spec.BootTimeout = &metav1.Duration{Duration: 30 * time.Minute}
+
+		objects := []runtime.Object{
+			tinkerbellMachine,
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			validHardware(hardwareName, hardwareUUID, hardwareIP),
+			validMachine(machineName, clusterNamespace, clusterName),
+			validSecret(machineName, clusterNamespace),
+		}
+
+		client := kubernetesClientWithObjects(t, objects)
+
+		result := reconcile(t, client, false)
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected workflow to be checked again")
 
-	// Status should be updated on every run.
-	//
-	// Don't execute this test in parallel, as we reset status here.
-	t.Run("refreshes_status_when_machine_is_already_provisioned", func(t *testing.T) { //nolint:paralleltest
-		updatedMachine.Status.Addresses = nil
-		g := NewWithT(t)
+		updatedMachine := &infrastructurev1.TinkerbellMachine{}
+		g.Expect(client.Get(context.Background(), namespacedName, updatedMachine)).To(Succeed())
+		g.Expect(updatedMachine.Status.Ready).To(BeFalse(), "Expected machine not to be ready while provisioning")
 
-		g.Expect(client.Update(context.Background(), updatedMachine)).To(Succeed())
-		_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
-		g.Expect(err).NotTo(HaveOccurred())
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(context.Background(), types.NamespacedName{Name: tinkerbellMachineName}, template)).To(Succeed())
+		g.Expect(*template.Spec.Data).NotTo(ContainSubstring("reboot-image"),
+			"Expected machine to be rebooted by the boot workflow")
+		g.Expect(*template.Spec.Data).NotTo(ContainSubstring("kexec"))
 
-		updatedMachine = &infrastructurev1.TinkerbellMachine{}
-		g.Expect(client.Get(ctx, namespacedName, updatedMachine)).To(Succeed())
-		g.Expect(updatedMachine.Status.Addresses).NotTo(BeEmpty(), "Machine status should be updated on every reconciliation")
-	})
-}
+		setWorkflowState(t, client, tinkerbellMachineName, tinkv1.WorkflowStateSuccess)
 
-//nolint:funlen
-func Test_Machine_reconciliation(t *testing.T) {
-	t.Parallel()
+		reconcile(t, client, false)
 
-	t.Run("is_not_requeued_when", func(t *testing.T) {
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(context.Background(), types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+		g.Expect(hardware.Spec.AllowPXE).To(Equal(pointer.BoolPtr(false)), "Expected netboot to be disabled")
+		g.Expect(hardware.Spec.AllowWorkflow).To(Equal(pointer.BoolPtr(true)),
+			"Expected boot workflow to be allowed to run")
+		g.Expect(client.Get(context.Background(), bootName, &tinkv1.Workflow{})).NotTo(Succeed(),
+			"Expected boot workflow not to be created before disabled netboot is propagated")
+
+		propagateNetboot(t, client, hardwareName)
+
+		reconcile(t, client, false)
+
+		bootWorkflow := &tinkv1.Workflow{}
+		g.Expect(client.Get(context.Background(), bootName, bootWorkflow)).To(Succeed())
+		g.Expect(bootWorkflow.Spec.HardwareRef).To(Equal(hardwareName))
+
+		bootTemplate := &tinkv1.Template{}
+		g.Expect(client.Get(context.Background(), bootName, bootTemplate)).To(Succeed())
+		g.Expect(*bootTemplate.Spec.Data).To(ContainSubstring(`name: "reboot-image"`))
+		g.Expect(*bootTemplate.Spec.Data).NotTo(ContainSubstring("sleep"), "Expected reboot not to be delayed")
+
+		g.Expect(getMachine(t, client).Status.Ready).To(BeFalse(), "Expected machine not to be ready before reboot")
+
+		startBootAction(t, client, bootName.Name, "reboot-image")
+
+		return client
+	}
+
+	t.Run("disables_netboot_and_waits_until_host_boots", func(t *testing.T) {
 		t.Parallel()
+		g := NewWithT(t)
 
-		// Requeue will be handled when resource is created.
-		t.Run("is_requeued_when_machine_object_is_missing",
-			machineReconciliationIsRequeuedWhenTinkerbellMachineObjectIsMissing)
+		client := provisionedClient(t)
 
-		// From https://cluster-api.sigs.k8s.io/developer/providers/cluster-infrastructure.html#behavior
-		// Requeue will be handled when ownerRef is set
-		t.Run("machine_has_no_owner_set", machineReconciliationIsRequeuedWhenTinkerbellMachineHasNoOwnerSet)
+		result := reconcile(t, client, false)
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected host to be probed again")
 
-		// From https://cluster-api.sigs.k8s.io/developer/providers/cluster-infrastructure.html#behavior
-		// Requeue will be handled when bootstrap secret is set through the Watch on Machines
-		t.Run("bootstrap_secret_is_not_ready", machineReconciliationIsRequeuedWhenBootstrapSecretIsNotReady)
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(context.Background(), types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+		g.Expect(hardware.Spec.AllowPXE).To(Equal(pointer.BoolPtr(false)), "Expected netboot to be disabled")
+		g.Expect(hardware.Spec.AllowWorkflow).To(Equal(pointer.BoolPtr(false)), "Expected workflows to be disabled")
 
-		// From https://cluster-api.sigs.k8s.io/developer/providers/cluster-infrastructure.html#behavior
-		// Requeue will be handled when bootstrap secret is set through the Watch on Clusters
-		t.Run("cluster_infrastructure_is_not_ready", machineReconciliationIsRequeuedWhenClusterInfrastructureIsNotReady)
+		updatedMachine := getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeFalse(), "Expected machine not to be ready while booting")
+		g.Expect(conditions.GetReason(updatedMachine, infrastructurev1.HostReachableCondition)).To(
+			Equal(infrastructurev1.WaitingForBootReason))
+
+		reconcile(t, client, true)
+
+		updatedMachine = getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeTrue(), "Expected machine to be ready once host booted")
+		g.Expect(conditions.IsTrue(updatedMachine, infrastructurev1.HostReachableCondition)).To(BeTrue())
 	})
 
-	t.Run("fails_when", func(t *testing.T) {
+	t.Run("reports_host_not_booted_in_time_without_failing_machine", func(t *testing.T) {
 		t.Parallel()
+		g := NewWithT(t)
 
-		t.Run("reconciler_is_nil", machineReconciliationFailsWhenReconcilerIsNil)
-		t.Run("reconciler_has_no_client_set", machineReconciliationFailsWhenReconcilerHasNoClientSet)
+		client := provisionedClient(t)
 
-		// CAPI spec says this is optional, but @detiber says it's effectively required, so treat it as so.
-		t.Run("machine_has_no_version_set", machineReconciliationFailsWhenMachineHasNoVersionSet)
+		reconcile(t, client, false)
 
-		t.Run("associated_cluster_object_does_not_exist",
-			machineReconciliationFailsWhenAssociatedClusterObjectDoesNotExist)
+		updatedMachine := getMachine(t, client)
+		for i := range updatedMachine.Status.Conditions {
+			if updatedMachine.Status.Conditions[i].Type == infrastructurev1.HostReachableCondition {
+				updatedMachine.Status.Conditions[i].LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+			}
+		}
+		g.Expect(client.Update(context.Background(), updatedMachine)).To(Succeed())
 
-		t.Run("associated_tinkerbell_cluster_object_does_not_exist",
-			machineReconciliationFailsWhenAssociatedTinkerbellClusterObjectDoesNotExist)
+		result := reconcile(t, client, false)
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected host to be probed again")
 
-		// If for example CAPI changes key used to store bootstrap date, we shouldn't try to create machines
-		// with empty bootstrap config, we should fail early instead.
-		t.Run("bootstrap_config_is_empty", machineReconciliationFailsWhenBootstrapConfigIsEmpty)
-		t.Run("bootstrap_config_has_no_value_key", machineReconciliationFailsWhenBootstrapConfigHasNoValueKey)
+		updatedMachine = getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeFalse())
+		g.Expect(updatedMachine.Status.ErrorReason).To(BeNil(), "Expected machine not to be marked as failed")
+		g.Expect(updatedMachine.Status.ErrorMessage).To(BeNil(), "Expected machine not to be marked as failed")
+		g.Expect(conditions.GetReason(updatedMachine, infrastructurev1.HostReachableCondition)).To(
+			Equal(infrastructurev1.BootTimedOutReason))
+		g.Expect(*conditions.GetSeverity(updatedMachine, infrastructurev1.HostReachableCondition)).To(
+			Equal(clusterv1.ConditionSeverityWarning))
 
-		t.Run("there_is_no_hardware_available", machineReconciliationFailsWhenThereIsNoHardwareAvailable)
+		reconcile(t, client, true)
 
-		t.Run("selected_hardware_has_no_ip_address_set", machineReconciliationFailsWhenSelectedHardwareHasNoIPAddressSet)
+		updatedMachine = getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeTrue(), "Expected machine to be ready once host booted late")
 	})
 
-	// Single hardware should only ever be used for a single machine.
-	t.Run("selects_unique_and_available_hardware_for_each_machine", //nolint:paralleltest
-		machineReconciliationSelectsUniqueAndAvailablehardwareForEachMachine)
+	t.Run("becomes_ready_once_workflow_hands_over_without_boot_timeout", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
 
-	// Patching Hardware and TinkerbellMachine are not atomic operations, so we should handle situation, when
-	// misspelling process is aborted in the middle.
-	//
-	// Without that, new Hardware will be selected each time.
-	t.Run("uses_already_selected_hardware_if_patching_tinkerbell_machine_failed", //nolint:paralleltest
-		machineReconciliationUsesAlreadySelectedHardwareIfPatchingTinkerbellMachineFailed)
+		client := provisionedClient(t)
 
-	t.Run("when_machine_is_scheduled_for_removal_it", func(t *testing.T) {
+		updatedMachine := getMachine(t, client)
+		updatedMachine.Spec.BootTimeout = nil
+		g.Expect(client.Update(context.Background(), updatedMachine)).To(Succeed())
+
+		result := reconcile(t, client, false)
+		g.Expect(result.RequeueAfter).To(BeZero(), "Expected host not to be probed")
+
+		updatedMachine = getMachine(t, client)
+		g.Expect(updatedMachine.Status.Ready).To(BeTrue(), "Expected machine to be ready once workflow handed over")
+		g.Expect(conditions.Get(updatedMachine, infrastructurev1.HostReachableCondition)).To(BeNil())
+	})
+
+	t.Run("removes_boot_workflow_when_reprovisioning", func(t *testing.T) {
 		t.Parallel()
+		g := NewWithT(t)
 
-		// From https://cluster-api.sigs.k8s.io/developer/providers/machine-infrastructure.html#behavior
-		t.Run("removes_tinkerbell_finalizer", notImplemented)
+		client := provisionedClient(t)
 
-		// Removing machine should release used hardware.
-		t.Run("marks_hardware_as_available_for_other_machines", notImplemented)
+		updatedMachine := getMachine(t, client)
+		updatedMachine.Annotations = map[string]string{infrastructurev1.ReprovisionAnnotation: ""}
+		g.Expect(client.Update(context.Background(), updatedMachine)).To(Succeed())
+
+		reconcile(t, client, false)
+
+		g.Expect(client.Get(context.Background(), bootName, &tinkv1.Workflow{})).NotTo(Succeed(),
+			"Expected boot workflow to be removed, so the reprovisioned machine is rebooted again")
+		g.Expect(client.Get(context.Background(), bootName, &tinkv1.Template{})).NotTo(Succeed())
 	})
 }
 
-func Test_Machine_reconciliation_when_machine_is_scheduled_for_removal_it(t *testing.T) {
-	t.Parallel()
-	g := NewWithT(t)
+const (
+	bmcWorkerHardwareName = "bmc-worker"
+	bmcAddress            = "10.0.0.100"
+)
 
-	objects := []runtime.Object{
-		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, ""),
+// bmcObjects returns the objects of a provisioned machine, which cluster configures power cycling
+// machines through their BMCs.
+func bmcObjects(hardwareUUID string) []runtime.Object {
+	tinkerbellCluster := validTinkerbellCluster(clusterName, clusterNamespace)
+	tinkerbellCluster.Spec.BMC = &infrastructurev1.BMC{
+		WorkerHardwareName: bmcWorkerHardwareName,
+		Image:              "registry.local/ipmitool:v1",
+		CredentialsRef:     corev1.LocalObjectReference{Name: "bmc-credentials"},
+	}
+
+	hardware := validHardware(hardwareName, hardwareUUID, hardwareIP)
+	hardware.Annotations = map[string]string{controllers.HardwareBMCAddressAnnotation: bmcAddress}
+
+	return []runtime.Object{
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
 		validCluster(clusterName, clusterNamespace),
-		validTinkerbellCluster(clusterName, clusterNamespace),
-		validHardware(hardwareName, uuid.New().String(), hardwareIP),
+		tinkerbellCluster,
+		hardware,
+		validHardware(bmcWorkerHardwareName, uuid.New().String(), "10.0.0.200"),
 		validMachine(machineName, clusterNamespace, clusterName),
 		validSecret(machineName, clusterNamespace),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "bmc-credentials", Namespace: clusterNamespace},
+			Type:       corev1.SecretTypeBasicAuth,
+			Data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte("admin"),
+				corev1.BasicAuthPasswordKey: []byte("secret"),
+			},
+		},
 	}
+}
+
+// provisionedClientWithAnnotation returns a client with the provisioned machine annotated with given
+// annotation.
+func provisionedClientWithAnnotation(t *testing.T, objects []runtime.Object, annotation string) client.Client {
+	t.Helper()
+	g := NewWithT(t)
 
 	client := kubernetesClientWithObjects(t, objects)
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
 
 	_, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
 	g.Expect(err).NotTo(HaveOccurred())
 
-	ctx := context.Background()
+	workflow := &tinkv1.Workflow{}
+	g.Expect(client.Get(context.Background(), types.NamespacedName{Name: tinkerbellMachineName}, workflow)).To(Succeed())
 
-	tinkerbellMachineNamespacedName := types.NamespacedName{
-		Name:      tinkerbellMachineName,
-		Namespace: clusterNamespace,
+	workflow.Status.State = tinkv1.WorkflowStateSuccess
+	g.Expect(client.Update(context.Background(), workflow)).To(Succeed())
+
+	_, err = reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	tinkerbellMachine := &infrastructurev1.TinkerbellMachine{}
+	g.Expect(client.Get(context.Background(), namespacedName, tinkerbellMachine)).To(Succeed())
+	g.Expect(tinkerbellMachine.Status.Ready).To(BeTrue())
+
+	tinkerbellMachine.Annotations = map[string]string{annotation: ""}
+	g.Expect(client.Update(context.Background(), tinkerbellMachine)).To(Succeed())
+
+	return client
+}
+
+// setWorkflowState sets the state of the Workflow with given name as the Tinkerbell controllers do.
+func setWorkflowState(t *testing.T, client client.Client, name, state string) {
+	t.Helper()
+	g := NewWithT(t)
+
+	workflow := &tinkv1.Workflow{}
+	g.Expect(client.Get(context.Background(), types.NamespacedName{Name: name}, workflow)).To(Succeed())
+
+	workflow.Status.State = state
+	g.Expect(client.Update(context.Background(), workflow)).To(Succeed())
+}
+
+// propagateNetboot sets the netboot settings of the Hardware with given name on its interfaces as the
+// Tinkerbell controllers do once they are pushed to Tinkerbell.
+func propagateNetboot(t *testing.T, client client.Client, name string) {
+	t.Helper()
+	g := NewWithT(t)
+
+	hardware := &tinkv1.Hardware{}
+	g.Expect(client.Get(context.Background(), types.NamespacedName{Name: name}, hardware)).To(Succeed())
+
+	for i := range hardware.Status.Interfaces {
+		hardware.Status.Interfaces[i].Netboot = &tinkv1.Netboot{
+			AllowPXE:      hardware.Spec.AllowPXE,
+			AllowWorkflow: hardware.Spec.AllowWorkflow,
+		}
 	}
+	g.Expect(client.Update(context.Background(), hardware)).To(Succeed())
+}
 
-	updatedMachine := &infrastructurev1.TinkerbellMachine{}
-	g.Expect(client.Get(ctx, tinkerbellMachineNamespacedName, updatedMachine)).To(Succeed())
+// startBootAction reports the action with given name of the Workflow with given name started. The worker
+// does not report the action restarting the machine as finished before the machine goes down.
+func startBootAction(t *testing.T, client client.Client, name, action string) {
+	t.Helper()
+	g := NewWithT(t)
 
-	now := metav1.Now()
-	updatedMachine.ObjectMeta.DeletionTimestamp = &now
+	workflow := &tinkv1.Workflow{}
+	g.Expect(client.Get(context.Background(), types.NamespacedName{Name: name}, workflow)).To(Succeed())
 
-	g.Expect(client.Update(ctx, updatedMachine)).To(Succeed())
-	_, err = reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
-	g.Expect(err).NotTo(HaveOccurred())
+	workflow.Status.State = tinkv1.WorkflowStateRunning
+	workflow.Status.Events = []tinkv1.Event{{ActionName: action, ActionStatus: tinkv1.WorkflowStateRunning}}
+	g.Expect(client.Update(context.Background(), workflow)).To(Succeed())
+}
 
-	hardwareNamespacedName := types.NamespacedName{
-		Name: hardwareName,
+//nolint:funlen
+func Test_Machine_reconciliation_with_reboot_requested(t *testing.T) {
+	t.Parallel()
+
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+	rebootName := types.NamespacedName{Name: tinkerbellMachineName + "-reboot"}
+
+	reconcile := func(t *testing.T, client client.Client) ctrl.Result {
+		t.Helper()
+		g := NewWithT(t)
+
+		result, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return result
 	}
 
-	updatedHardware := &tinkv1.Hardware{}
-	g.Expect(client.Get(ctx, hardwareNamespacedName, updatedHardware)).To(Succeed())
+	expectRebootFinished := func(t *testing.T, client client.Client) {
+		t.Helper()
+		g := NewWithT(t)
 
-	t.Run("removes_tinkerbell_machine_finalizer_from_hardware", func(t *testing.T) {
+		g.Expect(client.Get(context.Background(), rebootName, &tinkv1.Workflow{})).NotTo(Succeed(),
+			"Expected power workflow to be removed")
+		g.Expect(client.Get(context.Background(), rebootName, &tinkv1.Template{})).NotTo(Succeed(),
+			"Expected power template to be removed")
+
+		tinkerbellMachine := &infrastructurev1.TinkerbellMachine{}
+		g.Expect(client.Get(context.Background(), namespacedName, tinkerbellMachine)).To(Succeed())
+		g.Expect(tinkerbellMachine.Annotations).NotTo(HaveKey(infrastructurev1.RebootAnnotation),
+			"Expected reboot annotation to be removed")
+	}
+
+	t.Run("power_cycles_machine_through_bmc_worker", func(t *testing.T) {
 		t.Parallel()
 		g := NewWithT(t)
 
-		g.Expect(updatedHardware.ObjectMeta.GetFinalizers()).To(BeEmpty())
+		client := provisionedClientWithAnnotation(t, bmcObjects(uuid.New().String()), infrastructurev1.RebootAnnotation)
+
+		result := reconcile(t, client)
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected power workflow to be checked again")
+
+		workflow := &tinkv1.Workflow{}
+		g.Expect(client.Get(context.Background(), rebootName, workflow)).To(Succeed())
+		g.Expect(workflow.Spec.TemplateRef).To(Equal(rebootName.Name))
+		g.Expect(workflow.Spec.HardwareRef).To(Equal(bmcWorkerHardwareName), "Expected workflow to run on BMC worker")
+
+		template := &tinkv1.Template{}
+		g.Expect(client.Get(context.Background(), rebootName, template)).To(Succeed())
+		g.Expect(*template.Spec.Data).To(ContainSubstring(`BMC_ADDRESS: "` + bmcAddress + `"`))
+		g.Expect(*template.Spec.Data).To(ContainSubstring(`IPMI_PASSWORD: "secret"`))
+		g.Expect(*template.Spec.Data).To(ContainSubstring("ipmi chassis power on"))
+		g.Expect(*template.Spec.Data).NotTo(ContainSubstring("bootdev pxe"), "Expected machine to boot from the disk")
+
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(context.Background(), types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+		g.Expect(hardware.Spec.AllowPXE).To(Equal(pointer.BoolPtr(false)), "Expected netboot to stay disabled")
+
+		setWorkflowState(t, client, rebootName.Name, tinkv1.WorkflowStateRunning)
+
+		result = reconcile(t, client)
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected power workflow to be checked again")
+
+		setWorkflowState(t, client, rebootName.Name, tinkv1.WorkflowStateSuccess)
+
+		reconcile(t, client)
+
+		expectRebootFinished(t, client)
 	})
 
-	t.Run("makes_hardware_available_for_other_machines", func(t *testing.T) {
+	t.Run("finishes_reboot_when_power_workflow_fails", func(t *testing.T) {
 		t.Parallel()
-		g := NewWithT(t)
 
-		g.Expect(updatedHardware.ObjectMeta.Labels).NotTo(HaveKey(controllers.HardwareOwnerNameLabel),
-			"Found hardware owner name label")
-		g.Expect(updatedHardware.ObjectMeta.Labels).NotTo(HaveKey(controllers.HardwareOwnerNamespaceLabel),
-			"Found hardware owner namespace label")
+		client := provisionedClientWithAnnotation(t, bmcObjects(uuid.New().String()), infrastructurev1.RebootAnnotation)
+
+		reconcile(t, client)
+		setWorkflowState(t, client, rebootName.Name, tinkv1.WorkflowStateTimeout)
+		reconcile(t, client)
+
+		expectRebootFinished(t, client)
 	})
-}
 
-const (
-	machineName           = "myMachineName"
-	tinkerbellMachineName = "myTinkerbellMachineName"
-)
+	t.Run("finishes_reboot_without_bmc_configured", func(t *testing.T) {
+		t.Parallel()
 
-func machineReconciliationFailsWhenReconcilerIsNil(t *testing.T) {
-	t.Parallel()
-	g := NewWithT(t)
+		objects := []runtime.Object{
+			validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, uuid.New().String()),
+			validCluster(clusterName, clusterNamespace),
+			validTinkerbellCluster(clusterName, clusterNamespace),
+			validHardware(hardwareName, uuid.New().String(), hardwareIP),
+			validMachine(machineName, clusterNamespace, clusterName),
+			validSecret(machineName, clusterNamespace),
+		}
 
-	var machineController *controllers.TinkerbellMachineReconciler
+		client := provisionedClientWithAnnotation(t, objects, infrastructurev1.RebootAnnotation)
 
-	request := ctrl.Request{
-		NamespacedName: types.NamespacedName{
-			Namespace: clusterNamespace,
-			Name:      tinkerbellMachineName,
-		},
-	}
+		reconcile(t, client)
 
-	_, err := machineController.Reconcile(context.TODO(), request)
-	g.Expect(err).To(MatchError(controllers.ErrConfigurationNil))
+		expectRebootFinished(t, client)
+	})
 }
 
-func machineReconciliationFailsWhenReconcilerHasNoClientSet(t *testing.T) {
+//nolint:funlen
+func Test_Machine_reconciliation_with_reprovisioning_requested_power_cycles_machine_into_new_workflow(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
 
-	machineController := &controllers.TinkerbellMachineReconciler{}
+	ctx := context.Background()
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+	workflowName := types.NamespacedName{Name: tinkerbellMachineName}
+	powerName := types.NamespacedName{Name: tinkerbellMachineName + "-reprovision"}
 
-	request := ctrl.Request{
-		NamespacedName: types.NamespacedName{
-			Namespace: clusterNamespace,
-			Name:      tinkerbellMachineName,
-		},
+	client := provisionedClientWithAnnotation(t, bmcObjects(uuid.New().String()), infrastructurev1.ReprovisionAnnotation)
+
+	reconcile := func() ctrl.Result {
+		result, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return result
 	}
 
-	_, err := machineController.Reconcile(context.TODO(), request)
-	g.Expect(err).To(MatchError(controllers.ErrMissingClient))
+	getHardware := func() *tinkv1.Hardware {
+		hardware := &tinkv1.Hardware{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: hardwareName}, hardware)).To(Succeed())
+
+		return hardware
+	}
+
+	// First reconciliation removes the workflow, second one recreates it.
+	reconcile()
+	reconcile()
+
+	workflow := &tinkv1.Workflow{}
+	g.Expect(client.Get(ctx, workflowName, workflow)).To(Succeed())
+	g.Expect(workflow.Status.State).To(BeEmpty(), "Expected workflow to be recreated")
+	g.Expect(workflow.Annotations).To(HaveKey("infrastructure.cluster.x-k8s.io/power-cycle"),
+		"Expected workflow to wait for power cycle")
+	g.Expect(getHardware().Spec.AllowPXE).To(Equal(pointer.BoolPtr(true)), "Expected PXE to be allowed")
+
+	tinkerbellMachine := &infrastructurev1.TinkerbellMachine{}
+	g.Expect(client.Get(ctx, namespacedName, tinkerbellMachine)).To(Succeed())
+	g.Expect(tinkerbellMachine.Annotations).NotTo(HaveKey(infrastructurev1.ReprovisionAnnotation))
+
+	reconcile()
+
+	g.Expect(client.Get(ctx, powerName, &tinkv1.Workflow{})).NotTo(Succeed(),
+		"Expected machine not to be power cycled before the workflow and netboot are propagated")
+
+	// Tinkerbell controllers push the workflow and netboot settings to Tinkerbell.
+	propagateNetboot(t, client, hardwareName)
+
+	g.Expect(client.Get(ctx, workflowName, workflow)).To(Succeed())
+	workflow.SetTinkID(uuid.New().String())
+	workflow.Status.State = tinkv1.WorkflowStatePending
+	g.Expect(client.Update(ctx, workflow)).To(Succeed())
+
+	result := reconcile()
+	g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected power workflow to be checked again")
+
+	powerWorkflow := &tinkv1.Workflow{}
+	g.Expect(client.Get(ctx, powerName, powerWorkflow)).To(Succeed())
+	g.Expect(powerWorkflow.Spec.HardwareRef).To(Equal(bmcWorkerHardwareName))
+
+	powerTemplate := &tinkv1.Template{}
+	g.Expect(client.Get(ctx, powerName, powerTemplate)).To(Succeed())
+	g.Expect(*powerTemplate.Spec.Data).To(ContainSubstring("ipmi chassis bootdev pxe"),
+		"Expected machine to boot into the installation environment")
+
+	setWorkflowState(t, client, powerName.Name, tinkv1.WorkflowStateSuccess)
+
+	reconcile()
+
+	g.Expect(client.Get(ctx, powerName, &tinkv1.Workflow{})).NotTo(Succeed(), "Expected power workflow to be removed")
+	g.Expect(client.Get(ctx, workflowName, workflow)).To(Succeed())
+	g.Expect(workflow.Annotations).NotTo(HaveKey("infrastructure.cluster.x-k8s.io/power-cycle"),
+		"Expected workflow not to wait for power cycle anymore")
+
+	// Restarted machine runs the new workflow.
+	setWorkflowState(t, client, workflowName.Name, tinkv1.WorkflowStateRunning)
+	reconcile()
+
+	g.Expect(client.Get(ctx, powerName, &tinkv1.Workflow{})).NotTo(Succeed(), "Expected machine to be power cycled once")
+
+	setWorkflowState(t, client, workflowName.Name, tinkv1.WorkflowStateSuccess)
+	reconcile()
+
+	g.Expect(getHardware().Spec.AllowPXE).To(Equal(pointer.BoolPtr(false)), "Expected netboot to be disabled again")
 }
 
-//nolint:unparam
 func reconcileMachineWithClient(client client.Client, name, namespace string) (ctrl.Result, error) {
 	machineController := &controllers.TinkerbellMachineReconciler{
 		Client: client,
@@ -657,6 +3068,27 @@ func machineReconciliationFailsWhenBootstrapConfigHasNoValueKey(t *testing.T) {
 	g.Expect(err).To(MatchError(controllers.ErrMissingBootstrapDataSecretValueKey))
 }
 
+func machineReconciliationFailsWhenBootstrapConfigHasUnsupportedFormat(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	hardwareUUID := uuid.New().String()
+	secret := validSecret(machineName, clusterNamespace)
+	secret.Data["format"] = []byte("foo")
+
+	objects := []runtime.Object{
+		validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, hardwareUUID),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validHardware(hardwareName, hardwareUUID, hardwareIP),
+		validMachine(machineName, clusterNamespace, clusterName),
+		secret,
+	}
+
+	_, err := reconcileMachineWithClient(kubernetesClientWithObjects(t, objects), tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).To(MatchError(controllers.ErrUnsupportedBootstrapDataFormat))
+}
+
 func machineReconciliationFailsWhenAssociatedClusterObjectDoesNotExist(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
@@ -696,7 +3128,7 @@ func machineReconciliationFailsWhenAssociatedTinkerbellClusterObjectDoesNotExist
 	g.Expect(err).To(MatchError(ContainSubstring("getting TinkerbellCluster object")))
 }
 
-func machineReconciliationFailsWhenThereIsNoHardwareAvailable(t *testing.T) {
+func machineReconciliationWaitsWhenThereIsNoHardwareAvailable(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
 
@@ -710,8 +3142,18 @@ func machineReconciliationFailsWhenThereIsNoHardwareAvailable(t *testing.T) {
 		validSecret(machineName, clusterNamespace),
 	}
 
-	_, err := reconcileMachineWithClient(kubernetesClientWithObjects(t, objects), tinkerbellMachineName, clusterNamespace)
-	g.Expect(err).To(MatchError(controllers.ErrNoHardwareAvailable))
+	client := kubernetesClientWithObjects(t, objects)
+
+	result, err := reconcileMachineWithClient(client, tinkerbellMachineName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.IsZero()).To(BeTrue(), "Expected no requeue to be requested")
+
+	updatedMachine := &infrastructurev1.TinkerbellMachine{}
+	namespacedName := types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace}
+	g.Expect(client.Get(context.Background(), namespacedName, updatedMachine)).To(Succeed())
+	g.Expect(updatedMachine.Status.WaitingForHardwareSince).NotTo(BeNil())
+	g.Expect(conditions.GetReason(updatedMachine, infrastructurev1.HardwareSelectedCondition)).To(
+		Equal(infrastructurev1.WaitingForHardwareReason))
 }
 
 func machineReconciliationFailsWhenSelectedHardwareHasNoIPAddressSet(t *testing.T) {
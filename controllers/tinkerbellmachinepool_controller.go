@@ -0,0 +1,379 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	exputil "sigs.k8s.io/cluster-api/exp/util"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
+)
+
+// TinkerbellMachinePoolReconciler implements Reconciler interface by managing a TinkerbellMachine for
+// each replica of the MachinePool. The TinkerbellMachines claim Hardware and run the provisioning
+// workflows the same way as TinkerbellMachines of Machines.
+type TinkerbellMachinePoolReconciler struct {
+	client.Client
+	WatchFilterValue string
+
+	// Recorder is used for emitting events about TinkerbellMachinePool lifecycle. If nil, events
+	// are discarded.
+	Recorder record.EventRecorder
+}
+
+// poolReconcileContext holds the state of a single TinkerbellMachinePool reconciliation.
+type poolReconcileContext struct {
+	ctx         context.Context
+	log         logr.Logger
+	client      client.Client
+	recorder    record.EventRecorder
+	pool        *infrastructurev1.TinkerbellMachinePool
+	patchHelper *patch.Helper
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellmachinepools,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellmachinepools/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinepools;machinepools/status,verbs=get;list;watch
+
+// Reconcile scales TinkerbellMachines of the pool to the number of MachinePool replicas.
+func (tmpr *TinkerbellMachinePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) { //nolint:lll
+	ctx, span := tracing.StartReconcile(ctx, "tinkerbellmachinepool", req)
+	defer func() {
+		recordReconcileError("tinkerbellmachinepool", reterr)
+		tracing.End(span, reterr)
+	}()
+
+	if tmpr.Client == nil {
+		return ctrl.Result{}, fmt.Errorf("invalid configuration: %w", ErrMissingClient)
+	}
+
+	prc := &poolReconcileContext{
+		ctx:      ctx,
+		log:      ctrl.LoggerFrom(ctx).WithValues("TinkerbellMachinePool", req.NamespacedName),
+		client:   tmpr.Client,
+		recorder: eventRecorderOrDiscard(tmpr.Recorder),
+		pool:     &infrastructurev1.TinkerbellMachinePool{},
+	}
+
+	if err := prc.client.Get(ctx, req.NamespacedName, prc.pool); err != nil {
+		if apierrors.IsNotFound(err) {
+			prc.log.Info("TinkerbellMachinePool not found")
+
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, fmt.Errorf("getting TinkerbellMachinePool: %w", err)
+	}
+
+	patchHelper, err := patch.NewHelper(prc.pool, prc.client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("initializing patch helper: %w", err)
+	}
+
+	prc.patchHelper = patchHelper
+
+	if !prc.pool.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, prc.reconcileDelete()
+	}
+
+	machinePool, err := exputil.GetOwnerMachinePool(ctx, prc.client, prc.pool.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting owner MachinePool: %w", err)
+	}
+
+	if machinePool == nil {
+		prc.log.Info("MachinePool Controller has not yet set OwnerRef")
+
+		return ctrl.Result{}, nil
+	}
+
+	cluster, err := util.GetClusterFromMetadata(ctx, prc.client, machinePool.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting cluster from metadata: %w", err)
+	}
+
+	if annotations.IsPaused(cluster, prc.pool) {
+		prc.log.Info("TinkerbellMachinePool is marked as paused. Won't reconcile")
+
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, prc.reconcile(machinePool)
+}
+
+func (prc *poolReconcileContext) reconcile(machinePool *expv1.MachinePool) error {
+	// To make sure machines are removed and Hardware released together with the pool.
+	controllerutil.AddFinalizer(prc.pool, infrastructurev1.MachinePoolFinalizer)
+
+	if err := prc.patch(); err != nil {
+		return fmt.Errorf("patching TinkerbellMachinePool object with finalizer: %w", err)
+	}
+
+	members, err := prc.members()
+	if err != nil {
+		return fmt.Errorf("listing machines of the pool: %w", err)
+	}
+
+	replicas := 1
+	if machinePool.Spec.Replicas != nil {
+		replicas = int(*machinePool.Spec.Replicas)
+	}
+
+	active := activeMembers(members)
+
+	for i := len(active); i < replicas; i++ {
+		member, err := prc.createMember(machinePool, members)
+		if err != nil {
+			return fmt.Errorf("creating machine of the pool: %w", err)
+		}
+
+		members = append(members, *member)
+		active = append(active, member)
+	}
+
+	if len(active) > replicas {
+		// Machines which are not provisioned yet are removed first, then the newest ones.
+		sort.SliceStable(active, func(i, j int) bool {
+			if active[i].Status.Ready != active[j].Status.Ready {
+				return !active[i].Status.Ready
+			}
+
+			return active[j].CreationTimestamp.Before(&active[i].CreationTimestamp)
+		})
+
+		for _, member := range active[:len(active)-replicas] {
+			if err := prc.deleteMember(member); err != nil {
+				return fmt.Errorf("removing machine of the pool: %w", err)
+			}
+		}
+
+		active = active[len(active)-replicas:]
+	}
+
+	prc.setStatus(active, replicas)
+
+	if err := prc.patch(); err != nil {
+		return fmt.Errorf("patching TinkerbellMachinePool object with status: %w", err)
+	}
+
+	return nil
+}
+
+// reconcileDelete removes all machines of the pool, so their Hardware gets released, before removing
+// the finalizer.
+func (prc *poolReconcileContext) reconcileDelete() error {
+	members, err := prc.members()
+	if err != nil {
+		return fmt.Errorf("listing machines of the pool: %w", err)
+	}
+
+	for _, member := range activeMembers(members) {
+		if err := prc.deleteMember(member); err != nil {
+			return fmt.Errorf("removing machine of the pool: %w", err)
+		}
+	}
+
+	// The pool is reconciled again once its machines are removed.
+	if len(members) > 0 {
+		prc.log.Info("Waiting for machines of the pool to be removed", "machines", len(members))
+
+		return nil
+	}
+
+	controllerutil.RemoveFinalizer(prc.pool, infrastructurev1.MachinePoolFinalizer)
+
+	return prc.patch()
+}
+
+// members returns all TinkerbellMachines of the pool, including machines being removed.
+func (prc *poolReconcileContext) members() ([]infrastructurev1.TinkerbellMachine, error) {
+	machines := &infrastructurev1.TinkerbellMachineList{}
+
+	if err := prc.client.List(prc.ctx, machines, client.InNamespace(prc.pool.Namespace),
+		client.MatchingLabels{infrastructurev1.MachinePoolNameLabel: prc.pool.Name}); err != nil {
+		return nil, fmt.Errorf("listing TinkerbellMachines: %w", err)
+	}
+
+	members := []infrastructurev1.TinkerbellMachine{}
+
+	for i := range machines.Items {
+		if metav1.IsControlledBy(&machines.Items[i], prc.pool) {
+			members = append(members, machines.Items[i])
+		}
+	}
+
+	return members, nil
+}
+
+// activeMembers returns machines of the pool, which are not being removed.
+func activeMembers(members []infrastructurev1.TinkerbellMachine) []*infrastructurev1.TinkerbellMachine {
+	active := []*infrastructurev1.TinkerbellMachine{}
+
+	for i := range members {
+		if members[i].DeletionTimestamp.IsZero() {
+			active = append(active, &members[i])
+		}
+	}
+
+	return active
+}
+
+// memberName returns the name for a new machine of the pool, which is not used by any of given machines.
+func memberName(poolName string, members []infrastructurev1.TinkerbellMachine) string {
+	used := map[string]bool{}
+
+	for i := range members {
+		used[members[i].Name] = true
+	}
+
+	for i := 0; ; i++ {
+		if name := fmt.Sprintf("%s-%d", poolName, i); !used[name] {
+			return name
+		}
+	}
+}
+
+func (prc *poolReconcileContext) createMember(
+	machinePool *expv1.MachinePool,
+	members []infrastructurev1.TinkerbellMachine,
+) (*infrastructurev1.TinkerbellMachine, error) {
+	labels := map[string]string{
+		clusterv1.ClusterLabelName:            machinePool.Spec.ClusterName,
+		infrastructurev1.MachinePoolNameLabel: prc.pool.Name,
+	}
+
+	// Machines must be visible to the controllers watching the pool.
+	if watchValue, ok := prc.pool.Labels[clusterv1.WatchLabel]; ok {
+		labels[clusterv1.WatchLabel] = watchValue
+	}
+
+	member := &infrastructurev1.TinkerbellMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      memberName(prc.pool.Name, members),
+			Namespace: prc.pool.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(prc.pool, infrastructurev1.GroupVersion.WithKind(tinkerbellMachinePoolKind)),
+			},
+		},
+		Spec: *prc.pool.Spec.Template.DeepCopy(),
+	}
+
+	if err := prc.client.Create(prc.ctx, member); err != nil {
+		return nil, fmt.Errorf("creating TinkerbellMachine: %w", err)
+	}
+
+	prc.log.Info("Created machine of the pool", "TinkerbellMachine", member.Name)
+	prc.recorder.Eventf(prc.pool, corev1.EventTypeNormal, "MachineCreated", "Created TinkerbellMachine %s", member.Name)
+
+	return member, nil
+}
+
+func (prc *poolReconcileContext) deleteMember(member *infrastructurev1.TinkerbellMachine) error {
+	if err := prc.client.Delete(prc.ctx, member); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting TinkerbellMachine: %w", err)
+	}
+
+	prc.log.Info("Removing machine of the pool", "TinkerbellMachine", member.Name)
+	prc.recorder.Eventf(prc.pool, corev1.EventTypeNormal, "MachineDeleted", "Deleted TinkerbellMachine %s", member.Name)
+
+	return nil
+}
+
+// setStatus reports provider IDs of the provisioned machines of the pool and the number of replicas.
+func (prc *poolReconcileContext) setStatus(active []*infrastructurev1.TinkerbellMachine, replicas int) {
+	providerIDs := []string{}
+
+	for _, member := range active {
+		if member.Status.Ready && member.Spec.ProviderID != "" {
+			providerIDs = append(providerIDs, member.Spec.ProviderID)
+		}
+	}
+
+	sort.Strings(providerIDs)
+
+	prc.pool.Spec.ProviderIDList = providerIDs
+	prc.pool.Status.Replicas = int32(len(active))
+	prc.pool.Status.ReadyReplicas = int32(len(providerIDs))
+	prc.pool.Status.Ready = len(providerIDs) == replicas && len(active) == replicas
+
+	if prc.pool.Status.Ready {
+		conditions.MarkTrue(prc.pool, infrastructurev1.ReplicasReadyCondition)
+
+		return
+	}
+
+	conditions.MarkFalse(prc.pool, infrastructurev1.ReplicasReadyCondition, infrastructurev1.WaitingForReplicasReason,
+		clusterv1.ConditionSeverityInfo, "%d of %d replicas ready", len(providerIDs), replicas)
+}
+
+// patch commits all done changes to TinkerbellMachinePool object.
+func (prc *poolReconcileContext) patch() error {
+	if err := prc.patchHelper.Patch(prc.ctx, prc.pool); err != nil {
+		return fmt.Errorf("patching machine pool object: %w", err)
+	}
+
+	return nil
+}
+
+// SetupWithManager configures reconciler with a given manager.
+func (tmpr *TinkerbellMachinePoolReconciler) SetupWithManager(
+	ctx context.Context,
+	mgr ctrl.Manager,
+	options controller.Options,
+) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	builder := ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(log, tmpr.WatchFilterValue)).
+		For(&infrastructurev1.TinkerbellMachinePool{}).
+		Owns(&infrastructurev1.TinkerbellMachine{}).
+		Watches(
+			&source.Kind{Type: &expv1.MachinePool{}},
+			handler.EnqueueRequestsFromMapFunc(exputil.MachinePoolToInfrastructureMapFunc(
+				infrastructurev1.GroupVersion.WithKind(tinkerbellMachinePoolKind), log)),
+		)
+
+	if err := builder.Complete(tmpr); err != nil {
+		return fmt.Errorf("failed to create controller: %w", err)
+	}
+
+	return nil
+}
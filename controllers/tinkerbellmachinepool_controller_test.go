@@ -0,0 +1,271 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/controllers"
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+const (
+	machinePoolName           = "myMachinePoolName"
+	tinkerbellMachinePoolName = "myTinkerbellMachinePoolName"
+)
+
+func validMachinePool(name, namespace, clusterName string, replicas int32) *expv1.MachinePool {
+	return &expv1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: clusterName,
+			},
+		},
+		Spec: expv1.MachinePoolSpec{
+			ClusterName: clusterName,
+			Replicas:    pointer.Int32Ptr(replicas),
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{
+					ClusterName: clusterName,
+					Version:     pointer.StringPtr("1.19.4"),
+					Bootstrap: clusterv1.Bootstrap{
+						DataSecretName: pointer.StringPtr(name),
+					},
+					InfrastructureRef: corev1.ObjectReference{
+						APIVersion: infrastructurev1.GroupVersion.String(),
+						Kind:       "TinkerbellMachinePool",
+						Name:       tinkerbellMachinePoolName,
+					},
+				},
+			},
+		},
+	}
+}
+
+func validTinkerbellMachinePool(name, namespace, machinePoolName string) *infrastructurev1.TinkerbellMachinePool {
+	return &infrastructurev1.TinkerbellMachinePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(uuid.New().String()),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: expv1.GroupVersion.String(),
+					Kind:       "MachinePool",
+					Name:       machinePoolName,
+				},
+			},
+		},
+	}
+}
+
+func reconcileMachinePoolWithClient(client client.Client, name, namespace string) (ctrl.Result, error) {
+	machinePoolController := &controllers.TinkerbellMachinePoolReconciler{
+		Client: client,
+	}
+
+	request := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	return machinePoolController.Reconcile(context.TODO(), request) //nolint:wrapcheck
+}
+
+func poolMembers(t *testing.T, c client.Client) []infrastructurev1.TinkerbellMachine {
+	t.Helper()
+	g := NewWithT(t)
+
+	machines := &infrastructurev1.TinkerbellMachineList{}
+	g.Expect(c.List(context.Background(), machines, client.InNamespace(clusterNamespace),
+		client.MatchingLabels{infrastructurev1.MachinePoolNameLabel: tinkerbellMachinePoolName})).To(Succeed())
+
+	return machines.Items
+}
+
+//nolint:funlen
+func Test_Machine_pool_reconciliation(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	tinkerbellMachinePool := validTinkerbellMachinePool(tinkerbellMachinePoolName, clusterNamespace, machinePoolName)
+	tinkerbellMachinePool.Spec.HardwareSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"pool": "workers"},
+	}
+
+	poolHardware := []*tinkv1.Hardware{
+		validHardware("pool-1", uuid.New().String(), "10.10.10.11"),
+		validHardware("pool-2", uuid.New().String(), "10.10.10.12"),
+	}
+
+	objects := []runtime.Object{
+		tinkerbellMachinePool,
+		validMachinePool(machinePoolName, clusterNamespace, clusterName, 2),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validSecret(machinePoolName, clusterNamespace),
+		// Sorted before the pool Hardware, but not matching the hardware selector.
+		validHardware("other", uuid.New().String(), "10.10.10.10"),
+	}
+
+	for _, hardware := range poolHardware {
+		hardware.Labels = map[string]string{"pool": "workers"}
+		objects = append(objects, hardware)
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+	ctx := context.Background()
+	poolKey := types.NamespacedName{Name: tinkerbellMachinePoolName, Namespace: clusterNamespace}
+
+	_, err := reconcileMachinePoolWithClient(client, tinkerbellMachinePoolName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	members := poolMembers(t, client)
+	g.Expect(members).To(HaveLen(2), "Expected machine to be created for each replica")
+
+	for i := range members {
+		g.Expect(metav1.IsControlledBy(&members[i], tinkerbellMachinePool)).To(BeTrue(),
+			"Expected machine to be controlled by the pool")
+		g.Expect(members[i].Labels).To(HaveKeyWithValue(clusterv1.ClusterLabelName, clusterName))
+
+		_, err := reconcileMachineWithClient(client, members[i].Name, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	_, err = reconcileMachinePoolWithClient(client, tinkerbellMachinePoolName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	t.Run("claims_hardware_matching_selector_for_each_replica", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		hardwareNames := []string{}
+
+		for _, member := range poolMembers(t, client) {
+			g.Expect(member.Status.Ready).To(BeTrue(), "Expected machine to be ready")
+
+			hardwareNames = append(hardwareNames, member.Spec.HardwareName)
+
+			workflow := &tinkv1.Workflow{}
+			g.Expect(client.Get(ctx, types.NamespacedName{Name: member.Name}, workflow)).To(Succeed(),
+				"Expected workflow to be created for the machine")
+		}
+
+		g.Expect(hardwareNames).To(ConsistOf("pool-1", "pool-2"))
+	})
+
+	t.Run("reports_provider_ids_and_ready_replicas", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		pool := &infrastructurev1.TinkerbellMachinePool{}
+		g.Expect(client.Get(ctx, poolKey, pool)).To(Succeed())
+
+		g.Expect(pool.Spec.ProviderIDList).To(ConsistOf(
+			"tinkerbell://"+poolHardware[0].Spec.ID,
+			"tinkerbell://"+poolHardware[1].Spec.ID,
+		))
+		g.Expect(pool.Status.Replicas).To(BeEquivalentTo(2))
+		g.Expect(pool.Status.ReadyReplicas).To(BeEquivalentTo(2))
+		g.Expect(pool.Status.Ready).To(BeTrue(), "Expected pool to be ready")
+	})
+}
+
+func Test_Machine_pool_reconciliation_when_scaling_down(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	objects := []runtime.Object{
+		validTinkerbellMachinePool(tinkerbellMachinePoolName, clusterNamespace, machinePoolName),
+		validMachinePool(machinePoolName, clusterNamespace, clusterName, 2),
+		validCluster(clusterName, clusterNamespace),
+		validTinkerbellCluster(clusterName, clusterNamespace),
+		validSecret(machinePoolName, clusterNamespace),
+		validHardware("pool-1", uuid.New().String(), "10.10.10.11"),
+		validHardware("pool-2", uuid.New().String(), "10.10.10.12"),
+	}
+
+	client := kubernetesClientWithObjects(t, objects)
+	ctx := context.Background()
+
+	_, err := reconcileMachinePoolWithClient(client, tinkerbellMachinePoolName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	members := poolMembers(t, client)
+	g.Expect(members).To(HaveLen(2))
+
+	for i := range members {
+		_, err := reconcileMachineWithClient(client, members[i].Name, clusterNamespace)
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	machinePool := &expv1.MachinePool{}
+	g.Expect(client.Get(ctx, types.NamespacedName{Name: machinePoolName, Namespace: clusterNamespace},
+		machinePool)).To(Succeed())
+
+	machinePool.Spec.Replicas = pointer.Int32Ptr(1)
+	g.Expect(client.Update(ctx, machinePool)).To(Succeed())
+
+	_, err = reconcileMachinePoolWithClient(client, tinkerbellMachinePoolName, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var removed *infrastructurev1.TinkerbellMachine
+
+	for _, member := range poolMembers(t, client) {
+		member := member
+
+		if !member.DeletionTimestamp.IsZero() {
+			g.Expect(removed).To(BeNil(), "Expected only one machine to be removed")
+
+			removed = &member
+		}
+	}
+
+	g.Expect(removed).NotTo(BeNil(), "Expected one machine to be removed")
+
+	// Removed machine releases its Hardware.
+	_, err = reconcileMachineWithClient(client, removed.Name, clusterNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	hardware := &tinkv1.Hardware{}
+	g.Expect(client.Get(ctx, types.NamespacedName{Name: removed.Spec.HardwareName}, hardware)).To(Succeed())
+	g.Expect(hardware.Labels).NotTo(HaveKey(controllers.HardwareOwnerNameLabel), "Expected Hardware to be released")
+
+	pool := &infrastructurev1.TinkerbellMachinePool{}
+	g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachinePoolName, Namespace: clusterNamespace},
+		pool)).To(Succeed())
+	g.Expect(pool.Status.Replicas).To(BeEquivalentTo(1))
+	g.Expect(pool.Spec.ProviderIDList).To(HaveLen(1))
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
+)
+
+// TinkerbellRemediationReconciler implements Reconciler interface by remediating unhealthy Machines
+// for MachineHealthCheck. The machine is first rebooted, then reprovisioned on the same Hardware and
+// only if it is still unhealthy, the Machine is deleted, so its owner replaces it. MachineHealthCheck
+// removes the TinkerbellRemediation once the machine becomes healthy again.
+type TinkerbellRemediationReconciler struct {
+	client.Client
+	WatchFilterValue string
+
+	// Recorder is used for emitting events about TinkerbellRemediation lifecycle. If nil, events
+	// are discarded.
+	Recorder record.EventRecorder
+}
+
+// remediationReconcileContext holds the state of a single TinkerbellRemediation reconciliation.
+type remediationReconcileContext struct {
+	ctx         context.Context
+	log         logr.Logger
+	client      client.Client
+	recorder    record.EventRecorder
+	remediation *infrastructurev1.TinkerbellRemediation
+	machine     *clusterv1.Machine
+	patchHelper *patch.Helper
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellremediations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellremediations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=tinkerbellremediationtemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=delete
+
+// Reconcile takes the next remediation step for the Machine, once the current one times out.
+func (trr *TinkerbellRemediationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) { //nolint:lll
+	ctx, span := tracing.StartReconcile(ctx, "tinkerbellremediation", req)
+	defer func() {
+		recordReconcileError("tinkerbellremediation", reterr)
+		tracing.End(span, reterr)
+	}()
+
+	if trr.Client == nil {
+		return ctrl.Result{}, fmt.Errorf("invalid configuration: %w", ErrMissingClient)
+	}
+
+	rrc := &remediationReconcileContext{
+		ctx:         ctx,
+		log:         ctrl.LoggerFrom(ctx).WithValues("TinkerbellRemediation", req.NamespacedName),
+		client:      trr.Client,
+		recorder:    eventRecorderOrDiscard(trr.Recorder),
+		remediation: &infrastructurev1.TinkerbellRemediation{},
+	}
+
+	if err := rrc.client.Get(ctx, req.NamespacedName, rrc.remediation); err != nil {
+		if apierrors.IsNotFound(err) {
+			rrc.log.Info("TinkerbellRemediation not found")
+
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, fmt.Errorf("getting TinkerbellRemediation: %w", err)
+	}
+
+	if !rrc.remediation.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(rrc.remediation, rrc.client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("initializing patch helper: %w", err)
+	}
+
+	rrc.patchHelper = patchHelper
+
+	machine, err := util.GetOwnerMachine(ctx, rrc.client, rrc.remediation.ObjectMeta)
+	if err != nil {
+		// Remediation is garbage collected after the Machine it deleted.
+		if apierrors.IsNotFound(err) {
+			rrc.log.Info("Owner Machine is gone")
+
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, fmt.Errorf("getting owner Machine: %w", err)
+	}
+
+	if machine == nil {
+		rrc.log.Info("MachineHealthCheck has not yet set OwnerRef")
+
+		return ctrl.Result{}, nil
+	}
+
+	rrc.machine = machine
+
+	cluster, err := util.GetClusterFromMetadata(ctx, rrc.client, machine.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting cluster from metadata: %w", err)
+	}
+
+	if annotations.IsPaused(cluster, rrc.remediation) {
+		rrc.log.Info("TinkerbellRemediation is marked as paused. Won't reconcile")
+
+		return ctrl.Result{}, nil
+	}
+
+	return rrc.reconcile()
+}
+
+func (rrc *remediationReconcileContext) reconcile() (ctrl.Result, error) {
+	status := &rrc.remediation.Status
+
+	// The current remediation step is given time to make the machine healthy again.
+	if status.TimeoutTime != nil {
+		if remaining := time.Until(status.TimeoutTime.Time); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	var err error
+
+	switch status.Phase {
+	case "":
+		err = rrc.reboot()
+	case infrastructurev1.RemediationPhaseRebooting:
+		err = rrc.reprovision()
+	case infrastructurev1.RemediationPhaseReprovisioning:
+		err = rrc.deleteMachine()
+	case infrastructurev1.RemediationPhaseDeleting:
+		return ctrl.Result{}, nil
+	}
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := rrc.patch(); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if status.TimeoutTime == nil {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{RequeueAfter: time.Until(status.TimeoutTime.Time)}, nil
+}
+
+// reboot requests a reboot of the machine through a Tinkerbell workflow. Machines without Hardware
+// cannot be remediated in place, so they are deleted right away.
+func (rrc *remediationReconcileContext) reboot() error {
+	tinkerbellMachine, err := rrc.getTinkerbellMachine()
+	if err != nil {
+		return err
+	}
+
+	if tinkerbellMachine == nil || tinkerbellMachine.Spec.HardwareName == "" {
+		rrc.log.Info("Machine has no Hardware selected, skipping in-place remediation")
+
+		return rrc.deleteMachine()
+	}
+
+	if err := rrc.annotate(tinkerbellMachine, infrastructurev1.RebootAnnotation); err != nil {
+		return err
+	}
+
+	rrc.remediation.Status.RebootAttempts++
+	rrc.setPhase(infrastructurev1.RemediationPhaseRebooting,
+		durationOrDefault(rrc.remediation.Spec.RebootTimeout, infrastructurev1.DefaultRebootTimeout))
+
+	rrc.recorder.Eventf(rrc.remediation, corev1.EventTypeNormal, "Rebooting",
+		"Requested reboot of TinkerbellMachine %s", tinkerbellMachine.Name)
+
+	return nil
+}
+
+// reprovision requests reprovisioning of the machine on the same Hardware, as the reboot did not
+// make it healthy.
+func (rrc *remediationReconcileContext) reprovision() error {
+	tinkerbellMachine, err := rrc.getTinkerbellMachine()
+	if err != nil {
+		return err
+	}
+
+	if tinkerbellMachine == nil {
+		return rrc.deleteMachine()
+	}
+
+	if err := rrc.annotate(tinkerbellMachine, infrastructurev1.ReprovisionAnnotation); err != nil {
+		return err
+	}
+
+	rrc.remediation.Status.ReprovisionAttempts++
+	rrc.setPhase(infrastructurev1.RemediationPhaseReprovisioning,
+		durationOrDefault(rrc.remediation.Spec.ReprovisionTimeout, infrastructurev1.DefaultReprovisionTimeout))
+
+	rrc.recorder.Eventf(rrc.remediation, corev1.EventTypeWarning, "Reprovisioning",
+		"Machine still unhealthy after reboot, requested reprovisioning of TinkerbellMachine %s", tinkerbellMachine.Name)
+
+	return nil
+}
+
+// deleteMachine deletes the Machine, so its owner replaces it with a new one.
+func (rrc *remediationReconcileContext) deleteMachine() error {
+	if err := rrc.client.Delete(rrc.ctx, rrc.machine); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting Machine: %w", err)
+	}
+
+	rrc.setPhase(infrastructurev1.RemediationPhaseDeleting, 0)
+
+	rrc.recorder.Eventf(rrc.remediation, corev1.EventTypeWarning, "MachineDeleted",
+		"In-place remediation failed, deleted Machine %s", rrc.machine.Name)
+
+	return nil
+}
+
+// setPhase records the start of the remediation phase and when it times out. Phases without timeout
+// are final.
+func (rrc *remediationReconcileContext) setPhase(phase infrastructurev1.RemediationPhase, timeout time.Duration) {
+	now := metav1.Now()
+
+	rrc.remediation.Status.Phase = phase
+	rrc.remediation.Status.LastTransitionTime = &now
+	rrc.remediation.Status.TimeoutTime = nil
+
+	if timeout > 0 {
+		timeoutTime := metav1.NewTime(now.Add(timeout))
+		rrc.remediation.Status.TimeoutTime = &timeoutTime
+	}
+
+	rrc.log.Info("Remediation phase changed", "phase", phase, "timeout", timeout)
+}
+
+// getTinkerbellMachine returns the TinkerbellMachine of the remediated Machine. If it does not exist,
+// nil is returned.
+func (rrc *remediationReconcileContext) getTinkerbellMachine() (*infrastructurev1.TinkerbellMachine, error) {
+	ref := rrc.machine.Spec.InfrastructureRef
+	if ref.Kind != "TinkerbellMachine" || ref.Name == "" {
+		return nil, nil
+	}
+
+	tinkerbellMachine := &infrastructurev1.TinkerbellMachine{}
+	key := client.ObjectKey{Namespace: rrc.machine.Namespace, Name: ref.Name}
+
+	if err := rrc.client.Get(rrc.ctx, key, tinkerbellMachine); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("getting TinkerbellMachine: %w", err)
+	}
+
+	return tinkerbellMachine, nil
+}
+
+// annotate sets given annotation on the TinkerbellMachine, which is then acted upon by the
+// TinkerbellMachine controller.
+func (rrc *remediationReconcileContext) annotate(
+	tinkerbellMachine *infrastructurev1.TinkerbellMachine,
+	annotation string,
+) error {
+	patchHelper, err := patch.NewHelper(tinkerbellMachine, rrc.client)
+	if err != nil {
+		return fmt.Errorf("initializing patch helper for TinkerbellMachine: %w", err)
+	}
+
+	if tinkerbellMachine.Annotations == nil {
+		tinkerbellMachine.Annotations = map[string]string{}
+	}
+
+	tinkerbellMachine.Annotations[annotation] = ""
+
+	if err := patchHelper.Patch(rrc.ctx, tinkerbellMachine); err != nil {
+		return fmt.Errorf("patching TinkerbellMachine object: %w", err)
+	}
+
+	return nil
+}
+
+// patch commits all done changes to TinkerbellRemediation object.
+func (rrc *remediationReconcileContext) patch() error {
+	if err := rrc.patchHelper.Patch(rrc.ctx, rrc.remediation); err != nil {
+		return fmt.Errorf("patching remediation object: %w", err)
+	}
+
+	return nil
+}
+
+// durationOrDefault returns the duration or the default one, if it is not set.
+func durationOrDefault(duration *metav1.Duration, defaultDuration time.Duration) time.Duration {
+	if duration == nil {
+		return defaultDuration
+	}
+
+	return duration.Duration
+}
+
+// SetupWithManager configures reconciler with a given manager.
+func (trr *TinkerbellRemediationReconciler) SetupWithManager(
+	ctx context.Context,
+	mgr ctrl.Manager,
+	options controller.Options,
+) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	builder := ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(log, trr.WatchFilterValue)).
+		For(&infrastructurev1.TinkerbellRemediation{})
+
+	if err := builder.Complete(trr); err != nil {
+		return fmt.Errorf("failed to create controller: %w", err)
+	}
+
+	return nil
+}
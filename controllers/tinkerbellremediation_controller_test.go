@@ -0,0 +1,375 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/controllers"
+)
+
+func validRemediation(name, namespace string, status infrastructurev1.TinkerbellRemediationStatus) *infrastructurev1.TinkerbellRemediation { //nolint:lll
+	return &infrastructurev1.TinkerbellRemediation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: clusterv1.GroupVersion.String(),
+					Kind:       "Machine",
+					Name:       name,
+				},
+			},
+		},
+		Status: status,
+	}
+}
+
+func remediatedMachine() *clusterv1.Machine {
+	machine := validMachine(machineName, clusterNamespace, clusterName)
+	machine.Spec.InfrastructureRef = corev1.ObjectReference{
+		APIVersion: infrastructurev1.GroupVersion.String(),
+		Kind:       "TinkerbellMachine",
+		Name:       tinkerbellMachineName,
+	}
+
+	return machine
+}
+
+func reconcileRemediationWithClient(client client.Client) (ctrl.Result, error) {
+	remediationController := &controllers.TinkerbellRemediationReconciler{
+		Client: client,
+	}
+
+	request := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      machineName,
+			Namespace: clusterNamespace,
+		},
+	}
+
+	return remediationController.Reconcile(context.TODO(), request) //nolint:wrapcheck
+}
+
+func timeFromNow(d time.Duration) *metav1.Time {
+	t := metav1.NewTime(time.Now().Add(d))
+
+	return &t
+}
+
+//nolint:funlen
+func Test_Remediation_reconciliation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		spec                     infrastructurev1.TinkerbellRemediationSpec
+		status                   infrastructurev1.TinkerbellRemediationStatus
+		hardwareName             string
+		withoutTinkerbellMachine bool
+		expectedPhase            infrastructurev1.RemediationPhase
+		expectedAnnotation       string
+		expectedTimeout          time.Duration
+		expectMachineExists      bool
+		expectRequeue            bool
+	}{
+		"reboots_unhealthy_machine": {
+			hardwareName:        hardwareName,
+			expectedPhase:       infrastructurev1.RemediationPhaseRebooting,
+			expectedAnnotation:  infrastructurev1.RebootAnnotation,
+			expectMachineExists: true,
+			expectRequeue:       true,
+		},
+		"waits_for_reboot_timeout": {
+			status: infrastructurev1.TinkerbellRemediationStatus{
+				Phase:          infrastructurev1.RemediationPhaseRebooting,
+				RebootAttempts: 1,
+				TimeoutTime:    timeFromNow(time.Minute),
+			},
+			hardwareName:        hardwareName,
+			expectedPhase:       infrastructurev1.RemediationPhaseRebooting,
+			expectMachineExists: true,
+			expectRequeue:       true,
+		},
+		"reprovisions_machine_still_unhealthy_after_reboot": {
+			status: infrastructurev1.TinkerbellRemediationStatus{
+				Phase:          infrastructurev1.RemediationPhaseRebooting,
+				RebootAttempts: 1,
+				TimeoutTime:    timeFromNow(-time.Minute),
+			},
+			hardwareName:        hardwareName,
+			expectedPhase:       infrastructurev1.RemediationPhaseReprovisioning,
+			expectedAnnotation:  infrastructurev1.ReprovisionAnnotation,
+			expectMachineExists: true,
+			expectRequeue:       true,
+		},
+		"deletes_machine_still_unhealthy_after_reprovisioning": {
+			status: infrastructurev1.TinkerbellRemediationStatus{
+				Phase:               infrastructurev1.RemediationPhaseReprovisioning,
+				RebootAttempts:      1,
+				ReprovisionAttempts: 1,
+				TimeoutTime:         timeFromNow(-time.Minute),
+			},
+			hardwareName:  hardwareName,
+			expectedPhase: infrastructurev1.RemediationPhaseDeleting,
+		},
+		"deletes_machine_without_hardware_right_away": {
+			expectedPhase: infrastructurev1.RemediationPhaseDeleting,
+		},
+		"uses_configured_reboot_timeout": {
+			spec: infrastructurev1.TinkerbellRemediationSpec{
+				RebootTimeout: &metav1.Duration{Duration: time.Hour},
+			},
+			hardwareName:        hardwareName,
+			expectedPhase:       infrastructurev1.RemediationPhaseRebooting,
+			expectedAnnotation:  infrastructurev1.RebootAnnotation,
+			expectedTimeout:     time.Hour,
+			expectMachineExists: true,
+			expectRequeue:       true,
+		},
+		"uses_configured_reprovision_timeout": {
+			spec: infrastructurev1.TinkerbellRemediationSpec{
+				ReprovisionTimeout: &metav1.Duration{Duration: time.Hour},
+			},
+			status: infrastructurev1.TinkerbellRemediationStatus{
+				Phase:          infrastructurev1.RemediationPhaseRebooting,
+				RebootAttempts: 1,
+				TimeoutTime:    timeFromNow(-time.Minute),
+			},
+			hardwareName:        hardwareName,
+			expectedPhase:       infrastructurev1.RemediationPhaseReprovisioning,
+			expectedAnnotation:  infrastructurev1.ReprovisionAnnotation,
+			expectedTimeout:     time.Hour,
+			expectMachineExists: true,
+			expectRequeue:       true,
+		},
+		"waits_for_reprovision_timeout": {
+			status: infrastructurev1.TinkerbellRemediationStatus{
+				Phase:               infrastructurev1.RemediationPhaseReprovisioning,
+				RebootAttempts:      1,
+				ReprovisionAttempts: 1,
+				TimeoutTime:         timeFromNow(time.Minute),
+			},
+			hardwareName:        hardwareName,
+			expectedPhase:       infrastructurev1.RemediationPhaseReprovisioning,
+			expectMachineExists: true,
+			expectRequeue:       true,
+		},
+		"deletes_machine_without_tinkerbell_machine_right_away": {
+			hardwareName:             hardwareName,
+			withoutTinkerbellMachine: true,
+			expectedPhase:            infrastructurev1.RemediationPhaseDeleting,
+		},
+		"deletes_machine_which_tinkerbell_machine_is_gone_after_reboot": {
+			status: infrastructurev1.TinkerbellRemediationStatus{
+				Phase:          infrastructurev1.RemediationPhaseRebooting,
+				RebootAttempts: 1,
+				TimeoutTime:    timeFromNow(-time.Minute),
+			},
+			hardwareName:             hardwareName,
+			withoutTinkerbellMachine: true,
+			expectedPhase:            infrastructurev1.RemediationPhaseDeleting,
+		},
+		"does_nothing_once_machine_is_deleted": {
+			status: infrastructurev1.TinkerbellRemediationStatus{
+				Phase:               infrastructurev1.RemediationPhaseDeleting,
+				RebootAttempts:      1,
+				ReprovisionAttempts: 1,
+			},
+			hardwareName:        hardwareName,
+			expectedPhase:       infrastructurev1.RemediationPhaseDeleting,
+			expectMachineExists: true,
+		},
+	}
+
+	for name, c := range cases { //nolint:paralleltest
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName,
+				uuid.New().String())
+			tinkerbellMachine.Spec.HardwareName = c.hardwareName
+
+			remediation := validRemediation(machineName, clusterNamespace, c.status)
+			remediation.Spec = c.spec
+
+			objects := []runtime.Object{
+				remediation,
+				remediatedMachine(),
+				validCluster(clusterName, clusterNamespace),
+			}
+
+			if !c.withoutTinkerbellMachine {
+				objects = append(objects, tinkerbellMachine)
+			}
+
+			client := kubernetesClientWithObjects(t, objects)
+
+			result, err := reconcileRemediationWithClient(client)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			if c.expectRequeue {
+				g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Expected requeue until the phase times out")
+			} else {
+				g.Expect(result).To(Equal(ctrl.Result{}))
+			}
+
+			g.Expect(client.Get(ctx, types.NamespacedName{Name: machineName, Namespace: clusterNamespace},
+				remediation)).To(Succeed())
+			g.Expect(remediation.Status.Phase).To(Equal(c.expectedPhase))
+
+			if c.expectedTimeout != 0 {
+				g.Expect(remediation.Status.TimeoutTime.Time).To(BeTemporally("~", time.Now().Add(c.expectedTimeout), time.Minute))
+			}
+
+			if c.expectedAnnotation != "" {
+				g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace},
+					tinkerbellMachine)).To(Succeed())
+				g.Expect(tinkerbellMachine.Annotations).To(HaveKey(c.expectedAnnotation))
+			}
+
+			err = client.Get(ctx, types.NamespacedName{Name: machineName, Namespace: clusterNamespace}, &clusterv1.Machine{})
+			if c.expectMachineExists {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected Machine to be deleted")
+			}
+		})
+	}
+
+	t.Run("records_remediation_attempts", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		objects := []runtime.Object{
+			validRemediation(machineName, clusterNamespace, infrastructurev1.TinkerbellRemediationStatus{
+				Phase:          infrastructurev1.RemediationPhaseRebooting,
+				RebootAttempts: 1,
+				TimeoutTime:    timeFromNow(-time.Minute),
+			}),
+			validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, uuid.New().String()),
+			remediatedMachine(),
+			validCluster(clusterName, clusterNamespace),
+		}
+
+		client := kubernetesClientWithObjects(t, objects)
+
+		_, err := reconcileRemediationWithClient(client)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		remediation := &infrastructurev1.TinkerbellRemediation{}
+		g.Expect(client.Get(ctx, types.NamespacedName{Name: machineName, Namespace: clusterNamespace},
+			remediation)).To(Succeed())
+
+		g.Expect(remediation.Status.RebootAttempts).To(Equal(int32(1)))
+		g.Expect(remediation.Status.ReprovisionAttempts).To(Equal(int32(1)))
+		g.Expect(remediation.Status.LastTransitionTime).NotTo(BeNil())
+		g.Expect(remediation.Status.TimeoutTime.Time).To(
+			BeTemporally("~", time.Now().Add(infrastructurev1.DefaultReprovisionTimeout), time.Minute))
+	})
+
+	t.Run("walks_through_phases_until_machine_is_deleted", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		tinkerbellMachine := validTinkerbellMachine(tinkerbellMachineName, clusterNamespace, machineName, uuid.New().String())
+		tinkerbellMachine.Spec.HardwareName = hardwareName
+
+		objects := []runtime.Object{
+			validRemediation(machineName, clusterNamespace, infrastructurev1.TinkerbellRemediationStatus{}),
+			tinkerbellMachine,
+			remediatedMachine(),
+			validCluster(clusterName, clusterNamespace),
+		}
+
+		client := kubernetesClientWithObjects(t, objects)
+		remediationName := types.NamespacedName{Name: machineName, Namespace: clusterNamespace}
+
+		// expireTimeout makes the current phase time out, as if the machine stayed unhealthy.
+		expireTimeout := func() {
+			remediation := &infrastructurev1.TinkerbellRemediation{}
+			g.Expect(client.Get(ctx, remediationName, remediation)).To(Succeed())
+
+			remediation.Status.TimeoutTime = timeFromNow(-time.Second)
+			g.Expect(client.Update(ctx, remediation)).To(Succeed())
+		}
+
+		steps := []struct {
+			expectedPhase               infrastructurev1.RemediationPhase
+			expectedAnnotation          string
+			expectedRebootAttempts      int32
+			expectedReprovisionAttempts int32
+		}{
+			{infrastructurev1.RemediationPhaseRebooting, infrastructurev1.RebootAnnotation, 1, 0},
+			{infrastructurev1.RemediationPhaseReprovisioning, infrastructurev1.ReprovisionAnnotation, 1, 1},
+			{infrastructurev1.RemediationPhaseDeleting, "", 1, 1},
+		}
+
+		for i, step := range steps {
+			if i > 0 {
+				// Phase is kept until it times out.
+				result, err := reconcileRemediationWithClient(client)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+				expireTimeout()
+			}
+
+			_, err := reconcileRemediationWithClient(client)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			remediation := &infrastructurev1.TinkerbellRemediation{}
+			g.Expect(client.Get(ctx, remediationName, remediation)).To(Succeed())
+			g.Expect(remediation.Status.Phase).To(Equal(step.expectedPhase))
+			g.Expect(remediation.Status.RebootAttempts).To(Equal(step.expectedRebootAttempts))
+			g.Expect(remediation.Status.ReprovisionAttempts).To(Equal(step.expectedReprovisionAttempts))
+
+			if step.expectedAnnotation != "" {
+				tinkerbellMachine := &infrastructurev1.TinkerbellMachine{}
+				g.Expect(client.Get(ctx, types.NamespacedName{Name: tinkerbellMachineName, Namespace: clusterNamespace},
+					tinkerbellMachine)).To(Succeed())
+				g.Expect(tinkerbellMachine.Annotations).To(HaveKey(step.expectedAnnotation))
+			}
+		}
+
+		err := client.Get(ctx, types.NamespacedName{Name: machineName, Namespace: clusterNamespace}, &clusterv1.Machine{})
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Expected Machine to be deleted")
+
+		// Remediation of the deleted Machine is not requeued anymore.
+		result, err := reconcileRemediationWithClient(client)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(ctrl.Result{}))
+	})
+}
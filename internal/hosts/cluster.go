@@ -0,0 +1,37 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hosts
+
+import (
+	"context"
+
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const clusterClientSourceName = "cluster-api-provider-tinkerbell"
+
+// ClusterClientFunc returns the client of the workload cluster with the given key.
+type ClusterClientFunc func(ctx context.Context, cluster client.ObjectKey) (client.Client, error)
+
+// NewClusterClientFunc returns a ClusterClientFunc accessing the workload clusters using their kubeconfig
+// secrets read with the given management cluster client.
+func NewClusterClientFunc(c client.Client) ClusterClientFunc {
+	return func(ctx context.Context, cluster client.ObjectKey) (client.Client, error) {
+		return remote.NewClusterClient(ctx, clusterClientSourceName, c, cluster) //nolint:wrapcheck
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hosts provides methods for checking reachability of already provisioned hosts
+// adopted by Tinkerbell machines and for rebooting them.
+package hosts
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultPort is the kubelet port, which all kubernetes nodes listen on.
+	DefaultPort = 10250
+
+	defaultTimeout = 5 * time.Second
+)
+
+// Prober checks reachability of hosts by opening TCP connections.
+type Prober struct {
+	// Port is the TCP port probed on the hosts. If zero, DefaultPort is used.
+	Port int
+
+	// Timeout is the timeout for opening the connection. If zero, default timeout is used.
+	Timeout time.Duration
+}
+
+// NewProber returns prober checking the kubelet port of the hosts.
+func NewProber() *Prober {
+	return &Prober{}
+}
+
+// Reachable reports whether a TCP connection to the probed port of the host with given address can be
+// opened. Error is only returned when the context is done.
+func (p *Prober) Reachable(ctx context.Context, address string) (bool, error) {
+	port := p.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+	if err != nil {
+		return false, ctx.Err() //nolint:wrapcheck
+	}
+
+	return true, conn.Close() //nolint:wrapcheck
+}
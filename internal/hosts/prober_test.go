@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hosts_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/hosts"
+)
+
+func Test_Probing_host_reachability(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+
+	t.Run("reports_host_listening_on_probed_port_as_reachable", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		prober := &hosts.Prober{Port: port}
+
+		reachable, err := prober.Reachable(context.Background(), "127.0.0.1")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(reachable).To(BeTrue())
+	})
+
+	t.Run("reports_host_not_listening_on_probed_port_as_unreachable", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		closed, err := net.Listen("tcp", "127.0.0.1:0")
+		g.Expect(err).NotTo(HaveOccurred())
+
+		closedPort := closed.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+		g.Expect(closed.Close()).To(Succeed())
+
+		prober := &hosts.Prober{Port: closedPort, Timeout: time.Second}
+
+		reachable, err := prober.Reachable(context.Background(), "127.0.0.1")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(reachable).To(BeFalse())
+	})
+
+	t.Cleanup(func() {
+		listener.Close() //nolint:errcheck
+	})
+}
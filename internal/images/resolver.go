@@ -0,0 +1,504 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package images provides methods for checking availability of machine images
+// used for provisioning Tinkerbell machines.
+package images
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultPositiveTTL is the default duration for which found images are cached.
+	DefaultPositiveTTL = 10 * time.Minute
+
+	// DefaultNegativeTTL is the default duration for which missing images are cached.
+	DefaultNegativeTTL = 1 * time.Minute
+
+	defaultTimeout = 30 * time.Second
+
+	dockerHubRegistry    = "docker.io"
+	dockerHubAPIRegistry = "registry-1.docker.io"
+
+	manifestMediaTypes = "application/vnd.oci.image.manifest.v1+json," +
+		"application/vnd.oci.image.index.v1+json," +
+		"application/vnd.docker.distribution.manifest.v2+json," +
+		"application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+var (
+	// ErrInvalidReference is the error returned when the image URL is neither HTTP(S) URL nor OCI reference.
+	ErrInvalidReference = fmt.Errorf("invalid image reference")
+
+	// ErrUnexpectedStatus is the error returned when the image source responds with an unexpected status code.
+	ErrUnexpectedStatus = fmt.Errorf("unexpected response status")
+
+	// ErrUnsupportedCredentials is the error returned when the credentials Secret has unsupported format.
+	ErrUnsupportedCredentials = fmt.Errorf("unsupported credentials secret")
+)
+
+// Resolver checks availability of machine images and caches the results.
+type Resolver struct {
+	// HTTPClient is used for all requests. If nil, client with default timeout is used.
+	HTTPClient *http.Client
+
+	// PositiveTTL is the duration for which found images are cached.
+	PositiveTTL time.Duration
+
+	// NegativeTTL is the duration for which missing images are cached.
+	NegativeTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	now   func() time.Time
+}
+
+// Options configure how availability of an image is checked.
+type Options struct {
+	// Credentials are used for accessing the image source. Optional.
+	Credentials *Credentials
+
+	// PlainHTTPRegistries lists OCI registries, which are accessed over plain HTTP instead of HTTPS.
+	PlainHTTPRegistries []string
+}
+
+func (o Options) plainHTTP(registry string) bool {
+	for _, plainHTTPRegistry := range o.PlainHTTPRegistries {
+		if plainHTTPRegistry == registry {
+			return true
+		}
+	}
+
+	return false
+}
+
+type cacheEntry struct {
+	available bool
+	expires   time.Time
+}
+
+// NewResolver returns Resolver with default cache TTLs.
+func NewResolver() *Resolver {
+	return &Resolver{
+		HTTPClient:  &http.Client{Timeout: defaultTimeout},
+		PositiveTTL: DefaultPositiveTTL,
+		NegativeTTL: DefaultNegativeTTL,
+	}
+}
+
+// Available checks if the image pointed by the given URL exists. HTTP(S) URLs are checked using
+// HEAD request, everything else is treated as OCI reference and checked by looking up the manifest
+// in the registry over HTTPS, unless the registry is listed in plain HTTP registries.
+//
+// Errors are returned only when availability could not be determined.
+func (r *Resolver) Available(ctx context.Context, imageURL string, options Options) (bool, error) {
+	if strings.HasPrefix(imageURL, "http://") || strings.HasPrefix(imageURL, "https://") {
+		return r.cachedAvailable(imageURL, func() (bool, error) {
+			return r.httpAvailable(ctx, imageURL, options.Credentials)
+		})
+	}
+
+	ref, err := parseReference(imageURL)
+	if err != nil {
+		return false, err
+	}
+
+	scheme := "https"
+	if options.plainHTTP(ref.registry) {
+		scheme = "http"
+	}
+
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, ref.registry, ref.repository, ref.reference)
+
+	return r.cachedAvailable(manifestURL, func() (bool, error) {
+		return r.ociAvailable(ctx, manifestURL, ref, options.Credentials)
+	})
+}
+
+// cachedAvailable returns cached availability of the given URL or checks and caches it.
+func (r *Resolver) cachedAvailable(key string, check func() (bool, error)) (bool, error) {
+	if available, ok := r.cached(key); ok {
+		return available, nil
+	}
+
+	available, err := check()
+	if err != nil {
+		return false, err
+	}
+
+	r.store(key, available)
+
+	return available, nil
+}
+
+func (r *Resolver) cached(key string) (bool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.cache[key]
+	if !ok || r.timeNow().After(entry.expires) {
+		return false, false
+	}
+
+	return entry.available, true
+}
+
+func (r *Resolver) store(key string, available bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cache == nil {
+		r.cache = map[string]cacheEntry{}
+	}
+
+	ttl := r.NegativeTTL
+	if available {
+		ttl = r.PositiveTTL
+	}
+
+	r.cache[key] = cacheEntry{
+		available: available,
+		expires:   r.timeNow().Add(ttl),
+	}
+}
+
+func (r *Resolver) timeNow() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+
+	return time.Now()
+}
+
+func (r *Resolver) httpClient() *http.Client {
+	if r.HTTPClient != nil {
+		return r.HTTPClient
+	}
+
+	return &http.Client{Timeout: defaultTimeout}
+}
+
+func (r *Resolver) httpAvailable(ctx context.Context, imageURL string, credentials *Credentials) (bool, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidReference, err) //nolint:errorlint
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, imageURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+
+	if username, password, ok := credentials.forHost(u.Host); ok {
+		req.SetBasicAuth(username, password)
+	}
+
+	return r.check(req)
+}
+
+func (r *Resolver) check(req *http.Request) (bool, error) {
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return false, fmt.Errorf("requesting %q: %w", req.URL, err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	return available(resp)
+}
+
+func available(resp *http.Response) (bool, error) {
+	switch {
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w %d from %q", ErrUnexpectedStatus, resp.StatusCode, resp.Request.URL)
+	}
+}
+
+// reference is a parsed OCI image reference.
+type reference struct {
+	registry   string
+	repository string
+	reference  string
+}
+
+func parseReference(imageURL string) (reference, error) {
+	imageURL = strings.TrimPrefix(imageURL, "oci://")
+
+	slash := strings.Index(imageURL, "/")
+	if slash <= 0 {
+		return reference{}, fmt.Errorf("%w: %q has no registry", ErrInvalidReference, imageURL)
+	}
+
+	ref := reference{registry: imageURL[:slash]}
+	remainder := imageURL[slash+1:]
+
+	switch {
+	case strings.Contains(remainder, "@"):
+		at := strings.Index(remainder, "@")
+		ref.repository, ref.reference = remainder[:at], remainder[at+1:]
+	case strings.LastIndex(remainder, ":") > strings.LastIndex(remainder, "/"):
+		colon := strings.LastIndex(remainder, ":")
+		ref.repository, ref.reference = remainder[:colon], remainder[colon+1:]
+	default:
+		ref.repository, ref.reference = remainder, "latest"
+	}
+
+	if ref.repository == "" || ref.reference == "" {
+		return reference{}, fmt.Errorf("%w: %q", ErrInvalidReference, imageURL)
+	}
+
+	if ref.registry == dockerHubRegistry {
+		ref.registry = dockerHubAPIRegistry
+	}
+
+	return ref, nil
+}
+
+func (r *Resolver) ociAvailable(
+	ctx context.Context,
+	manifestURL string,
+	ref reference,
+	credentials *Credentials,
+) (bool, error) {
+	req, err := r.manifestRequest(ctx, manifestURL)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return false, fmt.Errorf("requesting %q: %w", manifestURL, err)
+	}
+
+	resp.Body.Close() //nolint:errcheck,gosec
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return available(resp)
+	}
+
+	// Registry requires authentication, even for anonymous access in most cases.
+	authorization, err := r.authorization(ctx, resp.Header.Get("WWW-Authenticate"), ref, credentials)
+	if err != nil {
+		return false, err
+	}
+
+	req, err = r.manifestRequest(ctx, manifestURL)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Authorization", authorization)
+
+	return r.check(req)
+}
+
+func (r *Resolver) manifestRequest(ctx context.Context, manifestURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", manifestMediaTypes)
+
+	return req, nil
+}
+
+// authorization returns Authorization header value for the given authentication challenge.
+func (r *Resolver) authorization(
+	ctx context.Context,
+	challenge string,
+	ref reference,
+	credentials *Credentials,
+) (string, error) {
+	username, password, hasCredentials := credentials.forHost(ref.registry)
+
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCredentials {
+			return "", fmt.Errorf("%w %d from %q: credentials required", ErrUnexpectedStatus,
+				http.StatusUnauthorized, ref.registry)
+		}
+
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("%w %d from %q: unsupported challenge %q", ErrUnexpectedStatus,
+			http.StatusUnauthorized, ref.registry, challenge)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("%w: invalid token realm in challenge %q", ErrUnexpectedStatus, challenge)
+	}
+
+	query := tokenURL.Query()
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", ref.repository))
+
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("creating token request: %w", err)
+	}
+
+	if hasCredentials {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting token from %q: %w", tokenURL.Host, err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w %d from %q", ErrUnexpectedStatus, resp.StatusCode, tokenURL.Host)
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge parses WWW-Authenticate header value, e.g.
+// Bearer realm="https://ghcr.io/token",service="ghcr.io".
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2) //nolint:gomnd
+	if len(parts) < 2 {                                           //nolint:gomnd
+		return parts[0], params
+	}
+
+	for _, param := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2) //nolint:gomnd
+		if len(kv) != 2 {                                      //nolint:gomnd
+			continue
+		}
+
+		params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+
+	return parts[0], params
+}
+
+// Credentials holds credentials used for accessing image sources.
+type Credentials struct {
+	username string
+	password string
+
+	// registries holds per-host credentials parsed from docker config.
+	registries map[string]dockerAuth
+}
+
+type dockerAuth struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// CredentialsFromSecret parses credentials from either kubernetes.io/basic-auth or
+// kubernetes.io/dockerconfigjson Secret.
+func CredentialsFromSecret(secret *corev1.Secret) (*Credentials, error) {
+	if dockerConfig, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+		config := struct {
+			Auths map[string]dockerAuth `json:"auths"`
+		}{}
+
+		if err := json.Unmarshal(dockerConfig, &config); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", corev1.DockerConfigJsonKey, err)
+		}
+
+		return &Credentials{registries: config.Auths}, nil
+	}
+
+	username, hasUsername := secret.Data[corev1.BasicAuthUsernameKey]
+	password, hasPassword := secret.Data[corev1.BasicAuthPasswordKey]
+
+	if !hasUsername || !hasPassword {
+		return nil, fmt.Errorf("%w: expected either %q key or %q and %q keys", ErrUnsupportedCredentials,
+			corev1.DockerConfigJsonKey, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+	}
+
+	return &Credentials{username: string(username), password: string(password)}, nil
+}
+
+func (c *Credentials) forHost(host string) (string, string, bool) {
+	if c == nil {
+		return "", "", false
+	}
+
+	if c.registries == nil {
+		return c.username, c.password, true
+	}
+
+	for registry, auth := range c.registries {
+		registryHost := strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+		registryHost = strings.SplitN(registryHost, "/", 2)[0] //nolint:gomnd
+
+		if registryHost != host && !(host == dockerHubAPIRegistry && registryHost == "index.docker.io") {
+			continue
+		}
+
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				continue
+			}
+
+			if userPass := strings.SplitN(string(decoded), ":", 2); len(userPass) == 2 { //nolint:gomnd
+				return userPass[0], userPass[1], true
+			}
+		}
+
+		return auth.Username, auth.Password, true
+	}
+
+	return "", "", false
+}
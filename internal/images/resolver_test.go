@@ -0,0 +1,322 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/images"
+)
+
+const (
+	username = "foo"
+	password = "bar"
+	token    = "baz"
+)
+
+//nolint:funlen
+func Test_Checking_availability_of_image_served_over_HTTP(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		path             string
+		expectAvailable  bool
+		expectedError    error
+		credentials      *images.Credentials
+		expectedRequests int32
+		checkTwice       bool
+	}{
+		"reports_existing_image_as_available": {
+			path:             "/exists.gz",
+			expectAvailable:  true,
+			expectedRequests: 1,
+		},
+		"reports_missing_image_as_not_available": {
+			path:             "/missing.gz",
+			expectedRequests: 1,
+		},
+		"returns_error_on_unexpected_response_status": {
+			path:             "/error.gz",
+			expectedError:    images.ErrUnexpectedStatus,
+			expectedRequests: 1,
+		},
+		"caches_results": {
+			path:             "/exists.gz",
+			expectAvailable:  true,
+			expectedRequests: 1,
+			checkTwice:       true,
+		},
+		"does_not_cache_errors": {
+			path:             "/error.gz",
+			expectedError:    images.ErrUnexpectedStatus,
+			expectedRequests: 2, //nolint:gomnd
+			checkTwice:       true,
+		},
+		"uses_basic_auth_credentials": {
+			path:             "/private.gz",
+			expectAvailable:  true,
+			expectedRequests: 1,
+			credentials:      basicAuthCredentials(t),
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewWithT(t)
+
+			var requests int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+
+				if r.Method != http.MethodHead {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+
+					return
+				}
+
+				switch r.URL.Path {
+				case "/exists.gz":
+				case "/private.gz":
+					if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+						w.WriteHeader(http.StatusUnauthorized)
+					}
+				case "/error.gz":
+					w.WriteHeader(http.StatusInternalServerError)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			t.Cleanup(server.Close)
+
+			resolver := images.NewResolver()
+
+			checks := 1
+			if c.checkTwice {
+				checks = 2
+			}
+
+			for i := 0; i < checks; i++ {
+				available, err := resolver.Available(context.Background(), server.URL+c.path, images.Options{Credentials: c.credentials})
+				if c.expectedError != nil {
+					g.Expect(err).To(MatchError(c.expectedError))
+				} else {
+					g.Expect(err).NotTo(HaveOccurred())
+				}
+
+				g.Expect(available).To(Equal(c.expectAvailable))
+			}
+
+			g.Expect(atomic.LoadInt32(&requests)).To(Equal(c.expectedRequests))
+		})
+	}
+}
+
+func Test_Checking_availability_of_image_in_OCI_registry(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(nil)
+	t.Cleanup(server.Close)
+
+	server.Config.Handler = registryHandler(t, server.URL)
+
+	registry := strings.TrimPrefix(server.URL, "https://")
+
+	credentials, err := images.CredentialsFromSecret(&corev1.Secret{
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, registry,
+				base64.StdEncoding.EncodeToString([]byte(username+":"+password)))),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Parsing credentials: %v", err)
+	}
+
+	cases := map[string]struct {
+		reference       string
+		credentials     *images.Credentials
+		expectAvailable bool
+		expectedError   error
+	}{
+		"reports_existing_tag_as_available": {
+			reference:       registry + "/public/ubuntu:v1.20.11",
+			expectAvailable: true,
+		},
+		"reports_missing_tag_as_not_available": {
+			reference: registry + "/public/ubuntu:v1.20.12",
+		},
+		"reports_image_from_private_repository_as_available_with_credentials": {
+			reference:       "oci://" + registry + "/private/ubuntu:v1.20.11",
+			credentials:     credentials,
+			expectAvailable: true,
+		},
+		"returns_error_for_private_repository_without_credentials": {
+			reference:     registry + "/private/ubuntu:v1.20.11",
+			expectedError: images.ErrUnexpectedStatus,
+		},
+		"returns_error_for_reference_without_registry": {
+			reference:     "ubuntu",
+			expectedError: images.ErrInvalidReference,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewWithT(t)
+
+			resolver := images.NewResolver()
+			resolver.HTTPClient = server.Client()
+
+			available, err := resolver.Available(context.Background(), c.reference, images.Options{Credentials: c.credentials})
+			if c.expectedError != nil {
+				g.Expect(err).To(MatchError(c.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			g.Expect(available).To(Equal(c.expectAvailable))
+		})
+	}
+}
+
+func Test_Checking_availability_of_image_in_plain_HTTP_OCI_registry(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(nil)
+	t.Cleanup(server.Close)
+
+	server.Config.Handler = registryHandler(t, server.URL)
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+	reference := registry + "/public/ubuntu:v1.20.11"
+
+	t.Run("reports_existing_tag_as_available", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+
+		available, err := images.NewResolver().Available(context.Background(), reference, images.Options{
+			PlainHTTPRegistries: []string{registry},
+		})
+
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(available).To(BeTrue())
+	})
+
+	t.Run("returns_error_when_registry_is_not_configured_for_plain_HTTP", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+
+		available, err := images.NewResolver().Available(context.Background(), reference, images.Options{})
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(available).To(BeFalse())
+	})
+}
+
+func Test_Parsing_credentials_from_Secret_requires_supported_keys(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+
+	_, err := images.CredentialsFromSecret(&corev1.Secret{
+		Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte(username),
+		},
+	})
+
+	g.Expect(err).To(MatchError(images.ErrUnsupportedCredentials))
+}
+
+func basicAuthCredentials(t *testing.T) *images.Credentials {
+	t.Helper()
+
+	credentials, err := images.CredentialsFromSecret(&corev1.Secret{
+		Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte(username),
+			corev1.BasicAuthPasswordKey: []byte(password),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Parsing credentials: %v", err)
+	}
+
+	return credentials
+}
+
+// registryHandler mimics OCI distribution API with token authentication. Repositories
+// under "private/" require credentials to obtain a token.
+func registryHandler(t *testing.T, serverURL string) http.Handler {
+	t.Helper()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			scope := r.URL.Query().Get("scope")
+
+			if strings.HasPrefix(scope, "repository:private/") {
+				if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+					w.WriteHeader(http.StatusUnauthorized)
+
+					return
+				}
+			}
+
+			fmt.Fprintf(w, `{"token":%q}`, token)
+
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, serverURL))
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.manifest.v1+json") {
+			w.WriteHeader(http.StatusNotAcceptable)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/public/ubuntu/manifests/v1.20.11", "/v2/private/ubuntu/manifests/v1.20.11":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains Prometheus metrics exposed by CAPT. All metrics are registered
+// into the controller-runtime registry, so they are served on the manager metrics endpoint.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Namespace is the prefix of all CAPT metrics.
+const Namespace = "capt"
+
+// Provisioning phases observed by ProvisioningPhaseDuration.
+const (
+	// PhaseWorkflowCreation is the time from selecting hardware for a machine to creating the workflow.
+	PhaseWorkflowCreation = "workflow_creation"
+
+	// PhaseWorkflowExecution is the time from creating the workflow to the workflow succeeding.
+	PhaseWorkflowExecution = "workflow_execution"
+)
+
+//nolint:gochecknoglobals
+var (
+	// ProvisioningPhaseDuration observes durations of machine provisioning phases.
+	ProvisioningPhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "machine_provisioning_phase_duration_seconds",
+		Help:      "Duration of machine provisioning phases.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, []string{"phase"})
+
+	// WorkflowOutcomes counts workflows reaching a terminal state.
+	WorkflowOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "workflow_outcomes_total",
+		Help:      "Number of workflows reaching a terminal state, by state.",
+	}, []string{"state"})
+
+	// TinkRequestDuration observes latency of Tinkerbell API calls.
+	TinkRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "tink_request_duration_seconds",
+		Help:      "Latency of Tinkerbell API calls, by client and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"client", "method"})
+
+	// TinkRequestErrors counts failed Tinkerbell API calls.
+	TinkRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "tink_request_errors_total",
+		Help:      "Number of failed Tinkerbell API calls, by client and method.",
+	}, []string{"client", "method"})
+
+	// ReconcileErrors counts reconciliation errors.
+	ReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "reconcile_errors_total",
+		Help:      "Number of reconciliation errors, by controller and error reason.",
+	}, []string{"controller", "reason"})
+)
+
+//nolint:gochecknoinits
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		ProvisioningPhaseDuration,
+		WorkflowOutcomes,
+		TinkRequestDuration,
+		TinkRequestErrors,
+		ReconcileErrors,
+	)
+}
+
+// ObserveTinkRequest records latency and outcome of a Tinkerbell API call started at given time.
+func ObserveTinkRequest(client, method string, start time.Time, err error) {
+	TinkRequestDuration.WithLabelValues(client, method).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		TinkRequestErrors.WithLabelValues(client, method).Inc()
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultActionTimeout is the timeout of user supplied actions in seconds when not specified.
+const DefaultActionTimeout = 600
+
+// Action is a user supplied action added to the default workflow.
+type Action struct {
+	Name  string
+	Image string
+
+	// Timeout is the timeout of the action in seconds. If zero, DefaultActionTimeout is used.
+	Timeout int64
+
+	Environment map[string]string
+	Volumes     []string
+}
+
+// TimeoutSeconds returns the timeout of the action in seconds.
+func (a Action) TimeoutSeconds() int64 {
+	if a.Timeout <= 0 {
+		return DefaultActionTimeout
+	}
+
+	return a.Timeout
+}
+
+// quote returns the value as double-quoted YAML scalar. JSON strings are valid YAML scalars, which keeps
+// user supplied values, e.g. read from Secrets, from breaking the rendered workflow. Template delimiters
+// are escaped, as Tinkerbell templates the workflow when it is created.
+func quote(value string) (string, error) {
+	quoted, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("quoting %q: %w", value, err)
+	}
+
+	return strings.ReplaceAll(string(quoted), "{{", "{{`{{`}}"), nil
+}
+
+// actionsTemplate renders a list of user supplied actions.
+const actionsTemplate = `
+{{- define "actions"}}
+{{- range .}}
+      - name: {{quote .Name}}
+        image: {{quote .Image}}
+        timeout: {{.TimeoutSeconds}}
+{{- with .Environment}}
+        environment:
+{{- range $name, $value := .}}
+          {{quote $name}}: {{quote $value}}
+{{- end}}
+{{- end}}
+{{- with .Volumes}}
+        volumes:
+{{- range .}}
+          - {{quote .}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}`
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	tmplparse "text/template/parse"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RenderOverride renders the given template override with the data of the workflow template, so
+// overrides can refer e.g. to {{.ImageURL}} or {{.DestDisk}}. Fields evaluated by Tinkerbell, like
+// {{.device_1}} or {{.hardware}}, start with a lower case letter, so they can not refer to the data
+// of the workflow template and are passed through unchanged, unless used within range or with.
+func (wt WorkflowTemplate) RenderOverride(override string) (string, error) {
+	tpl, err := template.New("override").
+		Funcs(template.FuncMap{"quote": quote}).
+		Option("missingkey=error").
+		Parse(override)
+	if err != nil {
+		return "", fmt.Errorf("parsing template override: %w", err)
+	}
+
+	for _, tree := range tpl.Templates() {
+		passThroughTinkerbellFields(tree.Tree.Root)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := tpl.Execute(buf, wt); err != nil {
+		return "", fmt.Errorf("rendering template override: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// passThroughTinkerbellFields replaces actions evaluating fields for Tinkerbell with their source text.
+// Only actions where the dot is the workflow template are replaced.
+func passThroughTinkerbellFields(list *tmplparse.ListNode) {
+	if list == nil {
+		return
+	}
+
+	for i, node := range list.Nodes {
+		switch node := node.(type) {
+		case *tmplparse.ActionNode:
+			if tinkerbellField(node) {
+				list.Nodes[i] = &tmplparse.TextNode{NodeType: tmplparse.NodeText, Pos: node.Pos, Text: []byte(node.String())}
+			}
+		case *tmplparse.IfNode:
+			passThroughTinkerbellFields(node.List)
+			passThroughTinkerbellFields(node.ElseList)
+		}
+	}
+}
+
+// tinkerbellField reports whether the action only evaluates a field starting with a lower case letter.
+func tinkerbellField(action *tmplparse.ActionNode) bool {
+	if len(action.Pipe.Decl) > 0 || len(action.Pipe.Cmds) != 1 || len(action.Pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+
+	field, ok := action.Pipe.Cmds[0].Args[0].(*tmplparse.FieldNode)
+	if !ok {
+		return false
+	}
+
+	first, _ := utf8.DecodeRuneInString(field.Ident[0])
+
+	return unicode.IsLower(first)
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templates
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultFilesystem is the filesystem of the root partition when not specified.
+const DefaultFilesystem = "ext4"
+
+// Partition is a partition created in the free space of the disk after the image is written.
+type Partition struct {
+	// Label is the GPT partition name, which is used for finding the partition.
+	Label string
+
+	// SizeMiB is the size of the partition in MiB. If zero, the partition takes the remaining space.
+	SizeMiB int64
+
+	// Filesystem is the filesystem the partition is formatted with. Not used when VolumeGroup is set.
+	Filesystem string
+
+	// MountPoint is where the filesystem is mounted in the installed OS. If empty, it is not mounted.
+	MountPoint string
+
+	// VolumeGroup is the name of the LVM volume group the partition is added to as physical volume.
+	VolumeGroup string
+}
+
+// VolumeGroup is an LVM volume group created on the partitions referencing it.
+type VolumeGroup struct {
+	Name           string
+	LogicalVolumes []LogicalVolume
+}
+
+// LogicalVolume is an LVM logical volume created in the volume group.
+type LogicalVolume struct {
+	Name string
+
+	// SizeMiB is the size of the volume in MiB. If zero, the volume takes the remaining space.
+	SizeMiB int64
+
+	Filesystem string
+	MountPoint string
+}
+
+// mount is a filesystem registered in the fstab of the installed OS.
+type mount struct {
+	device     string
+	filesystem string
+	mountPoint string
+}
+
+// RootFilesystemType returns the filesystem of the root partition.
+func (wt WorkflowTemplate) RootFilesystemType() string {
+	if wt.RootFilesystem == "" {
+		return DefaultFilesystem
+	}
+
+	return wt.RootFilesystem
+}
+
+// StorageCommands returns the shell commands creating the partitions, volume groups and filesystems
+// and registering the filesystems in the fstab of the root partition. When data partitions are preserved,
+// only the fstab is updated. Mounts are not registered for Ignition bootstrap format, as they are
+// configured by Ignition. If there is nothing to do, no commands are returned.
+func (wt WorkflowTemplate) StorageCommands() []string {
+	if len(wt.Partitions) == 0 {
+		return nil
+	}
+
+	commands := []string{
+		"set -eux",
+		"apk add --no-cache sgdisk util-linux e2fsprogs xfsprogs lvm2",
+		fmt.Sprintf(`part() { blkid -o device -t PARTLABEL="$1" | grep "^%s" | head -n 1; }`, wt.DestDisk),
+	}
+
+	if !wt.PreserveDataPartitions {
+		commands = append(commands, wt.createStorageCommands()...)
+	} else if len(wt.VolumeGroups) > 0 {
+		commands = append(commands, "vgchange -ay")
+	}
+
+	mounts := wt.mounts()
+
+	if len(mounts) == 0 || wt.BootstrapFormat == BootstrapFormatIgnition {
+		return commands
+	}
+
+	commands = append(commands, fmt.Sprintf("mount -t %s %s /mnt", wt.RootFilesystemType(), wt.DestPartition))
+
+	for _, m := range mounts {
+		commands = append(commands,
+			fmt.Sprintf("mkdir -p /mnt%s", m.mountPoint),
+			fmt.Sprintf(`echo "UUID=$(blkid -s UUID -o value %s) %s %s defaults 0 2" >> /mnt/etc/fstab`,
+				m.device, m.mountPoint, m.filesystem),
+		)
+	}
+
+	return append(commands, "umount /mnt")
+}
+
+// RAIDCommands returns the shell commands creating the software RAID array from the member disks, or
+// assembling it when data partitions are preserved. If no array is configured, no commands are returned.
+func (wt WorkflowTemplate) RAIDCommands() []string {
+	if len(wt.RAIDDevices) == 0 {
+		return nil
+	}
+
+	devices := strings.Join(wt.RAIDDevices, " ")
+
+	commands := []string{
+		"set -eux",
+		"apk add --no-cache mdadm",
+	}
+
+	if wt.PreserveDataPartitions {
+		return append(commands, fmt.Sprintf("mdadm --assemble --run %s %s", wt.DestDisk, devices))
+	}
+
+	// Superblock at the end of the members allows firmware to boot from any member of a mirror.
+	return append(commands,
+		"mdadm --stop --scan || true",
+		fmt.Sprintf("mdadm --zero-superblock --force %s || true", devices),
+		fmt.Sprintf("mdadm --create %s --run --metadata=1.0 --level=%d --raid-devices=%d %s",
+			wt.DestDisk, wt.RAIDLevel, len(wt.RAIDDevices), devices),
+	)
+}
+
+// createStorageCommands returns the shell commands creating the partitions, volume groups and filesystems.
+func (wt WorkflowTemplate) createStorageCommands() []string {
+	// Written image is usually smaller than the disk, so the backup GPT header must be moved to the end
+	// of the disk first.
+	commands := []string{fmt.Sprintf("sgdisk -e %s", wt.DestDisk)}
+
+	for _, p := range wt.Partitions {
+		end := "0"
+		if p.SizeMiB > 0 {
+			end = fmt.Sprintf("+%dM", p.SizeMiB)
+		}
+
+		commands = append(commands, fmt.Sprintf("sgdisk -n 0:0:%s -c 0:%s %s", end, p.Label, wt.DestDisk))
+	}
+
+	commands = append(commands, fmt.Sprintf("partx -u %s", wt.DestDisk))
+
+	physicalVolumes := map[string][]string{}
+
+	for _, p := range wt.Partitions {
+		device := fmt.Sprintf(`"$(part %s)"`, p.Label)
+
+		if p.VolumeGroup != "" {
+			commands = append(commands, fmt.Sprintf("pvcreate -ff -y %s", device))
+			physicalVolumes[p.VolumeGroup] = append(physicalVolumes[p.VolumeGroup], device)
+
+			continue
+		}
+
+		commands = append(commands, mkfs(p.Filesystem, device))
+	}
+
+	for _, vg := range wt.VolumeGroups {
+		commands = append(commands,
+			fmt.Sprintf("vgcreate -y %s %s", vg.Name, strings.Join(physicalVolumes[vg.Name], " ")))
+
+		for _, lv := range vg.LogicalVolumes {
+			size := "-l 100%FREE"
+			if lv.SizeMiB > 0 {
+				size = fmt.Sprintf("-L %dm", lv.SizeMiB)
+			}
+
+			commands = append(commands,
+				fmt.Sprintf("lvcreate -y -n %s %s %s", lv.Name, size, vg.Name),
+				mkfs(lv.Filesystem, fmt.Sprintf("/dev/%s/%s", vg.Name, lv.Name)),
+			)
+		}
+	}
+
+	return commands
+}
+
+// mounts returns the filesystems with mount points.
+func (wt WorkflowTemplate) mounts() []mount {
+	mounts := []mount{}
+
+	for _, p := range wt.Partitions {
+		if p.VolumeGroup == "" && p.MountPoint != "" {
+			mounts = append(mounts, mount{
+				device:     fmt.Sprintf(`"$(part %s)"`, p.Label),
+				filesystem: p.Filesystem,
+				mountPoint: p.MountPoint,
+			})
+		}
+	}
+
+	for _, vg := range wt.VolumeGroups {
+		for _, lv := range vg.LogicalVolumes {
+			if lv.MountPoint != "" {
+				mounts = append(mounts, mount{
+					device:     fmt.Sprintf("/dev/%s/%s", vg.Name, lv.Name),
+					filesystem: lv.Filesystem,
+					mountPoint: lv.MountPoint,
+				})
+			}
+		}
+	}
+
+	return mounts
+}
+
+// mkfs returns the shell command formatting the device with given filesystem.
+func mkfs(filesystem, device string) string {
+	if filesystem == "xfs" {
+		return fmt.Sprintf("mkfs.xfs -f %s", device)
+	}
+
+	return fmt.Sprintf("mkfs.%s -F %s", filesystem, device)
+}
@@ -19,7 +19,12 @@ limitations under the License.
 package templates
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
+	"text/template"
+
+	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 )
 
 var (
@@ -28,6 +33,65 @@ var (
 
 	// ErrMissingImageURL is the error returned when the WorfklowTemplate ImageURL is not specified.
 	ErrMissingImageURL = fmt.Errorf("imageURL can't be empty")
+
+	// ErrMissingImage is the error returned when the PowerCycleWorkflowTemplate Image is not specified.
+	ErrMissingImage = fmt.Errorf("image can't be empty")
+
+	// ErrMissingBMCAddress is the error returned when the PowerCycleWorkflowTemplate BMCAddress is not
+	// specified.
+	ErrMissingBMCAddress = fmt.Errorf("BMC address can't be empty")
+
+	// ErrUnsupportedBootstrapFormat is the error returned when the WorkflowTemplate BootstrapFormat
+	// is not supported.
+	ErrUnsupportedBootstrapFormat = fmt.Errorf("unsupported bootstrap format")
+
+	// ErrUnsupportedBootMode is the error returned when the BootWorkflowTemplate BootMode does not
+	// restart the machine.
+	ErrUnsupportedBootMode = fmt.Errorf("unsupported boot mode")
+)
+
+// DefaultArch is the architecture action images are built for when not suffixed with architecture.
+const DefaultArch = "amd64"
+
+// BootstrapFormat is the format of the bootstrap data, which determines the workflow flavor.
+type BootstrapFormat string
+
+const (
+	// BootstrapFormatCloudConfig is used for bootstrap data in cloud-config format. Workflow configures
+	// cloud-init to fetch the user data from the metadata service and kexecs into the installed OS.
+	BootstrapFormatCloudConfig = BootstrapFormat("cloud-config")
+
+	// BootstrapFormatIgnition is used for bootstrap data in Ignition format. Workflow writes an Ignition
+	// config to the OEM partition, which merges the user data served by the metadata service, and
+	// reboots into the installed OS, so Ignition runs on first boot.
+	BootstrapFormatIgnition = BootstrapFormat("ignition")
+)
+
+// BootMode selects how the installed OS is booted after the provisioning workflow.
+type BootMode string
+
+const (
+	// BootModeKexec kexecs into the installed OS as the final action of the provisioning workflow. Only
+	// supported with BootstrapFormatCloudConfig.
+	BootModeKexec = BootMode("kexec")
+
+	// BootModeReboot reboots the machine into the installed OS from the boot workflow.
+	BootModeReboot = BootMode("reboot")
+
+	// BootModePowerOff powers off the machine from the boot workflow, which boots the installed OS once
+	// powered on.
+	BootModePowerOff = BootMode("poweroff")
+)
+
+const (
+	// RebootActionName is the name of the action rebooting the machine into the installed OS.
+	RebootActionName = "reboot-image"
+
+	// PowerOffActionName is the name of the action powering off the machine.
+	PowerOffActionName = "poweroff-image"
+
+	// PowerCycleActionName is the name of the only action of the power cycle workflow.
+	PowerCycleActionName = "power-cycle"
 )
 
 // WorkflowTemplate is a helper struct for rendering CAPT Template data.
@@ -37,6 +101,155 @@ type WorkflowTemplate struct {
 	ImageURL      string
 	DestDisk      string
 	DestPartition string
+
+	// ImageSHA256 is the expected hex encoded sha256 digest of the image. If set, the digest of images
+	// served over HTTP(S) is computed while the image is streamed to the disk and the action fails with
+	// the partition tables wiped if it does not match. OCI references are pinned to the digest instead.
+	ImageSHA256 string
+
+	// ImageCompression is the compression format of the image. If empty, the image is assumed
+	// to be compressed.
+	ImageCompression string
+
+	// Arch is the CPU architecture of the hardware using GOARCH naming. Action images for
+	// architectures other than DefaultArch are suffixed with the architecture, e.g.
+	// oci2disk:v1.0.0-arm64. If empty, DefaultArch is used.
+	Arch string
+
+	// BootstrapFormat selects workflow flavor. If empty, BootstrapFormatCloudConfig is used.
+	BootstrapFormat BootstrapFormat
+
+	// OEMPartition is the partition the Ignition config is written to. Only used with
+	// BootstrapFormatIgnition.
+	OEMPartition string
+
+	// PreserveDataPartitions writes the image to DestPartition instead of DestDisk, so the partition
+	// table and other partitions of already provisioned hardware are kept. The image must then contain
+	// only the root partition.
+	PreserveDataPartitions bool
+
+	// RootFilesystem is the filesystem of DestPartition, which the bootstrap configuration is written to
+	// and which is booted. If empty, DefaultFilesystem is used.
+	RootFilesystem string
+
+	// Partitions are created in the free space of DestDisk after the image is written, unless data
+	// partitions are preserved.
+	Partitions []Partition
+
+	// VolumeGroups are created on the partitions referencing them.
+	VolumeGroups []VolumeGroup
+
+	// RAIDDevices are the member disks of the software RAID array DestDisk, which is created with RAIDLevel
+	// before the image is written. When data partitions are preserved, the existing array is assembled
+	// instead. If empty, no array is created.
+	RAIDDevices []string
+	RAIDLevel   int
+
+	// PreInstallActions are run before the image is written and before the RAID array is created.
+	PreInstallActions []Action
+
+	// PostInstallActions are run after the bootstrap configuration is written and before the installed
+	// OS is booted.
+	PostInstallActions []Action
+
+	// BootMode selects how the installed OS is booted. If empty, BootModeKexec is used with
+	// BootstrapFormatCloudConfig and BootModeReboot with BootstrapFormatIgnition. Only BootModeKexec
+	// adds an action to the workflow, the machine is restarted by the BootWorkflowTemplate otherwise.
+	BootMode BootMode
+
+	// DefaultUser is the default user of the installed OS. If nil, the user returned by DefaultUser is
+	// created. Only used with BootstrapFormatCloudConfig.
+	DefaultUser *User
+
+	// DisableDefaultUser disables creating the default user of the installed OS.
+	DisableDefaultUser bool
+
+	// Hardware is the Hardware the machine is provisioned on and Disks are the devices of its disks.
+	// Only used by template overrides.
+	Hardware *tinkv1.Hardware
+	Disks    []string
+}
+
+// ImageCompressed returns true if the image should be decompressed when written to the disk.
+func (wt WorkflowTemplate) ImageCompressed() bool {
+	return wt.ImageCompression != "none"
+}
+
+// VerifiesImageWhileStreaming returns true if the digest of the image served over HTTP(S) is verified while
+// the image is streamed to the disk.
+func (wt WorkflowTemplate) VerifiesImageWhileStreaming() bool {
+	return wt.ImageSHA256 != "" && !wt.ociImage()
+}
+
+// StreamedImageURL returns the image URL passed to oci2disk. OCI references with a known digest are pinned
+// to the digest, so the registry serves only the image with the expected content.
+func (wt WorkflowTemplate) StreamedImageURL() string {
+	if wt.ImageSHA256 == "" || !wt.ociImage() {
+		return wt.ImageURL
+	}
+
+	repository := wt.ImageURL
+	slash := strings.LastIndex(repository, "/")
+
+	if at := strings.Index(repository, "@"); at > slash {
+		repository = repository[:at]
+	} else if colon := strings.LastIndex(repository, ":"); colon > slash {
+		repository = repository[:colon]
+	}
+
+	return repository + "@sha256:" + wt.ImageSHA256
+}
+
+func (wt WorkflowTemplate) ociImage() bool {
+	return !strings.HasPrefix(wt.ImageURL, "http://") && !strings.HasPrefix(wt.ImageURL, "https://")
+}
+
+// DecompressCommand returns the command decompressing the image read from the standard input, which is
+// used when the image is verified while it is streamed to the disk.
+func (wt WorkflowTemplate) DecompressCommand() string {
+	switch wt.ImageCompression {
+	case "none":
+		return "cat"
+	case "xz":
+		return "unxz -c"
+	case "bzip2":
+		return "bunzip2 -c"
+	default:
+		return "gunzip -c"
+	}
+}
+
+// EffectiveBootMode returns how the installed OS is booted. Ignition must run on the first boot of the
+// installed OS, so it is always rebooted into instead of kexeced.
+func (wt WorkflowTemplate) EffectiveBootMode() BootMode {
+	switch {
+	case wt.BootMode == BootModePowerOff:
+		return BootModePowerOff
+	case wt.BootMode == BootModeReboot, wt.BootstrapFormat == BootstrapFormatIgnition:
+		return BootModeReboot
+	default:
+		return BootModeKexec
+	}
+}
+
+// ImageDestination returns the block device the image is written to.
+func (wt WorkflowTemplate) ImageDestination() string {
+	if wt.PreserveDataPartitions {
+		return wt.DestPartition
+	}
+
+	return wt.DestDisk
+}
+
+// ActionImage returns the image of the Tinkerbell hub action built for the hardware architecture. Hub
+// actions are published with architecture suffixed tags, unlike multi-arch images like alpine, which must
+// be used as they are.
+func (wt WorkflowTemplate) ActionImage(image string) string {
+	if wt.Arch == "" || wt.Arch == DefaultArch {
+		return image
+	}
+
+	return fmt.Sprintf("%s-%s", image, wt.Arch)
 }
 
 // Render renders workflow template for a given machine including user-data.
@@ -49,36 +262,200 @@ func (wt WorkflowTemplate) Render() (string, error) {
 		return "", ErrMissingImageURL
 	}
 
-	return fmt.Sprintf(workflowTemplate, wt.Name, wt.Name, wt.ImageURL, wt.DestDisk, wt.DestPartition,
-		wt.MetadataURL, wt.DestPartition, wt.DestPartition), nil
+	var flavor string
+
+	switch wt.BootstrapFormat {
+	case "", BootstrapFormatCloudConfig:
+		flavor = workflowTemplate
+	case BootstrapFormatIgnition:
+		flavor = ignitionWorkflowTemplate
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedBootstrapFormat, wt.BootstrapFormat)
+	}
+
+	tpl, err := parse(workflowHeaderTemplate + flavor + actionsTemplate + bootTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := tpl.Execute(buf, wt); err != nil {
+		return "", fmt.Errorf("rendering workflow template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// PowerCycleWorkflowTemplate is a helper struct for rendering CAPT Template data, which power cycles
+// a machine through its BMC using IPMI. The workflow runs on a worker with access to the BMC, so the
+// machine is restarted regardless of the state of its OS.
+type PowerCycleWorkflowTemplate struct {
+	Name       string
+	Image      string
+	BMCAddress string
+	Username   string
+	Password   string
+
+	// PXE makes the machine boot from the network once, so it enters the installation environment.
+	PXE bool
+}
+
+// Render renders power cycle workflow template for a given machine.
+func (pt PowerCycleWorkflowTemplate) Render() (string, error) {
+	if pt.Name == "" {
+		return "", ErrMissingName
+	}
+
+	if pt.Image == "" {
+		return "", ErrMissingImage
+	}
+
+	if pt.BMCAddress == "" {
+		return "", ErrMissingBMCAddress
+	}
+
+	tpl, err := parse(powerCycleWorkflowTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := tpl.Execute(buf, pt); err != nil {
+		return "", fmt.Errorf("rendering workflow template: %w", err)
+	}
+
+	return buf.String(), nil
 }
 
+// BootWorkflowTemplate is a helper struct for rendering CAPT Template data, which restarts the machine
+// into the installed OS. The workflow is run by the installation environment after the provisioning
+// workflow, once netboot of the machine is disabled, so the machine boots from the disk.
+type BootWorkflowTemplate struct {
+	Name string
+
+	// BootMode is either BootModeReboot or BootModePowerOff.
+	BootMode BootMode
+}
+
+// Render renders boot workflow template for a given machine.
+func (bt BootWorkflowTemplate) Render() (string, error) {
+	if bt.Name == "" {
+		return "", ErrMissingName
+	}
+
+	if bt.BootMode != BootModeReboot && bt.BootMode != BootModePowerOff {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedBootMode, bt.BootMode)
+	}
+
+	tpl, err := parse(bootWorkflowTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := tpl.Execute(buf, bt); err != nil {
+		return "", fmt.Errorf("rendering workflow template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// parse parses the workflow template text.
+func parse(text string) (*template.Template, error) {
+	tpl, err := template.New("workflow").
+		Funcs(template.FuncMap{"quote": quote}).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing workflow template: %w", err)
+	}
+
+	return tpl, nil
+}
+
+// Worker device is templated by Tinkerbell when the workflow is created, so it must be
+// escaped from rendering.
 const (
-	workflowTemplate = `
+	workflowHeaderTemplate = `
 version: "0.1"
-name: %s
+name: {{.Name}}
 global_timeout: 6000
 tasks:
-  - name: "%s"
-    worker: "{{.device_1}}"
+  - name: "{{.Name}}"
+    worker: "{{"{{.device_1}}"}}"
     volumes:
       - /dev:/dev
       - /dev/console:/dev/console
       - /lib/firmware:/lib/firmware:ro
     actions:
+{{- template "actions" .PreInstallActions}}
+{{- with .RAIDCommands}}
+      - name: "create-raid"
+        image: alpine:3.15
+        timeout: 600
+        command:
+          - /bin/sh
+          - -c
+          - |
+{{- range .}}
+            {{.}}
+{{- end}}
+{{- end}}
+{{- if .VerifiesImageWhileStreaming}}
       - name: "stream-image"
-        image: oci2disk:v1.0.0
+        image: alpine:3.15
         timeout: 360
+        command:
+          - /bin/sh
+          - -c
+          - |
+            set -o pipefail
+            mkfifo /tmp/image
+            sha256sum /tmp/image > /tmp/image.sha256 &
+            wget -q -O - "$IMG_URL" | tee /tmp/image | {{.DecompressCommand}} | dd of="$DEST_DISK" bs=4M conv=fsync
+            wait $!
+            if ! grep -q "^$IMG_SHA256 " /tmp/image.sha256; then
+              echo "image digest does not match $IMG_SHA256" >&2
+              size=$(blockdev --getsize64 "$DEST_DISK")
+              dd if=/dev/zero of="$DEST_DISK" bs=1M count=1 conv=fsync
+              dd if=/dev/zero of="$DEST_DISK" bs=1M count=1 seek=$((size / 1048576 - 1)) conv=fsync
+              exit 1
+            fi
         environment:
-          IMG_URL: %s
-          DEST_DISK: %s
-          COMPRESSED: true
-      - name: "add-tink-cloud-init-config"
-        image: writefile:v1.0.0
+          IMG_URL: {{.ImageURL}}
+          IMG_SHA256: {{.ImageSHA256}}
+          DEST_DISK: {{.ImageDestination}}
+{{- else}}
+      - name: "stream-image"
+        image: {{.ActionImage "oci2disk:v1.0.0"}}
+        timeout: 360
+        environment:
+          IMG_URL: {{.StreamedImageURL}}
+          DEST_DISK: {{.ImageDestination}}
+          COMPRESSED: {{.ImageCompressed}}
+{{- end}}
+{{- with .StorageCommands}}
+      - name: "configure-storage"
+        image: alpine:3.15
+        timeout: 600
+        command:
+          - /bin/sh
+          - -c
+          - |
+{{- range .}}
+            {{.}}
+{{- end}}
+{{- end}}
+`
+
+	workflowTemplate = `      - name: "add-tink-cloud-init-config"
+        image: {{.ActionImage "writefile:v1.0.0"}}
         timeout: 90
         environment:
-          DEST_DISK: %s
-          FS_TYPE: ext4
+          DEST_DISK: {{.DestPartition}}
+          FS_TYPE: {{.RootFilesystemType}}
           DEST_PATH: /etc/cloud/cloud.cfg.d/10_tinkerbell.cfg
           UID: 0
           GID: 0
@@ -87,23 +464,39 @@ tasks:
           CONTENTS: |
             datasource:
               Ec2:
-                metadata_urls: ["%s"]
+                metadata_urls: ["{{.MetadataURL}}"]
                 strict_id: false
+{{- if .DisableDefaultUser}}
+            users: []
+{{- else}}
+{{- with .User}}
             system_info:
               default_user:
-                name: tink
-                groups: [wheel, adm]
-                sudo: ["ALL=(ALL) NOPASSWD:ALL"]
+                name: {{quote .Name}}
+                groups: [{{range $i, $group := .Groups}}{{if $i}}, {{end}}{{quote $group}}{{end}}]
+{{- if .Sudo}}
+                sudo: [{{quote .Sudo}}]
+{{- else}}
+                sudo: false
+{{- end}}
                 shell: /bin/bash
+{{- with .SSHAuthorizedKeys}}
+                ssh_authorized_keys:
+{{- range .}}
+                  - {{quote .}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
             manage_etc_hosts: localhost
             warnings:
               dsid_missing_source: off
       - name: "add-tink-cloud-init-ds-config"
-        image: writefile:v1.0.0
+        image: {{.ActionImage "writefile:v1.0.0"}}
         timeout: 90
         environment:
-          DEST_DISK: %s
-          FS_TYPE: ext4
+          DEST_DISK: {{.DestPartition}}
+          FS_TYPE: {{.RootFilesystemType}}
           DEST_PATH: /etc/cloud/ds-identify.cfg
           UID: 0
           GID: 0
@@ -111,12 +504,109 @@ tasks:
           DIRMODE: 0700
           CONTENTS: |
             datasource: Ec2
+{{- template "actions" .PostInstallActions}}
+{{- template "boot" .}}
+`
+
+	ignitionWorkflowTemplate = `      - name: "add-tink-ignition-config"
+        image: {{.ActionImage "writefile:v1.0.0"}}
+        timeout: 90
+        environment:
+          DEST_DISK: {{.OEMPartition}}
+          FS_TYPE: ext4
+          DEST_PATH: /config.ign
+          UID: 0
+          GID: 0
+          MODE: 0600
+          DIRMODE: 0700
+          CONTENTS: |
+            {"ignition":{"version":"3.1.0","config":{"merge":[{"source":"{{.MetadataURL}}/2009-04-04/user-data"}]}}}
+{{- template "actions" .PostInstallActions}}
+{{- template "boot" .}}
+`
+
+	// Machines booting the installed OS by restarting are restarted by the boot workflow instead.
+	bootTemplate = `
+{{- define "boot"}}
+{{- if eq .EffectiveBootMode "kexec"}}
       - name: "kexec-image"
-        image: kexec:v1.0.0
+        image: {{.ActionImage "kexec:v1.0.0"}}
         timeout: 90
         pid: host
         environment:
-          BLOCK_DEVICE: %s
-          FS_TYPE: ext4
+          BLOCK_DEVICE: {{.DestPartition}}
+          FS_TYPE: {{.RootFilesystemType}}
+{{- end}}
+{{- end}}`
+
+	// Boot workflow runs in the installation environment left running by the provisioning workflow. The
+	// machine goes down before the worker reports the action finished, so the workflow never succeeds.
+	bootWorkflowTemplate = `
+version: "0.1"
+name: {{.Name}}
+global_timeout: 300
+tasks:
+  - name: "{{.Name}}"
+    worker: "{{"{{.device_1}}"}}"
+    actions:
+{{- if eq .BootMode "poweroff"}}
+      - name: "poweroff-image"
+        image: alpine:3.15
+        timeout: 90
+        pid: host
+        command:
+          - /bin/sh
+          - -c
+          - sync && echo o > /proc/sysrq-trigger
+{{- else}}
+      - name: "reboot-image"
+        image: alpine:3.15
+        timeout: 90
+        pid: host
+        command:
+          - /bin/sh
+          - -c
+          - sync && echo b > /proc/sysrq-trigger
+{{- end}}
+`
+
+	// Power cycle workflow runs on the worker with access to the BMC of the machine. The machine is
+	// shut down gracefully first and powered off only if it does not shut down within five minutes.
+	powerCycleWorkflowTemplate = `
+version: "0.1"
+name: {{.Name}}
+global_timeout: 900
+tasks:
+  - name: "{{.Name}}"
+    worker: "{{"{{.device_1}}"}}"
+    actions:
+      - name: "power-cycle"
+        image: {{quote .Image}}
+        timeout: 600
+        environment:
+          BMC_ADDRESS: {{quote .BMCAddress}}
+          BMC_USERNAME: {{quote .Username}}
+          IPMI_PASSWORD: {{quote .Password}}
+        command:
+          - /bin/sh
+          - -c
+          - |
+            set -eu
+            ipmi() { ipmitool -I lanplus -H "$BMC_ADDRESS" -U "$BMC_USERNAME" -E "$@"; }
+            powered_off() {
+              for i in $(seq "$1"); do
+                ipmi chassis power status | grep -q "is off" && return 0
+                sleep 5
+              done
+              return 1
+            }
+{{- if .PXE}}
+            ipmi chassis bootdev pxe
+{{- end}}
+            if ! ipmi chassis power status | grep -q "is off"; then
+              ipmi chassis power soft
+              powered_off 60 || { ipmi chassis power off; powered_off 12; }
+            fi
+            ipmi chassis power on
 `
 )
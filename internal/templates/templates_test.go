@@ -17,7 +17,10 @@ limitations under the License.
 package templates_test
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"text/template"
 
 	. "github.com/onsi/gomega"
 	"sigs.k8s.io/yaml"
@@ -65,6 +68,426 @@ func Test_Cloud_config_template(t *testing.T) {
 			mutateF: func(wt *templates.WorkflowTemplate) {},
 		},
 
+		"requires_supported_BootstrapFormat": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.BootstrapFormat = "foo"
+			},
+			expectError:   true,
+			expectedError: templates.ErrUnsupportedBootstrapFormat,
+		},
+
+		"renders_Ignition_config_into_OEM_partition_with_Ignition_bootstrap_format": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.BootstrapFormat = templates.BootstrapFormatIgnition
+				wt.OEMPartition = "/dev/sda6"
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(yaml.Unmarshal([]byte(renderResult), &map[string]interface{}{})).To(Succeed())
+				g.Expect(renderResult).To(ContainSubstring("DEST_DISK: /dev/sda6"))
+				g.Expect(renderResult).To(ContainSubstring("DEST_PATH: /config.ign"))
+				g.Expect(renderResult).To(ContainSubstring(wt.MetadataURL + "/2009-04-04/user-data"))
+				g.Expect(renderResult).NotTo(ContainSubstring("cloud-init"))
+			},
+		},
+
+		"verifies_image_digest_while_streaming_image": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.ImageSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+				wt.ImageCompression = "none"
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(yaml.Unmarshal([]byte(renderResult), &map[string]interface{}{})).To(Succeed())
+				g.Expect(renderResult).To(ContainSubstring("IMG_SHA256: " + wt.ImageSHA256))
+				g.Expect(renderResult).To(ContainSubstring(`tee /tmp/image | cat | dd of="$DEST_DISK"`))
+				g.Expect(strings.Count(renderResult, "$IMG_URL")).To(Equal(1), "Expected image to be downloaded once")
+				g.Expect(renderResult).To(ContainSubstring("seek=$((size / 1048576 - 1))"),
+					"Expected backup partition table to be wiped on digest mismatch")
+				g.Expect(renderResult).NotTo(ContainSubstring("oci2disk"))
+			},
+		},
+
+		"pins_OCI_image_to_digest": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.ImageURL = "registry.local:5000/images/ubuntu-2004:v1.20.11.gz"
+				wt.ImageSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring("oci2disk"))
+				g.Expect(renderResult).To(ContainSubstring("IMG_URL: registry.local:5000/images/ubuntu-2004@sha256:" +
+					wt.ImageSHA256))
+				g.Expect(renderResult).NotTo(ContainSubstring("sha256sum"))
+			},
+		},
+
+		"decompresses_verified_image": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.ImageSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+				wt.ImageCompression = "xz"
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring(`tee /tmp/image | unxz -c | dd of="$DEST_DISK"`))
+			},
+		},
+
+		"does_not_verify_image_digest_by_default": {
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).NotTo(ContainSubstring("sha256sum"))
+				g.Expect(renderResult).To(ContainSubstring("oci2disk"))
+				g.Expect(renderResult).To(ContainSubstring("COMPRESSED: true"))
+			},
+		},
+
+		"uses_action_images_for_hardware_architecture": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.Arch = "arm64"
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring("image: oci2disk:v1.0.0-arm64"))
+				g.Expect(renderResult).To(ContainSubstring("image: kexec:v1.0.0-arm64"))
+			},
+		},
+
+		"uses_multi_arch_images_as_they_are_for_hardware_architecture": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.Arch = "arm64"
+				wt.ImageSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+				wt.DestDisk = "/dev/md0"
+				wt.DestPartition = "/dev/md0p1"
+				wt.RAIDLevel = 1
+				wt.RAIDDevices = []string{"/dev/sda", "/dev/sdb"}
+				wt.Partitions = []templates.Partition{
+					{Label: "containerd", Filesystem: "xfs", MountPoint: "/var/lib/containerd"},
+				}
+				wt.BootMode = templates.BootModePowerOff
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				workflow := struct {
+					Tasks []struct {
+						Actions []struct {
+							Name  string `json:"name"`
+							Image string `json:"image"`
+						} `json:"actions"`
+					} `json:"tasks"`
+				}{}
+				g.Expect(yaml.Unmarshal([]byte(renderResult), &workflow)).To(Succeed())
+
+				images := map[string]string{}
+				for _, action := range workflow.Tasks[0].Actions {
+					images[action.Name] = action.Image
+				}
+
+				g.Expect(images).To(Equal(map[string]string{
+					"create-raid":                   "alpine:3.15",
+					"stream-image":                  "alpine:3.15",
+					"configure-storage":             "alpine:3.15",
+					"add-tink-cloud-init-config":    "writefile:v1.0.0-arm64",
+					"add-tink-cloud-init-ds-config": "writefile:v1.0.0-arm64",
+				}))
+			},
+		},
+
+		"uses_default_action_images_for_default_architecture": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.Arch = templates.DefaultArch
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring("image: oci2disk:v1.0.0\n"))
+			},
+		},
+
+		"writes_image_to_disk_by_default": {
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring("DEST_DISK: /dev/sda\n"))
+			},
+		},
+
+		"writes_image_to_root_partition_when_preserving_data_partitions": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.PreserveDataPartitions = true
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).NotTo(ContainSubstring("DEST_DISK: /dev/sda\n"))
+				g.Expect(renderResult).To(ContainSubstring("DEST_DISK: /dev/sda1\n"))
+			},
+		},
+
+		"does_not_configure_storage_by_default": {
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).NotTo(ContainSubstring("configure-storage"))
+				g.Expect(renderResult).To(ContainSubstring("FS_TYPE: ext4"))
+			},
+		},
+
+		"uses_filesystem_of_root_partition": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.DestPartition = "/dev/sda2"
+				wt.RootFilesystem = "xfs"
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring("BLOCK_DEVICE: /dev/sda2"))
+				g.Expect(renderResult).To(ContainSubstring("FS_TYPE: xfs"))
+				g.Expect(renderResult).NotTo(ContainSubstring("FS_TYPE: ext4"))
+			},
+		},
+
+		"creates_partitions_volume_groups_and_filesystems": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.Partitions = []templates.Partition{
+					{Label: "containerd", SizeMiB: 102400, Filesystem: "xfs", MountPoint: "/var/lib/containerd"},
+					{Label: "lvm", VolumeGroup: "data"},
+				}
+				wt.VolumeGroups = []templates.VolumeGroup{
+					{
+						Name: "data",
+						LogicalVolumes: []templates.LogicalVolume{
+							{Name: "kubelet", Filesystem: "ext4", MountPoint: "/var/lib/kubelet"},
+						},
+					},
+				}
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(yaml.Unmarshal([]byte(renderResult), &map[string]interface{}{})).To(Succeed())
+				g.Expect(renderResult).To(ContainSubstring(`name: "configure-storage"`))
+				g.Expect(renderResult).To(ContainSubstring("sgdisk -n 0:0:+102400M -c 0:containerd /dev/sda\n"))
+				g.Expect(renderResult).To(ContainSubstring("sgdisk -n 0:0:0 -c 0:lvm /dev/sda\n"))
+				g.Expect(renderResult).To(ContainSubstring(`mkfs.xfs -f "$(part containerd)"`))
+				g.Expect(renderResult).To(ContainSubstring(`vgcreate -y data "$(part lvm)"`))
+				g.Expect(renderResult).To(ContainSubstring("lvcreate -y -n kubelet -l 100%FREE data"))
+				g.Expect(renderResult).To(ContainSubstring("mkfs.ext4 -F /dev/data/kubelet"))
+				g.Expect(renderResult).To(ContainSubstring("mount -t ext4 /dev/sda1 /mnt"))
+				g.Expect(renderResult).To(ContainSubstring(
+					`echo "UUID=$(blkid -s UUID -o value /dev/data/kubelet) /var/lib/kubelet ext4 defaults 0 2"`))
+			},
+		},
+
+		"only_registers_mounts_when_preserving_data_partitions": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.PreserveDataPartitions = true
+				wt.Partitions = []templates.Partition{
+					{Label: "containerd", Filesystem: "xfs", MountPoint: "/var/lib/containerd"},
+				}
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).NotTo(ContainSubstring("sgdisk -n"))
+				g.Expect(renderResult).NotTo(ContainSubstring("mkfs."))
+				g.Expect(renderResult).To(ContainSubstring("/var/lib/containerd xfs defaults 0 2"))
+			},
+		},
+
+		"creates_RAID_array_before_writing_image": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.DestDisk = "/dev/md0"
+				wt.DestPartition = "/dev/md0p1"
+				wt.RAIDLevel = 1
+				wt.RAIDDevices = []string{"/dev/sda", "/dev/sdb"}
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(yaml.Unmarshal([]byte(renderResult), &map[string]interface{}{})).To(Succeed())
+				g.Expect(renderResult).To(ContainSubstring(`name: "create-raid"`))
+				g.Expect(renderResult).To(ContainSubstring(
+					"mdadm --create /dev/md0 --run --metadata=1.0 --level=1 --raid-devices=2 /dev/sda /dev/sdb"))
+				g.Expect(renderResult).To(ContainSubstring("DEST_DISK: /dev/md0\n"))
+			},
+		},
+
+		"assembles_RAID_array_when_preserving_data_partitions": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.DestDisk = "/dev/md0"
+				wt.PreserveDataPartitions = true
+				wt.RAIDLevel = 1
+				wt.RAIDDevices = []string{"/dev/sda", "/dev/sdb"}
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring("mdadm --assemble --run /dev/md0 /dev/sda /dev/sdb"))
+				g.Expect(renderResult).NotTo(ContainSubstring("mdadm --create"))
+			},
+		},
+
+		"adds_user_supplied_actions_around_default_actions": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.PreInstallActions = []templates.Action{
+					{
+						Name:        "update-firmware",
+						Image:       "quay.io/example/firmware:v1",
+						Environment: map[string]string{"PASSWORD": "p@ss: \"word\"\n{{.x}}"},
+						Volumes:     []string{"/dev:/dev"},
+					},
+				}
+				wt.PostInstallActions = []templates.Action{
+					{Name: "register", Image: "quay.io/example/register:v1", Timeout: 30},
+				}
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				workflow := struct {
+					Tasks []struct {
+						Actions []struct {
+							Name        string            `json:"name"`
+							Image       string            `json:"image"`
+							Timeout     int64             `json:"timeout"`
+							Environment map[string]string `json:"environment"`
+							Volumes     []string          `json:"volumes"`
+						} `json:"actions"`
+					} `json:"tasks"`
+				}{}
+
+				// Tinkerbell templates the workflow when it is created.
+				tpl, err := template.New("workflow").Parse(renderResult)
+				g.Expect(err).NotTo(HaveOccurred())
+
+				buf := &bytes.Buffer{}
+				g.Expect(tpl.Execute(buf, map[string]string{"device_1": "00:00:00:00:00:01"})).To(Succeed())
+
+				g.Expect(yaml.Unmarshal(buf.Bytes(), &workflow)).To(Succeed())
+				g.Expect(workflow.Tasks).To(HaveLen(1))
+
+				actions := workflow.Tasks[0].Actions
+				names := []string{}
+
+				for _, action := range actions {
+					names = append(names, action.Name)
+				}
+
+				g.Expect(names).To(Equal([]string{
+					"update-firmware",
+					"stream-image",
+					"add-tink-cloud-init-config",
+					"add-tink-cloud-init-ds-config",
+					"register",
+					"kexec-image",
+				}))
+
+				g.Expect(actions[0].Image).To(Equal("quay.io/example/firmware:v1"))
+				g.Expect(actions[0].Timeout).To(Equal(int64(templates.DefaultActionTimeout)))
+				g.Expect(actions[0].Environment).To(Equal(map[string]string{"PASSWORD": "p@ss: \"word\"\n{{.x}}"}))
+				g.Expect(actions[0].Volumes).To(Equal([]string{"/dev:/dev"}))
+				g.Expect(actions[4].Timeout).To(Equal(int64(30)))
+			},
+		},
+
+		"kexecs_into_installed_OS_by_default": {
+			mutateF: func(wt *templates.WorkflowTemplate) {},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring(`name: "kexec-image"`))
+				g.Expect(renderResult).NotTo(ContainSubstring(`name: "reboot-image"`))
+			},
+		},
+
+		"leaves_reboot_to_boot_workflow_with_reboot_boot_mode": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.BootMode = templates.BootModeReboot
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).NotTo(ContainSubstring(`name: "reboot-image"`))
+				g.Expect(renderResult).NotTo(ContainSubstring(`name: "kexec-image"`))
+				g.Expect(renderResult).NotTo(ContainSubstring("sleep"))
+			},
+		},
+
+		"leaves_power_off_to_boot_workflow_with_poweroff_boot_mode": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.BootMode = templates.BootModePowerOff
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(yaml.Unmarshal([]byte(renderResult), &map[string]interface{}{})).To(Succeed())
+				g.Expect(renderResult).NotTo(ContainSubstring(`name: "poweroff-image"`))
+				g.Expect(renderResult).NotTo(ContainSubstring(`name: "kexec-image"`))
+			},
+		},
+
+		"does_not_kexec_with_Ignition_bootstrap_format_when_kexec_is_requested": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.BootstrapFormat = templates.BootstrapFormatIgnition
+				wt.BootMode = templates.BootModeKexec
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(wt.EffectiveBootMode()).To(Equal(templates.BootModeReboot))
+				g.Expect(renderResult).NotTo(ContainSubstring(`name: "kexec-image"`))
+			},
+		},
+
+		"creates_tink_user_with_passwordless_sudo_by_default": {
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring(`name: "tink"`))
+				g.Expect(renderResult).To(ContainSubstring(`sudo: ["ALL=(ALL) NOPASSWD:ALL"]`))
+			},
+		},
+
+		"creates_configured_default_user_with_SSH_authorized_keys": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.DefaultUser = &templates.User{
+					Name:              "core",
+					Groups:            []string{"docker"},
+					SSHAuthorizedKeys: []string{"ssh-ed25519 AAAA foo@bar"},
+				}
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(yaml.Unmarshal([]byte(renderResult), &map[string]interface{}{})).To(Succeed())
+				g.Expect(renderResult).To(ContainSubstring(`name: "core"`))
+				g.Expect(renderResult).To(ContainSubstring(`groups: ["docker"]`))
+				g.Expect(renderResult).To(ContainSubstring("sudo: false"))
+				g.Expect(renderResult).To(ContainSubstring(`- "ssh-ed25519 AAAA foo@bar"`))
+				g.Expect(renderResult).NotTo(ContainSubstring(`name: "tink"`))
+			},
+		},
+
+		"does_not_create_default_user_when_disabled": {
+			mutateF: func(wt *templates.WorkflowTemplate) {
+				wt.DisableDefaultUser = true
+			},
+			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
+				g := NewWithT(t)
+
+				g.Expect(renderResult).To(ContainSubstring("users: []"))
+				g.Expect(renderResult).NotTo(ContainSubstring("default_user:"))
+			},
+		},
+
 		"rendered_output_should_be_valid_YAML": {
 			validateF: func(t *testing.T, wt *templates.WorkflowTemplate, renderResult string) { //nolint:thelper
 				g := NewWithT(t)
@@ -108,3 +531,226 @@ func Test_Cloud_config_template(t *testing.T) {
 		})
 	}
 }
+
+func Test_Template_override(t *testing.T) {
+	t.Parallel()
+
+	t.Run("renders_workflow_template_data", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		wt := validWorkflowTemplate()
+		wt.Disks = []string{"/dev/sda", "/dev/sdb"}
+
+		result, err := wt.RenderOverride(`name: {{.Name}}
+worker: "{{"{{.device_1}}"}}"
+image: {{quote .ImageURL}}
+disks:{{range .Disks}}
+  - {{.}}{{end}}
+`)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(`name: foo
+worker: "{{.device_1}}"
+image: "http://foo.bar.baz/do/it"
+disks:
+  - /dev/sda
+  - /dev/sdb
+`))
+	})
+
+	t.Run("passes_tinkerbell_fields_through", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		result, err := validWorkflowTemplate().RenderOverride(`name: {{.Name}}
+worker: "{{.device_1}}"
+{{- if .ImageURL}}
+hardware: {{.hardware.metadata.instance.id}}
+{{- end}}
+`)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(`name: foo
+worker: "{{.device_1}}"
+hardware: {{.hardware.metadata.instance.id}}
+`))
+	})
+
+	t.Run("fails_on_invalid_template", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		_, err := validWorkflowTemplate().RenderOverride("name: {{.Name")
+		g.Expect(err).To(MatchError(ContainSubstring("parsing template override")))
+	})
+
+	t.Run("fails_on_unknown_data", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		_, err := validWorkflowTemplate().RenderOverride("name: {{.Unknown}}")
+		g.Expect(err).To(MatchError(ContainSubstring("rendering template override")))
+	})
+}
+
+type powerCycleAction struct {
+	Name        string            `json:"name"`
+	Image       string            `json:"image"`
+	Environment map[string]string `json:"environment"`
+	Command     []string          `json:"command"`
+}
+
+//nolint:funlen
+func Test_Power_cycle_template(t *testing.T) {
+	t.Parallel()
+
+	validTemplate := func() templates.PowerCycleWorkflowTemplate {
+		return templates.PowerCycleWorkflowTemplate{
+			Name:       "foo-power",
+			Image:      "registry.local/ipmitool:v1",
+			BMCAddress: "10.0.0.100",
+			Username:   "admin",
+			Password:   `p"a{{ss}}`,
+		}
+	}
+
+	parse := func(t *testing.T, result string) []powerCycleAction {
+		t.Helper()
+		g := NewWithT(t)
+
+		workflow := struct {
+			Name  string `json:"name"`
+			Tasks []struct {
+				Worker  string             `json:"worker"`
+				Actions []powerCycleAction `json:"actions"`
+			} `json:"tasks"`
+		}{}
+		g.Expect(yaml.Unmarshal([]byte(result), &workflow)).To(Succeed())
+
+		g.Expect(workflow.Name).To(Equal("foo-power"))
+		g.Expect(workflow.Tasks).To(HaveLen(1))
+		g.Expect(workflow.Tasks[0].Worker).To(Equal("{{.device_1}}"))
+
+		return workflow.Tasks[0].Actions
+	}
+
+	t.Run("requires_non_empty_Name_Image_and_BMCAddress", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		pt := validTemplate()
+		pt.Name = ""
+		_, err := pt.Render()
+		g.Expect(err).To(MatchError(templates.ErrMissingName))
+
+		pt = validTemplate()
+		pt.Image = ""
+		_, err = pt.Render()
+		g.Expect(err).To(MatchError(templates.ErrMissingImage))
+
+		pt = validTemplate()
+		pt.BMCAddress = ""
+		_, err = pt.Render()
+		g.Expect(err).To(MatchError(templates.ErrMissingBMCAddress))
+	})
+
+	t.Run("renders_single_power_cycle_action", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		result, err := validTemplate().Render()
+		g.Expect(err).NotTo(HaveOccurred())
+
+		actions := parse(t, result)
+		g.Expect(actions).To(HaveLen(1), "Expected only the power cycle action")
+
+		action := actions[0]
+		g.Expect(action.Name).To(Equal(templates.PowerCycleActionName))
+		g.Expect(action.Image).To(Equal("registry.local/ipmitool:v1"))
+		g.Expect(action.Environment).To(Equal(map[string]string{
+			"BMC_ADDRESS":   "10.0.0.100",
+			"BMC_USERNAME":  "admin",
+			"IPMI_PASSWORD": "p\"a{{`{{`}}ss}}",
+		}), "Expected credentials to be quoted and escaped from Tinkerbell templating")
+		g.Expect(action.Command).To(ContainElement(ContainSubstring("ipmi chassis power soft")))
+		g.Expect(action.Command).To(ContainElement(ContainSubstring("ipmi chassis power on")))
+		g.Expect(action.Command).NotTo(ContainElement(ContainSubstring("bootdev pxe")),
+			"Expected machine to boot from the disk")
+
+		g.Expect(result).NotTo(ContainSubstring("stream-image"))
+		g.Expect(result).NotTo(ContainSubstring("IMG_URL"))
+	})
+
+	t.Run("boots_from_network_with_PXE", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		pt := validTemplate()
+		pt.PXE = true
+
+		result, err := pt.Render()
+		g.Expect(err).NotTo(HaveOccurred())
+
+		actions := parse(t, result)
+		g.Expect(actions).To(HaveLen(1))
+		g.Expect(actions[0].Command).To(ContainElement(ContainSubstring("ipmi chassis bootdev pxe")))
+	})
+}
+
+func Test_Boot_template(t *testing.T) {
+	t.Parallel()
+
+	render := func(t *testing.T, mode templates.BootMode) []powerCycleAction {
+		t.Helper()
+		g := NewWithT(t)
+
+		result, err := templates.BootWorkflowTemplate{Name: "foo-boot", BootMode: mode}.Render()
+		g.Expect(err).NotTo(HaveOccurred())
+
+		workflow := struct {
+			Name  string `json:"name"`
+			Tasks []struct {
+				Worker  string             `json:"worker"`
+				Actions []powerCycleAction `json:"actions"`
+			} `json:"tasks"`
+		}{}
+		g.Expect(yaml.Unmarshal([]byte(result), &workflow)).To(Succeed())
+
+		g.Expect(workflow.Name).To(Equal("foo-boot"))
+		g.Expect(workflow.Tasks).To(HaveLen(1))
+		g.Expect(workflow.Tasks[0].Worker).To(Equal("{{.device_1}}"))
+
+		return workflow.Tasks[0].Actions
+	}
+
+	t.Run("requires_non_empty_Name_and_restarting_BootMode", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		_, err := templates.BootWorkflowTemplate{BootMode: templates.BootModeReboot}.Render()
+		g.Expect(err).To(MatchError(templates.ErrMissingName))
+
+		_, err = templates.BootWorkflowTemplate{Name: "foo-boot", BootMode: templates.BootModeKexec}.Render()
+		g.Expect(err).To(MatchError(templates.ErrUnsupportedBootMode))
+	})
+
+	t.Run("reboots_immediately_with_reboot_boot_mode", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		actions := render(t, templates.BootModeReboot)
+		g.Expect(actions).To(HaveLen(1))
+		g.Expect(actions[0].Name).To(Equal(templates.RebootActionName))
+		g.Expect(actions[0].Command).To(ContainElement("sync && echo b > /proc/sysrq-trigger"),
+			"Expected reboot not to be delayed")
+	})
+
+	t.Run("powers_off_immediately_with_poweroff_boot_mode", func(t *testing.T) {
+		t.Parallel()
+		g := NewWithT(t)
+
+		actions := render(t, templates.BootModePowerOff)
+		g.Expect(actions).To(HaveLen(1))
+		g.Expect(actions[0].Name).To(Equal(templates.PowerOffActionName))
+		g.Expect(actions[0].Command).To(ContainElement("sync && echo o > /proc/sysrq-trigger"))
+	})
+}
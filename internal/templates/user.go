@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templates
+
+const (
+	// DefaultUserName is the name of the default user of the installed OS when not specified.
+	DefaultUserName = "tink"
+
+	// SudoNoPassword is the sudo rule allowing the user to run any command as root without a password.
+	SudoNoPassword = "ALL=(ALL) NOPASSWD:ALL"
+
+	// SudoPassword is the sudo rule allowing the user to run any command as root after entering the
+	// user password.
+	SudoPassword = "ALL=(ALL) ALL"
+)
+
+// User is the default user of the installed OS created by cloud-init.
+type User struct {
+	Name   string
+	Groups []string
+
+	// Sudo is the sudo rule of the user. If empty, the user cannot use sudo.
+	Sudo string
+
+	// SSHAuthorizedKeys are SSH public keys authorized to log in as the user.
+	SSHAuthorizedKeys []string
+}
+
+// DefaultUser returns the default user of the installed OS, which is used when not configured.
+func DefaultUser() User {
+	return User{
+		Name:   DefaultUserName,
+		Groups: []string{"wheel", "adm"},
+		Sudo:   SudoNoPassword,
+	}
+}
+
+// User returns the default user of the installed OS.
+func (wt WorkflowTemplate) User() User {
+	if wt.DefaultUser == nil {
+		return DefaultUser()
+	}
+
+	return *wt.DefaultUser
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing configures OpenTelemetry tracing for CAPT. Spans are started for each
+// reconciliation and for each Tinkerbell API call, so slow provisioning can be followed
+// from the controller into the Tinkerbell server.
+//
+// Tracing is disabled unless an OTLP endpoint is configured. When disabled, all spans are
+// dropped by the global no-op tracer provider.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// ServiceName is the name under which CAPT spans are reported.
+	ServiceName = "cluster-api-provider-tinkerbell"
+
+	instrumentationName = "github.com/tinkerbell/cluster-api-provider-tinkerbell"
+)
+
+// Options configures exporting of spans.
+type Options struct {
+	// Endpoint is the address of OTLP gRPC collector. If empty, tracing is disabled.
+	Endpoint string
+
+	// Insecure disables TLS for connection with the collector.
+	Insecure bool
+
+	// SamplingRatio is the fraction of traces which are sampled, between 0 and 1.
+	SamplingRatio float64
+}
+
+// Setup configures the global tracer provider to export spans to the OTLP collector. Returned
+// function flushes remaining spans and should be called before the process exits.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(opts.Endpoint)}
+	if opts.Insecure {
+		clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP trace exporter: %w", err)
+	}
+
+	provider := newTracerProvider(sdktrace.NewBatchSpanProcessor(exporter), opts.SamplingRatio)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// NewInMemoryProvider returns a tracer provider synchronously recording all spans into returned
+// exporter. It is intended to be used in tests.
+func NewInMemoryProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+
+	return newTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), 1), exporter
+}
+
+func newTracerProvider(processor sdktrace.SpanProcessor, samplingRatio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(ServiceName),
+		)),
+	)
+}
+
+// DialOptions returns gRPC dial options creating child spans for each call made on the connection.
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+	}
+}
+
+// StartReconcile starts a span for reconciliation of given object by given controller. Returned
+// context carries the span and a logger with trace and span IDs attached, so log lines can be
+// correlated with the trace.
+func StartReconcile(ctx context.Context, controller string, req ctrl.Request) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, controller+".Reconcile",
+		trace.WithAttributes(
+			attribute.String("controller", controller),
+			attribute.String("namespace", req.Namespace),
+			attribute.String("name", req.Name),
+		),
+	)
+
+	spanContext := span.SpanContext()
+	if !spanContext.IsValid() {
+		return ctx, span
+	}
+
+	log := ctrl.LoggerFrom(ctx).WithValues(
+		"traceID", spanContext.TraceID().String(),
+		"spanID", spanContext.SpanID().String(),
+	)
+
+	return ctrl.LoggerInto(ctx, log), span
+}
+
+// End ends given span, marking it as failed if reconciliation returned an error.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
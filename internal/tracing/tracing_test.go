@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
+)
+
+var errReconcile = errors.New("reconcile failed")
+
+func healthClient(t *testing.T) healthpb.HealthClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024) //nolint:gomnd
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(server.Stop)
+
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+	}, tracing.DialOptions()...)
+
+	conn, err := grpc.Dial("bufnet", dialOpts...)
+	if err != nil {
+		t.Fatalf("dialing test server: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return healthpb.NewHealthClient(conn)
+}
+
+// Global tracer provider can only be configured once, so all cases share it.
+//
+//nolint:paralleltest
+func Test_Tracing_reconciliation(t *testing.T) {
+	provider, exporter := tracing.NewInMemoryProvider()
+	otel.SetTracerProvider(provider)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "machine-0"}}
+
+	t.Run("records_span_for_each_reconciliation", func(t *testing.T) {
+		g := NewWithT(t)
+		exporter.Reset()
+
+		_, span := tracing.StartReconcile(context.Background(), "tinkerbellmachine", req)
+		tracing.End(span, nil)
+
+		spans := exporter.GetSpans()
+		g.Expect(spans).To(HaveLen(1))
+		g.Expect(spans[0].Name).To(Equal("tinkerbellmachine.Reconcile"))
+		g.Expect(spans[0].Attributes).To(ContainElements(
+			attribute.String("controller", "tinkerbellmachine"),
+			attribute.String("namespace", "default"),
+			attribute.String("name", "machine-0"),
+		))
+		g.Expect(spans[0].Status.Code).To(Equal(codes.Unset))
+	})
+
+	t.Run("marks_failed_reconciliation", func(t *testing.T) {
+		g := NewWithT(t)
+		exporter.Reset()
+
+		_, span := tracing.StartReconcile(context.Background(), "tinkerbellmachine", req)
+		tracing.End(span, errReconcile)
+
+		spans := exporter.GetSpans()
+		g.Expect(spans).To(HaveLen(1))
+		g.Expect(spans[0].Status.Code).To(Equal(codes.Error))
+		g.Expect(spans[0].Status.Description).To(Equal(errReconcile.Error()))
+	})
+
+	t.Run("records_grpc_calls_as_child_spans", func(t *testing.T) {
+		g := NewWithT(t)
+		client := healthClient(t)
+		exporter.Reset()
+
+		ctx, span := tracing.StartReconcile(context.Background(), "tink-hardware", req)
+
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		tracing.End(span, nil)
+
+		spans := exporter.GetSpans()
+		g.Expect(spans).To(HaveLen(2)) //nolint:gomnd
+		g.Expect(spans[0].Name).To(Equal("grpc.health.v1.Health/Check"))
+		g.Expect(spans[0].Parent.SpanID()).To(Equal(span.SpanContext().SpanID()))
+		g.Expect(spans[0].SpanContext.TraceID()).To(Equal(span.SpanContext().TraceID()))
+	})
+}
@@ -24,7 +24,10 @@ import (
 	"time"
 
 	"github.com/spf13/pflag"
-	tinkclient "github.com/tinkerbell/tink/client"
+	"github.com/tinkerbell/tink/protos/hardware"
+	"github.com/tinkerbell/tink/protos/template"
+	"github.com/tinkerbell/tink/protos/workflow"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	cgrecord "k8s.io/client-go/tools/record"
@@ -32,12 +35,18 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	infrastructurev1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/api/v1beta1"
 	"github.com/tinkerbell/cluster-api-provider-tinkerbell/controllers"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/hosts"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/images"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
 	tinkv1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 	"github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/client"
 	tinkhardware "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/controllers/hardware"
@@ -59,6 +68,7 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 	_ = infrastructurev1.AddToScheme(scheme)
 	_ = clusterv1.AddToScheme(scheme)
+	_ = expv1.AddToScheme(scheme)
 	_ = tinkv1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
@@ -77,8 +87,14 @@ var (
 	tinkerbellMachineConcurrency  int
 	tinkerbellHardwareConcurrency int
 	tinkerbellTemplateConcurrency int
+	hardwareMetricsLabelKeys      []string
+	hardwareQuarantineThreshold   int
+	evictFromMaintenance          bool
+	maintenanceGracePeriod        time.Duration
+	enableMachinePool             bool
 	tinkerbellWorkflowConcurrency int
 	webhookPort                   int
+	tracingOptions                tracing.Options
 	syncPeriod                    time.Duration
 	leaderElectionLeaseDuration   time.Duration
 	leaderElectionRenewDeadline   time.Duration
@@ -179,6 +195,36 @@ func initFlags(fs *pflag.FlagSet) { //nolint:funlen
 		"Number of Tinkerbell Workflow resources to process simultaneously",
 	)
 
+	fs.StringSliceVar(&hardwareMetricsLabelKeys,
+		"hardware-metrics-label-keys",
+		nil,
+		"Hardware label keys used for breaking down hardware pool metrics by label value (e.g. rack,zone)",
+	)
+
+	fs.IntVar(&hardwareQuarantineThreshold,
+		"hardware-quarantine-threshold",
+		0,
+		"Number of failed provisioning workflows after which Hardware is quarantined. Quarantine is disabled if 0.",
+	)
+
+	fs.BoolVar(&evictFromMaintenance,
+		"hardware-maintenance-evict-machines",
+		false,
+		"Delete Machines running on Hardware annotated for maintenance, so they are replaced on other Hardware",
+	)
+
+	fs.DurationVar(&maintenanceGracePeriod,
+		"hardware-maintenance-grace-period",
+		10*time.Minute, //nolint:gomnd
+		"Time after Hardware is annotated for maintenance before Machines running on it are deleted (e.g. 1h)",
+	)
+
+	fs.BoolVar(&enableMachinePool,
+		"machine-pool",
+		false,
+		"Enable TinkerbellMachinePools for MachinePools. Requires the MachinePool feature of Cluster API.",
+	)
+
 	fs.DurationVar(&syncPeriod,
 		"sync-period",
 		10*time.Minute, //nolint:gomnd
@@ -197,6 +243,24 @@ func initFlags(fs *pflag.FlagSet) { //nolint:funlen
 		"Webhook Server Certificate Directory, is the directory that contains the server key and certificate",
 	)
 
+	fs.StringVar(&tracingOptions.Endpoint,
+		"otlp-endpoint",
+		"",
+		"Address of the OTLP gRPC collector receiving traces (e.g. localhost:4317). If unspecified, tracing is disabled.",
+	)
+
+	fs.BoolVar(&tracingOptions.Insecure,
+		"otlp-insecure",
+		false,
+		"Disable TLS for connection with the OTLP collector",
+	)
+
+	fs.Float64Var(&tracingOptions.SamplingRatio,
+		"trace-sampling-ratio",
+		1,
+		"Fraction of reconciliations which are traced, between 0 and 1",
+	)
+
 	fs.StringVar(&healthAddr,
 		"health-addr",
 		":9440",
@@ -216,18 +280,34 @@ func addHealthChecks(mgr ctrl.Manager) error {
 	return nil
 }
 
+func setupMetrics(mgr ctrl.Manager) error {
+	collector := controllers.NewHardwarePoolCollector(
+		mgr.GetClient(),
+		ctrl.Log.WithName("metrics"),
+		hardwareMetricsLabelKeys,
+	)
+
+	if err := ctrlmetrics.Registry.Register(collector); err != nil {
+		return fmt.Errorf("unable to register hardware pool collector: %w", err)
+	}
+
+	return nil
+}
+
 func setupTinkShimControllers(ctx context.Context, mgr ctrl.Manager) error {
-	if err := tinkclient.Setup(); err != nil {
+	conn, err := client.NewClientConn(ctx, tracing.DialOptions()...)
+	if err != nil {
 		return fmt.Errorf("unable to create tinkerbell client: %w", err)
 	}
 
-	hwClient := client.NewHardwareClient(tinkclient.HardwareClient)
-	templateClient := client.NewTemplateClient(tinkclient.TemplateClient)
-	workflowClient := client.NewWorkflowClient(tinkclient.WorkflowClient, hwClient)
+	hwClient := client.NewHardwareClient(hardware.NewHardwareServiceClient(conn))
+	templateClient := client.NewTemplateClient(template.NewTemplateServiceClient(conn))
+	workflowClient := client.NewWorkflowClient(workflow.NewWorkflowServiceClient(conn), hwClient)
 
 	if err := (&tinkhardware.Reconciler{
 		Client:         mgr.GetClient(),
 		HardwareClient: hwClient,
+		Recorder:       mgr.GetEventRecorderFor("tink-hardware-controller"),
 	}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: tinkerbellHardwareConcurrency}); err != nil {
 		return fmt.Errorf("unable to create tink hardware controller: %w", err)
 	}
@@ -235,6 +315,7 @@ func setupTinkShimControllers(ctx context.Context, mgr ctrl.Manager) error {
 	if err := (&tinktemplate.Reconciler{
 		Client:         mgr.GetClient(),
 		TemplateClient: templateClient,
+		Recorder:       mgr.GetEventRecorderFor("tink-template-controller"),
 	}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: tinkerbellTemplateConcurrency}); err != nil {
 		return fmt.Errorf("unable to create tink template controller: %w", err)
 	}
@@ -242,6 +323,7 @@ func setupTinkShimControllers(ctx context.Context, mgr ctrl.Manager) error {
 	if err := (&tinkworkflow.Reconciler{
 		Client:         mgr.GetClient(),
 		WorkflowClient: workflowClient,
+		Recorder:       mgr.GetEventRecorderFor("tink-workflow-controller"),
 	}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: tinkerbellWorkflowConcurrency}); err != nil {
 		return fmt.Errorf("unable to create tink workflow controller: %w", err)
 	}
@@ -250,9 +332,14 @@ func setupTinkShimControllers(ctx context.Context, mgr ctrl.Manager) error {
 }
 
 func setupReconcilers(ctx context.Context, mgr ctrl.Manager) error {
+	if err := controllers.SetupIndexes(ctx, mgr.GetFieldIndexer()); err != nil {
+		return fmt.Errorf("unable to setup field indexes: %w", err)
+	}
+
 	if err := (&controllers.TinkerbellClusterReconciler{
 		Client:           mgr.GetClient(),
 		WatchFilterValue: watchFilterValue,
+		Recorder:         mgr.GetEventRecorderFor("tinkerbellcluster-controller"),
 	}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: tinkerbellClusterConcurrency}); err != nil {
 		return fmt.Errorf("unable to setup TinkerbellCluster controller:%w", err)
 	}
@@ -260,10 +347,43 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager) error {
 	if err := (&controllers.TinkerbellMachineReconciler{
 		Client:           mgr.GetClient(),
 		WatchFilterValue: watchFilterValue,
+		ImageResolver:    images.NewResolver(),
+		HostProber:       hosts.NewProber(),
+		Recorder:         mgr.GetEventRecorderFor("tinkerbellmachine-controller"),
+
+		HardwareQuarantineThreshold:    hardwareQuarantineThreshold,
+		EvictFromHardwareInMaintenance: evictFromMaintenance,
+		HardwareMaintenanceGracePeriod: maintenanceGracePeriod,
+		MachinePools:                   enableMachinePool,
 	}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: tinkerbellMachineConcurrency}); err != nil {
 		return fmt.Errorf("unable to setup TinkerbellMachine controller:%w", err)
 	}
 
+	if enableMachinePool {
+		if err := (&controllers.TinkerbellMachinePoolReconciler{
+			Client:           mgr.GetClient(),
+			WatchFilterValue: watchFilterValue,
+			Recorder:         mgr.GetEventRecorderFor("tinkerbellmachinepool-controller"),
+		}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: tinkerbellMachineConcurrency}); err != nil {
+			return fmt.Errorf("unable to setup TinkerbellMachinePool controller:%w", err)
+		}
+	}
+
+	if err := (&controllers.TinkerbellRemediationReconciler{
+		Client:           mgr.GetClient(),
+		WatchFilterValue: watchFilterValue,
+		Recorder:         mgr.GetEventRecorderFor("tinkerbellremediation-controller"),
+	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
+		return fmt.Errorf("unable to setup TinkerbellRemediation controller:%w", err)
+	}
+
+	if err := (&controllers.HardwareQuarantineReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("hardwarequarantine-controller"),
+	}).SetupWithManager(mgr, controller.Options{}); err != nil {
+		return fmt.Errorf("unable to setup Hardware quarantine controller:%w", err)
+	}
+
 	return nil
 }
 
@@ -272,14 +392,30 @@ func setupWebhooks(mgr ctrl.Manager) error {
 		return fmt.Errorf("unable to setup TinkerbellCluster webhook:%w", err)
 	}
 
+	if err := (&infrastructurev1.TinkerbellClusterTemplate{}).SetupWebhookWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to setup TinkerbellClusterTemplate webhook:%w", err)
+	}
+
 	if err := (&infrastructurev1.TinkerbellMachine{}).SetupWebhookWithManager(mgr); err != nil {
 		return fmt.Errorf("unable to setup TinkerbellMachine webhook:%w", err)
 	}
 
+	if err := (&infrastructurev1.TinkerbellMachinePool{}).SetupWebhookWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to setup TinkerbellMachinePool webhook:%w", err)
+	}
+
 	if err := (&infrastructurev1.TinkerbellMachineTemplate{}).SetupWebhookWithManager(mgr); err != nil {
 		return fmt.Errorf("unable to setup TinkerbellMachineTemplate webhook:%w", err)
 	}
 
+	if err := (&infrastructurev1.TinkerbellRemediation{}).SetupWebhookWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to setup TinkerbellRemediation webhook:%w", err)
+	}
+
+	if err := (&infrastructurev1.TinkerbellRemediationTemplate{}).SetupWebhookWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to setup TinkerbellRemediationTemplate webhook:%w", err)
+	}
+
 	return nil
 }
 
@@ -323,6 +459,9 @@ func main() { //nolint:funlen
 		CertDir:                 webhookCertDir,
 		HealthProbeBindAddress:  healthAddr,
 		EventBroadcaster:        broadcaster,
+		// ConfigMaps are only watched with metadata, so ConfigMaps referenced by machines are read
+		// directly instead of caching all ConfigMaps.
+		ClientDisableCacheFor: []crclient.Object{&corev1.ConfigMap{}},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -335,6 +474,12 @@ func main() { //nolint:funlen
 	// Setup the context that's going to be used in controllers and for the manager.
 	ctx := ctrl.SetupSignalHandler()
 
+	shutdownTracing, err := tracing.Setup(ctx, tracingOptions)
+	if err != nil {
+		setupLog.Error(err, "failed to setup tracing")
+		os.Exit(1)
+	}
+
 	if err := setupTinkShimControllers(ctx, mgr); err != nil {
 		setupLog.Error(err, "failed to add Tinkerbell Shim Controllers")
 		os.Exit(1)
@@ -355,6 +500,11 @@ func main() { //nolint:funlen
 		os.Exit(1)
 	}
 
+	if err := setupMetrics(mgr); err != nil {
+		setupLog.Error(err, "failed to add metrics collectors")
+		os.Exit(1)
+	}
+
 	// +kubebuilder:scaffold:builder
 	setupLog.Info("starting manager", "version", version.Get().String())
 
@@ -362,4 +512,8 @@ func main() { //nolint:funlen
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "failed to flush traces")
+	}
 }
@@ -41,6 +41,18 @@ type HardwareSpec struct {
 	// metadata
 	//+optional
 	UserData *string `json:"userData,omitempty"`
+
+	// AllowPXE is the desired netboot allowPXE setting for all of the
+	// hardware's interfaces in Tinkerbell. If unset, the value in
+	// Tinkerbell is left untouched.
+	//+optional
+	AllowPXE *bool `json:"allowPXE,omitempty"`
+
+	// AllowWorkflow is the desired netboot allowWorkflow setting for all of
+	// the hardware's interfaces in Tinkerbell. If unset, the value in
+	// Tinkerbell is left untouched.
+	//+optional
+	AllowWorkflow *bool `json:"allowWorkflow,omitempty"`
 }
 
 // HardwareStatus defines the observed state of Hardware.
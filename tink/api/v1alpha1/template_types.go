@@ -37,6 +37,10 @@ const (
 	// TemplateFinalizer is used by the controller to ensure
 	// proper deletion of the template resource.
 	TemplateFinalizer = "template.tinkerbell.org"
+
+	// TemplateUnmanagedAnnotation set to "true" marks templates, which are not pushed to Tinkerbell
+	// by the controller, e.g. templates referenced as template overrides and rendered by CAPT.
+	TemplateUnmanagedAnnotation = "template.tinkerbell.org/unmanaged"
 )
 
 // TemplateSpec defines the desired state of Template.
@@ -83,6 +87,22 @@ func (t *Template) SetTinkID(id string) {
 	t.Annotations[TemplateIDAnnotation] = id
 }
 
+// Managed reports whether the template is pushed to Tinkerbell by the controller. Templates taken over
+// by the controller before being annotated with TemplateUnmanagedAnnotation remain managed.
+func (t *Template) Managed() bool {
+	if t.GetAnnotations()[TemplateUnmanagedAnnotation] != "true" {
+		return true
+	}
+
+	for _, finalizer := range t.GetFinalizers() {
+		if finalizer == TemplateFinalizer {
+			return true
+		}
+	}
+
+	return false
+}
+
 // +kubebuilder:object:root=true
 
 // TemplateList contains a list of Templates.
@@ -30,6 +30,15 @@ const (
 	WorkflowFinalizer = "workflow.tinkerbell.org"
 )
 
+// Workflow states as reported by Tinkerbell in WorkflowStatus.State.
+const (
+	WorkflowStatePending = "STATE_PENDING"
+	WorkflowStateRunning = "STATE_RUNNING"
+	WorkflowStateFailed  = "STATE_FAILED"
+	WorkflowStateTimeout = "STATE_TIMEOUT"
+	WorkflowStateSuccess = "STATE_SUCCESS"
+)
+
 // WorkflowSpec defines the desired state of Workflow.
 type WorkflowSpec struct {
 	// Name of the Template associated with this workflow.
@@ -192,6 +192,16 @@ func (in *HardwareSpec) DeepCopyInto(out *HardwareSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.AllowPXE != nil {
+		in, out := &in.AllowPXE, &out.AllowPXE
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AllowWorkflow != nil {
+		in, out := &in.AllowWorkflow, &out.AllowWorkflow
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSpec.
@@ -19,6 +19,9 @@ package client
 
 import (
 	"errors"
+	"time"
+
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/metrics"
 )
 
 // ErrNotFound is returned if a requested resource is not found.
@@ -30,3 +33,13 @@ const (
 	sqlErrorString    = "rpc error: code = Unknown desc = sql: no rows in result set"
 	sqlErrorStringAlt = "rpc error: code = Unknown desc = SELECT: sql: no rows in result set"
 )
+
+// observe records metrics of the client method call started at given time. Not found errors
+// are expected during reconciliation, so they are not counted as failures.
+func observe(client, method string, start time.Time, err error) {
+	if errors.Is(err, ErrNotFound) {
+		err = nil
+	}
+
+	metrics.ObserveTinkRequest(client, method, start, err)
+}
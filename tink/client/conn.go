@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	certURLEnv       = "TINKERBELL_CERT_URL"
+	grpcAuthorityEnv = "TINKERBELL_GRPC_AUTHORITY"
+)
+
+var (
+	// ErrMissingCertURL is returned when the Tinkerbell certificate URL is not configured.
+	ErrMissingCertURL = fmt.Errorf("undefined %s", certURLEnv)
+
+	// ErrMissingGRPCAuthority is returned when the Tinkerbell gRPC authority is not configured.
+	ErrMissingGRPCAuthority = fmt.Errorf("undefined %s", grpcAuthorityEnv)
+
+	errParsingCert = errors.New("no certificates found")
+)
+
+// NewClientConn creates a connection to the Tinkerbell server configured using TINKERBELL_CERT_URL
+// and TINKERBELL_GRPC_AUTHORITY environment variables. Given options are appended to the default
+// dial options, e.g. to add interceptors.
+func NewClientConn(ctx context.Context, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	certURL := os.Getenv(certURLEnv)
+	if certURL == "" {
+		return nil, ErrMissingCertURL
+	}
+
+	grpcAuthority := os.Getenv(grpcAuthorityEnv)
+	if grpcAuthority == "" {
+		return nil, ErrMissingGRPCAuthority
+	}
+
+	certPool, err := fetchCertPool(ctx, certURL)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, "")),
+	}, opts...)
+
+	conn, err := grpc.DialContext(ctx, grpcAuthority, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to Tinkerbell server: %w", err)
+	}
+
+	return conn, nil
+}
+
+func fetchCertPool(ctx context.Context, certURL string) (*x509.CertPool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building certificate request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching certificate: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	certs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading certificate: %w", err)
+	}
+
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(certs) {
+		return nil, fmt.Errorf("parsing certificate: %w", errParsingCert)
+	}
+
+	return certPool, nil
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/tinkerbell/tink/protos/hardware"
@@ -37,7 +38,11 @@ func NewHardwareClient(client hardware.HardwareServiceClient) *Hardware {
 }
 
 // Create Tinkerbell Hardware.
-func (t *Hardware) Create(ctx context.Context, h *hardware.Hardware) error {
+func (t *Hardware) Create(ctx context.Context, h *hardware.Hardware) (err error) {
+	defer func(start time.Time) {
+		observe("hardware", "Create", start, err)
+	}(time.Now())
+
 	if h == nil {
 		return errors.New("hardware should not be nil") //nolint:goerr113
 	}
@@ -54,7 +59,11 @@ func (t *Hardware) Create(ctx context.Context, h *hardware.Hardware) error {
 }
 
 // Update Tinkerbell Hardware.
-func (t *Hardware) Update(ctx context.Context, h *hardware.Hardware) error {
+func (t *Hardware) Update(ctx context.Context, h *hardware.Hardware) (err error) {
+	defer func(start time.Time) {
+		observe("hardware", "Update", start, err)
+	}(time.Now())
+
 	if _, err := t.client.Push(ctx, &hardware.PushRequest{Data: h}); err != nil {
 		return fmt.Errorf("updating template in Tinkerbell: %w", err)
 	}
@@ -63,7 +72,11 @@ func (t *Hardware) Update(ctx context.Context, h *hardware.Hardware) error {
 }
 
 // Get returns a Tinkerbell Hardware.
-func (t *Hardware) Get(ctx context.Context, id, ip, mac string) (*hardware.Hardware, error) {
+func (t *Hardware) Get(ctx context.Context, id, ip, mac string) (_ *hardware.Hardware, err error) {
+	defer func(start time.Time) {
+		observe("hardware", "Get", start, err)
+	}(time.Now())
+
 	var method func(context.Context, *hardware.GetRequest, ...grpc.CallOption) (*hardware.Hardware, error)
 
 	req := &hardware.GetRequest{}
@@ -95,7 +108,11 @@ func (t *Hardware) Get(ctx context.Context, id, ip, mac string) (*hardware.Hardw
 }
 
 // Delete a Tinkerbell Hardware.
-func (t *Hardware) Delete(ctx context.Context, id string) error {
+func (t *Hardware) Delete(ctx context.Context, id string) (err error) {
+	defer func(start time.Time) {
+		observe("hardware", "Delete", start, err)
+	}(time.Now())
+
 	if _, err := t.client.Delete(ctx, &hardware.DeleteRequest{Id: id}); err != nil {
 		if err.Error() == sqlErrorString || err.Error() == sqlErrorStringAlt {
 			return fmt.Errorf("hardware %w", ErrNotFound)
@@ -19,6 +19,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/tinkerbell/tink/protos/template"
 )
@@ -34,7 +35,11 @@ func NewTemplateClient(client template.TemplateServiceClient) *Template {
 }
 
 // Get returns a Tinkerbell Template.
-func (t *Template) Get(ctx context.Context, id, name string) (*template.WorkflowTemplate, error) {
+func (t *Template) Get(ctx context.Context, id, name string) (_ *template.WorkflowTemplate, err error) {
+	defer func(start time.Time) {
+		observe("template", "Get", start, err)
+	}(time.Now())
+
 	req := &template.GetRequest{}
 	if id != "" {
 		req.GetBy = &template.GetRequest_Id{Id: id}
@@ -55,7 +60,11 @@ func (t *Template) Get(ctx context.Context, id, name string) (*template.Workflow
 }
 
 // Update a Tinkerbell Template.
-func (t *Template) Update(ctx context.Context, template *template.WorkflowTemplate) error {
+func (t *Template) Update(ctx context.Context, template *template.WorkflowTemplate) (err error) {
+	defer func(start time.Time) {
+		observe("template", "Update", start, err)
+	}(time.Now())
+
 	if _, err := t.client.UpdateTemplate(ctx, template); err != nil {
 		return fmt.Errorf("updating template in Tinkerbefll: %w", err)
 	}
@@ -64,7 +73,11 @@ func (t *Template) Update(ctx context.Context, template *template.WorkflowTempla
 }
 
 // Create a Tinkerbell Template.
-func (t *Template) Create(ctx context.Context, template *template.WorkflowTemplate) error {
+func (t *Template) Create(ctx context.Context, template *template.WorkflowTemplate) (err error) {
+	defer func(start time.Time) {
+		observe("template", "Create", start, err)
+	}(time.Now())
+
 	resp, err := t.client.CreateTemplate(ctx, template)
 	if err != nil {
 		return fmt.Errorf("creating template in Tinkerbell: %w", err)
@@ -76,7 +89,11 @@ func (t *Template) Create(ctx context.Context, template *template.WorkflowTempla
 }
 
 // Delete a Tinkerbell Template.
-func (t *Template) Delete(ctx context.Context, id string) error {
+func (t *Template) Delete(ctx context.Context, id string) (err error) {
+	defer func(start time.Time) {
+		observe("template", "Delete", start, err)
+	}(time.Now())
+
 	req := &template.GetRequest{
 		GetBy: &template.GetRequest_Id{Id: id},
 	}
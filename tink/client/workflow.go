@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/tinkerbell/tink/protos/hardware"
 	"github.com/tinkerbell/tink/protos/workflow"
@@ -39,7 +40,11 @@ func NewWorkflowClient(client workflow.WorkflowServiceClient, hClient *Hardware)
 }
 
 // Get returns a Tinkerbell Workflow.
-func (t *Workflow) Get(ctx context.Context, id string) (*workflow.Workflow, error) {
+func (t *Workflow) Get(ctx context.Context, id string) (_ *workflow.Workflow, err error) {
+	defer func(start time.Time) {
+		observe("workflow", "Get", start, err)
+	}(time.Now())
+
 	tinkWorkflow, err := t.client.GetWorkflow(ctx, &workflow.GetRequest{Id: id})
 	if err != nil {
 		if err.Error() == sqlErrorString || err.Error() == sqlErrorStringAlt {
@@ -53,7 +58,11 @@ func (t *Workflow) Get(ctx context.Context, id string) (*workflow.Workflow, erro
 }
 
 // GetMetadata returns the metadata for a given Tinkerbell Workflow.
-func (t *Workflow) GetMetadata(ctx context.Context, id string) ([]byte, error) {
+func (t *Workflow) GetMetadata(ctx context.Context, id string) (_ []byte, err error) {
+	defer func(start time.Time) {
+		observe("workflow", "GetMetadata", start, err)
+	}(time.Now())
+
 	verReq := &workflow.GetWorkflowDataRequest{WorkflowId: id}
 
 	verResp, err := t.client.GetWorkflowDataVersion(ctx, verReq)
@@ -80,7 +89,11 @@ func (t *Workflow) GetMetadata(ctx context.Context, id string) ([]byte, error) {
 }
 
 // GetActions returns the actions for a given Tinkerbell Workflow.
-func (t *Workflow) GetActions(ctx context.Context, id string) ([]*workflow.WorkflowAction, error) {
+func (t *Workflow) GetActions(ctx context.Context, id string) (_ []*workflow.WorkflowAction, err error) {
+	defer func(start time.Time) {
+		observe("workflow", "GetActions", start, err)
+	}(time.Now())
+
 	req := &workflow.WorkflowActionsRequest{WorkflowId: id}
 
 	resp, err := t.client.GetWorkflowActions(ctx, req)
@@ -96,7 +109,11 @@ func (t *Workflow) GetActions(ctx context.Context, id string) ([]*workflow.Workf
 }
 
 // GetEvents returns the events for a given Tinkerbell Workflow.
-func (t *Workflow) GetEvents(ctx context.Context, id string) ([]*workflow.WorkflowActionStatus, error) {
+func (t *Workflow) GetEvents(ctx context.Context, id string) (_ []*workflow.WorkflowActionStatus, err error) {
+	defer func(start time.Time) {
+		observe("workflow", "GetEvents", start, err)
+	}(time.Now())
+
 	req := &workflow.GetRequest{Id: id}
 
 	resp, err := t.client.ShowWorkflowEvents(ctx, req)
@@ -127,7 +144,11 @@ func (t *Workflow) GetEvents(ctx context.Context, id string) ([]*workflow.Workfl
 }
 
 // GetState returns the state for a given Tinkerbell Workflow.
-func (t *Workflow) GetState(ctx context.Context, id string) (workflow.State, error) {
+func (t *Workflow) GetState(ctx context.Context, id string) (_ workflow.State, err error) {
+	defer func(start time.Time) {
+		observe("workflow", "GetState", start, err)
+	}(time.Now())
+
 	req := &workflow.GetRequest{Id: id}
 
 	resp, err := t.client.GetWorkflowContext(ctx, req)
@@ -162,7 +183,11 @@ func (t *Workflow) GetState(ctx context.Context, id string) (workflow.State, err
 }
 
 // Create a Tinkerbell Workflow.
-func (t *Workflow) Create(ctx context.Context, templateID, hardwareID string) (string, error) {
+func (t *Workflow) Create(ctx context.Context, templateID, hardwareID string) (_ string, err error) {
+	defer func(start time.Time) {
+		observe("workflow", "Create", start, err)
+	}(time.Now())
+
 	h, err := t.hardwareClient.Get(ctx, hardwareID, "", "")
 	if err != nil {
 		return "", err
@@ -187,7 +212,11 @@ func (t *Workflow) Create(ctx context.Context, templateID, hardwareID string) (s
 }
 
 // Delete a Tinkerbell Workflow.
-func (t *Workflow) Delete(ctx context.Context, id string) error {
+func (t *Workflow) Delete(ctx context.Context, id string) (err error) {
+	defer func(start time.Time) {
+		observe("workflow", "Delete", start, err)
+	}(time.Now())
+
 	if _, err := t.client.DeleteWorkflow(ctx, &workflow.GetRequest{Id: id}); err != nil {
 		if err.Error() == sqlErrorString || err.Error() == sqlErrorStringAlt {
 			return fmt.Errorf("workflow %w", ErrNotFound)
@@ -23,6 +23,9 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -30,6 +33,14 @@ import (
 // ErrNotImplemented is returned if a requested action is not yet implemented.
 var ErrNotImplemented = errors.New("not implemented")
 
+// ReasonTinkerbellAPIError is the reason of warning events emitted when a Tinkerbell API call fails.
+const ReasonTinkerbellAPIError = "TinkerbellAPIError"
+
+// RecordTinkerbellAPIError emits a warning event on the given object about a failed Tinkerbell API call.
+func RecordTinkerbellAPIError(recorder record.EventRecorder, obj runtime.Object, action string, err error) {
+	recorder.Eventf(obj, corev1.EventTypeWarning, ReasonTinkerbellAPIError, "Failed to %s: %v", action, err)
+}
+
 // EnsureFinalizer ensures the given finalizer is applied to the resource.
 func EnsureFinalizer(
 	ctx context.Context,
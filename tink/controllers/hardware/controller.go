@@ -24,13 +24,17 @@ import (
 	"reflect"
 
 	"github.com/tinkerbell/tink/protos/hardware"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
 	tinkv1alpha1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/controllers/common"
 )
 
 type hardwareClient interface {
@@ -44,6 +48,7 @@ type hardwareClient interface {
 type Reconciler struct {
 	client.Client
 	HardwareClient hardwareClient
+	Recorder       record.EventRecorder
 }
 
 // SetupWithManager configures reconciler with a given manager.
@@ -55,9 +60,15 @@ func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, opt
 }
 
 // +kubebuilder:rbac:groups=tinkerbell.org,resources=hardware;hardware/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile ensures state of Tinkerbell hardware.
-func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "tink-hardware", req)
+	defer func() {
+		tracing.End(span, reterr)
+	}()
+
 	logger := ctrl.LoggerFrom(ctx).WithValues("hardware", req.NamespacedName.Name)
 
 	// Fetch the hardware.
@@ -91,6 +102,9 @@ func (r *Reconciler) reconcileNormal(ctx context.Context, h *tinkv1alpha1.Hardwa
 
 			h.Status.State = tinkv1alpha1.HardwareError
 
+			r.Recorder.Eventf(h, corev1.EventTypeWarning, "HardwareNotFound",
+				"Hardware %s not found in Tinkerbell", h.Spec.ID)
+
 			if err := r.Client.Status().Patch(ctx, h, patch); err != nil {
 				logger.Error(err, "Failed to patch hardware")
 
@@ -101,6 +115,7 @@ func (r *Reconciler) reconcileNormal(ctx context.Context, h *tinkv1alpha1.Hardwa
 		}
 
 		logger.Error(err, "Failed to get hardware from Tinkerbell")
+		common.RecordTinkerbellAPIError(r.Recorder, h, "get hardware from Tinkerbell", err)
 
 		return ctrl.Result{}, fmt.Errorf("failed to get hardware from Tinkerbell: %w", err)
 	}
@@ -114,6 +129,10 @@ func (r *Reconciler) reconcileNormal(ctx context.Context, h *tinkv1alpha1.Hardwa
 		return ctrl.Result{}, err
 	}
 
+	if err := r.reconcileNetboot(ctx, h, tinkHardware); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return r.reconcileStatus(ctx, h, tinkHardware)
 }
 
@@ -151,13 +170,63 @@ func (r *Reconciler) reconcileUserData(
 		tinkHardware.Metadata = string(newHWMetaData)
 		if err := r.HardwareClient.Update(ctx, tinkHardware); err != nil {
 			logger.Error(err, "Failed to update hardware userdata", "hardware", tinkHardware)
+			common.RecordTinkerbellAPIError(r.Recorder, h, "update hardware userdata", err)
 
 			return fmt.Errorf("failed to update hardware userdata: %w", err)
 		}
 
 		logger.Info("Updated userdata for hardware in Tinkerbell")
+		r.Recorder.Event(h, corev1.EventTypeNormal, "UserDataUpdated", "Updated userdata for hardware in Tinkerbell")
+	}
+
+	return nil
+}
+
+func (r *Reconciler) reconcileNetboot(
+	ctx context.Context,
+	h *tinkv1alpha1.Hardware,
+	tinkHardware *hardware.Hardware,
+) error {
+	logger := ctrl.LoggerFrom(ctx).WithValues("hardware", h.Name)
+
+	// if neither of netboot settings is set, skip reconciliation
+	if h.Spec.AllowPXE == nil && h.Spec.AllowWorkflow == nil {
+		return nil
 	}
 
+	changed := false
+
+	for _, iface := range tinkHardware.GetNetwork().GetInterfaces() {
+		if iface.Netboot == nil {
+			iface.Netboot = &hardware.Hardware_Netboot{}
+		}
+
+		if h.Spec.AllowPXE != nil && iface.Netboot.AllowPxe != *h.Spec.AllowPXE {
+			iface.Netboot.AllowPxe = *h.Spec.AllowPXE
+			changed = true
+		}
+
+		if h.Spec.AllowWorkflow != nil && iface.Netboot.AllowWorkflow != *h.Spec.AllowWorkflow {
+			iface.Netboot.AllowWorkflow = *h.Spec.AllowWorkflow
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := r.HardwareClient.Update(ctx, tinkHardware); err != nil {
+		logger.Error(err, "Failed to update hardware netboot settings", "hardware", tinkHardware)
+		common.RecordTinkerbellAPIError(r.Recorder, h, "update hardware netboot settings", err)
+
+		return fmt.Errorf("failed to update hardware netboot settings: %w", err)
+	}
+
+	logger.Info("Updated netboot settings for hardware in Tinkerbell",
+		"allowPXE", h.Spec.AllowPXE, "allowWorkflow", h.Spec.AllowWorkflow)
+	r.Recorder.Event(h, corev1.EventTypeNormal, "NetbootUpdated", "Updated netboot settings for hardware in Tinkerbell")
+
 	return nil
 }
 
@@ -23,13 +23,16 @@ import (
 	"fmt"
 
 	"github.com/tinkerbell/tink/protos/template"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
 	tinkv1alpha1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 	tinkclient "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/client"
 	"github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/controllers/common"
@@ -46,6 +49,7 @@ type templateClient interface {
 type Reconciler struct {
 	client.Client
 	TemplateClient templateClient
+	Recorder       record.EventRecorder
 }
 
 // SetupWithManager configures reconciler with a given manager.
@@ -57,9 +61,15 @@ func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, opt
 }
 
 // +kubebuilder:rbac:groups=tinkerbell.org,resources=templates;templates/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile ensures state of Tinkerbell templates.
-func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "tink-template", req)
+	defer func() {
+		tracing.End(span, reterr)
+	}()
+
 	logger := ctrl.LoggerFrom(ctx).WithValues("template", req.NamespacedName.Name)
 
 	// Fetch the template.
@@ -74,6 +84,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, fmt.Errorf("failed to get template: %w", err)
 	}
 
+	if !template.Managed() {
+		return ctrl.Result{}, nil
+	}
+
 	// Ensure that we add the finalizer to the resource
 	if err := common.EnsureFinalizer(ctx, r.Client, logger, template, tinkv1alpha1.TemplateFinalizer); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to ensure finalizer on template: %w", err)
@@ -123,6 +137,8 @@ func (r *Reconciler) reconcileNormal(ctx context.Context, t *tinkv1alpha1.Templa
 
 		tinkTemplate = result
 	case err != nil:
+		common.RecordTinkerbellAPIError(r.Recorder, t, "get template from Tinkerbell", err)
+
 		return ctrl.Result{}, fmt.Errorf("failed to get template: %w", err)
 	default:
 		templateID = tinkTemplate.Id
@@ -153,8 +169,12 @@ func (r *Reconciler) reconcileTemplateData(
 	if t.Spec.Data != nil && *t.Spec.Data != tinkTemplate.GetData() {
 		tinkTemplate.Data = *t.Spec.Data
 		if err := r.TemplateClient.Update(ctx, tinkTemplate); err != nil {
+			common.RecordTinkerbellAPIError(r.Recorder, t, "update template in Tinkerbell", err)
+
 			return fmt.Errorf("failed to update template in Tinkerbell: %w", err)
 		}
+
+		r.Recorder.Eventf(t, corev1.EventTypeNormal, "TemplateUpdated", "Updated template %s in Tinkerbell", tinkTemplate.Id)
 	}
 
 	patch := client.MergeFrom(t.DeepCopy())
@@ -196,10 +216,13 @@ func (r *Reconciler) createTemplate(ctx context.Context, t *tinkv1alpha1.Templat
 
 	if err := r.TemplateClient.Create(ctx, tinkTemplate); err != nil {
 		logger.Error(err, "Failed to create template in Tinkerbell")
+		common.RecordTinkerbellAPIError(r.Recorder, t, "create template in Tinkerbell", err)
 
 		return nil, fmt.Errorf("failed to create template in Tinkerbell: %w", err)
 	}
 
+	r.Recorder.Eventf(t, corev1.EventTypeNormal, "TemplateCreated", "Created template %s in Tinkerbell", tinkTemplate.Id)
+
 	return tinkTemplate, nil
 }
 
@@ -214,6 +237,7 @@ func (r *Reconciler) reconcileDelete(ctx context.Context, t *tinkv1alpha1.Templa
 		err := r.TemplateClient.Delete(ctx, id)
 		if err != nil && !errors.Is(err, tinkclient.ErrNotFound) {
 			logger.Error(err, "Failed to delete template from Tinkerbell")
+			common.RecordTinkerbellAPIError(r.Recorder, t, "delete template from Tinkerbell", err)
 
 			return ctrl.Result{}, fmt.Errorf("failed to delete template from Tinkerbell: %w", err)
 		}
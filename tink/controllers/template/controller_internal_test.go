@@ -25,6 +25,7 @@ import (
 	"github.com/tinkerbell/tink/protos/template"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -45,6 +46,46 @@ tasks:
         image: hello-world
         timeout: 60`
 
+func TestTemplateReconciler_Reconcile_ignores_unmanaged_templates(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+
+	g.Expect(tinkv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+	in := &tinkv1alpha1.Template{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			Annotations: map[string]string{
+				tinkv1alpha1.TemplateUnmanagedAnnotation: "true",
+			},
+		},
+		Spec: tinkv1alpha1.TemplateSpec{
+			Data: pointer.StringPtr(helloWorldTemplate),
+		},
+	}
+
+	fakeTemplateClient := tinkfake.NewFakeTemplateClient()
+
+	r := &Reconciler{
+		Client:         fake.NewClientBuilder().WithScheme(scheme).WithObjects(in.DeepCopy()).Build(),
+		TemplateClient: fakeTemplateClient,
+		Recorder:       record.NewFakeRecorder(10), //nolint:gomnd
+	}
+
+	got, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKey{Name: in.Name}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(BeEquivalentTo(ctrl.Result{}))
+
+	// Verify that the template was not pushed to the fakeTemplateServiceClient
+	g.Expect(fakeTemplateClient.Objs).To(BeEmpty())
+
+	k8sTemplate := &tinkv1alpha1.Template{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Name: in.Name}, k8sTemplate)).To(Succeed())
+	g.Expect(k8sTemplate.Finalizers).To(BeEmpty())
+	g.Expect(k8sTemplate.TinkID()).To(BeEmpty())
+}
+
 //nolint:funlen
 func TestTemplateReconciler_reconcileDelete(t *testing.T) {
 	t.Parallel()
@@ -110,6 +151,7 @@ func TestTemplateReconciler_reconcileDelete(t *testing.T) {
 			r := &Reconciler{
 				Client:         fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.in.DeepCopy()).Build(),
 				TemplateClient: fakeTemplateClient,
+				Recorder:       record.NewFakeRecorder(10), //nolint:gomnd
 			}
 
 			got, err := r.reconcileDelete(context.Background(), tt.in)
@@ -141,11 +183,12 @@ func TestTemplateReconciler_reconcileNormal(t *testing.T) {
 	g.Expect(tinkv1alpha1.AddToScheme(scheme)).To(Succeed())
 
 	tests := []struct {
-		name     string
-		in       *tinkv1alpha1.Template
-		tinkObjs []*template.WorkflowTemplate
-		want     ctrl.Result
-		wantErr  bool
+		name       string
+		in         *tinkv1alpha1.Template
+		tinkObjs   []*template.WorkflowTemplate
+		want       ctrl.Result
+		wantErr    bool
+		wantEvents []string
 	}{
 		{
 			name: "successful create",
@@ -154,9 +197,10 @@ func TestTemplateReconciler_reconcileNormal(t *testing.T) {
 					Name: "test",
 				},
 			},
-			tinkObjs: nil,
-			want:     ctrl.Result{},
-			wantErr:  false,
+			tinkObjs:   nil,
+			want:       ctrl.Result{},
+			wantErr:    false,
+			wantEvents: []string{"Normal TemplateCreated"},
 		},
 		{
 			name: "successful update",
@@ -177,8 +221,9 @@ func TestTemplateReconciler_reconcileNormal(t *testing.T) {
 					Name: "test",
 				},
 			},
-			want:    ctrl.Result{},
-			wantErr: false,
+			want:       ctrl.Result{},
+			wantErr:    false,
+			wantEvents: []string{"Normal TemplateUpdated"},
 		},
 		{
 			name: "successful adopt",
@@ -194,8 +239,9 @@ func TestTemplateReconciler_reconcileNormal(t *testing.T) {
 					Data: helloWorldTemplate,
 				},
 			},
-			want:    ctrl.Result{},
-			wantErr: false,
+			want:       ctrl.Result{},
+			wantErr:    false,
+			wantEvents: []string{"Normal TemplateCreated"},
 		},
 	}
 	for i := range tests {
@@ -209,6 +255,7 @@ func TestTemplateReconciler_reconcileNormal(t *testing.T) {
 			r := &Reconciler{
 				Client:         fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.in.DeepCopy()).Build(),
 				TemplateClient: fakeTemplateClient,
+				Recorder:       record.NewFakeRecorder(10), //nolint:gomnd
 			}
 
 			got, err := r.reconcileNormal(context.Background(), tt.in)
@@ -246,6 +293,14 @@ func TestTemplateReconciler_reconcileNormal(t *testing.T) {
 			// Verify the Data matches
 			g.Expect(k8sTemplate.Spec.Data).NotTo(BeNil())
 			g.Expect(tinkTemplate.Data).To(BeEquivalentTo(*k8sTemplate.Spec.Data))
+
+			// Verify the emitted events
+			recorder, _ := r.Recorder.(*record.FakeRecorder)
+			g.Expect(recorder.Events).To(HaveLen(len(tt.wantEvents)))
+
+			for _, want := range tt.wantEvents {
+				g.Expect(<-recorder.Events).To(HavePrefix(want))
+			}
 		})
 	}
 }
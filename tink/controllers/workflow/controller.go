@@ -24,13 +24,17 @@ import (
 	"time"
 
 	"github.com/tinkerbell/tink/protos/workflow"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/metrics"
+	"github.com/tinkerbell/cluster-api-provider-tinkerbell/internal/tracing"
 	tinkv1alpha1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
 	tinkclient "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/client"
 	"github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/controllers/common"
@@ -50,6 +54,7 @@ type workflowClient interface {
 type Reconciler struct {
 	client.Client
 	WorkflowClient workflowClient
+	Recorder       record.EventRecorder
 }
 
 // SetupWithManager configures reconciler with a given manager.
@@ -61,9 +66,15 @@ func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, opt
 }
 
 // +kubebuilder:rbac:groups=tinkerbell.org,resources=workflows;workflows/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile ensures state of Tinkerbell workflows.
-func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "tink-workflow", req)
+	defer func() {
+		tracing.End(span, reterr)
+	}()
+
 	logger := ctrl.LoggerFrom(ctx).WithValues("workflow", req.NamespacedName.Name)
 
 	// Fetch the workflow.
@@ -107,6 +118,8 @@ func (r *Reconciler) reconcileNormal(ctx context.Context, w *tinkv1alpha1.Workfl
 
 	tinkWorkflow, err := r.WorkflowClient.Get(ctx, workflowID)
 	if err != nil {
+		common.RecordTinkerbellAPIError(r.Recorder, w, "get workflow from Tinkerbell", err)
+
 		return ctrl.Result{}, fmt.Errorf("failed to get workflow: %w", err)
 	}
 
@@ -156,6 +169,7 @@ func (r *Reconciler) reconcileStatusEvents(ctx context.Context, w *tinkv1alpha1.
 	events, err := r.WorkflowClient.GetEvents(ctx, id)
 	if err != nil {
 		logger.Error(err, "Failed to get events for workflow")
+		common.RecordTinkerbellAPIError(r.Recorder, w, "get events for workflow", err)
 
 		return fmt.Errorf("failed to get events for workflow: %w", err)
 	}
@@ -165,17 +179,40 @@ func (r *Reconciler) reconcileStatusEvents(ctx context.Context, w *tinkv1alpha1.
 		statusEvents = append(statusEvents, eventFromTinkEvent(event))
 	}
 
+	r.recordActionEvents(w, statusEvents)
+
 	w.Status.Events = statusEvents
 
 	return nil
 }
 
+// recordActionEvents emits events for completed and failed actions, which were not yet
+// recorded in the workflow status.
+func (r *Reconciler) recordActionEvents(w *tinkv1alpha1.Workflow, statusEvents []tinkv1alpha1.Event) {
+	if len(statusEvents) <= len(w.Status.Events) {
+		return
+	}
+
+	for _, event := range statusEvents[len(w.Status.Events):] {
+		switch event.ActionStatus {
+		case workflow.State_STATE_SUCCESS.String():
+			r.Recorder.Eventf(w, corev1.EventTypeNormal, "ActionCompleted",
+				"Action %q of task %q completed in %ds", event.ActionName, event.TaskName, event.Seconds)
+		case workflow.State_STATE_FAILED.String(), workflow.State_STATE_TIMEOUT.String():
+			r.Recorder.Eventf(w, corev1.EventTypeWarning, "ActionFailed",
+				"Action %q of task %q finished with state %s: %s",
+				event.ActionName, event.TaskName, event.ActionStatus, event.Message)
+		}
+	}
+}
+
 func (r *Reconciler) reconcileStatusActions(ctx context.Context, w *tinkv1alpha1.Workflow, id string) error {
 	logger := ctrl.LoggerFrom(ctx).WithValues("workflow", w.Name)
 
 	actions, err := r.WorkflowClient.GetActions(ctx, id)
 	if err != nil {
 		logger.Error(err, "Failed to get actions for workflow")
+		common.RecordTinkerbellAPIError(r.Recorder, w, "get actions for workflow", err)
 
 		return fmt.Errorf("failed to get actions for workflow: %w", err)
 	}
@@ -210,6 +247,7 @@ func (r *Reconciler) reconcileStatus(
 	md, err := r.WorkflowClient.GetMetadata(ctx, tinkWorkflow.GetId())
 	if err != nil {
 		logger.Error(err, "Failed to get metadata for workflow")
+		common.RecordTinkerbellAPIError(r.Recorder, w, "get metadata for workflow", err)
 
 		return ctrl.Result{}, fmt.Errorf("failed to get metadata for workflow: %w", err)
 	}
@@ -227,10 +265,19 @@ func (r *Reconciler) reconcileStatus(
 	state, err := r.WorkflowClient.GetState(ctx, tinkWorkflow.GetId())
 	if err != nil {
 		logger.Error(err, "Failed to get state for workflow")
+		common.RecordTinkerbellAPIError(r.Recorder, w, "get state for workflow", err)
 
 		return ctrl.Result{}, fmt.Errorf("failed to get state for workflow: %w", err)
 	}
 
+	if w.Status.State != state.String() {
+		r.recordStateEvent(w, state)
+
+		if isTerminalState(state) {
+			metrics.WorkflowOutcomes.WithLabelValues(state.String()).Inc()
+		}
+	}
+
 	w.Status.State = state.String()
 
 	if state != workflow.State_STATE_SUCCESS {
@@ -243,6 +290,27 @@ func (r *Reconciler) reconcileStatus(
 	return ctrl.Result{}, nil
 }
 
+// recordStateEvent emits an event for workflow transitioning into the given state.
+func (r *Reconciler) recordStateEvent(w *tinkv1alpha1.Workflow, state workflow.State) {
+	switch state { //nolint:exhaustive
+	case workflow.State_STATE_RUNNING:
+		r.Recorder.Event(w, corev1.EventTypeNormal, "WorkflowStarted", "Workflow started")
+	case workflow.State_STATE_SUCCESS:
+		r.Recorder.Event(w, corev1.EventTypeNormal, "WorkflowSucceeded", "Workflow finished successfully")
+	case workflow.State_STATE_FAILED, workflow.State_STATE_TIMEOUT:
+		r.Recorder.Eventf(w, corev1.EventTypeWarning, "WorkflowFailed", "Workflow finished with state %s", state)
+	}
+}
+
+func isTerminalState(state workflow.State) bool {
+	switch state { //nolint:exhaustive
+	case workflow.State_STATE_SUCCESS, workflow.State_STATE_FAILED, workflow.State_STATE_TIMEOUT:
+		return true
+	default:
+		return false
+	}
+}
+
 func (r *Reconciler) createWorkflow(ctx context.Context, w *tinkv1alpha1.Workflow) (string, error) {
 	logger := ctrl.LoggerFrom(ctx).WithValues("workflow", w.Name)
 
@@ -270,10 +338,13 @@ func (r *Reconciler) createWorkflow(ctx context.Context, w *tinkv1alpha1.Workflo
 	)
 	if err != nil {
 		logger.Error(err, "Failed to create workflow")
+		common.RecordTinkerbellAPIError(r.Recorder, w, "create workflow in Tinkerbell", err)
 
 		return "", fmt.Errorf("failed to create workflow: %w", err)
 	}
 
+	r.Recorder.Eventf(w, corev1.EventTypeNormal, "WorkflowCreated", "Created workflow %s in Tinkerbell", id)
+
 	return id, nil
 }
 
@@ -288,6 +359,7 @@ func (r *Reconciler) reconcileDelete(ctx context.Context, w *tinkv1alpha1.Workfl
 		err := r.WorkflowClient.Delete(ctx, id)
 		if err != nil && !errors.Is(err, tinkclient.ErrNotFound) {
 			logger.Error(err, "Failed to delete workflow from Tinkerbell")
+			common.RecordTinkerbellAPIError(r.Recorder, w, "delete workflow from Tinkerbell", err)
 
 			return ctrl.Result{}, fmt.Errorf("failed to delete workflow from Tinkerbell: %w", err)
 		}
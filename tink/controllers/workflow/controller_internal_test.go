@@ -0,0 +1,152 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/tinkerbell/tink/protos/workflow"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	tinkv1alpha1 "github.com/tinkerbell/cluster-api-provider-tinkerbell/tink/api/v1alpha1"
+)
+
+func receivedEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+
+	return events
+}
+
+//nolint:funlen
+func TestWorkflowReconciler_recordActionEvents(t *testing.T) {
+	t.Parallel()
+
+	succeeded := tinkv1alpha1.Event{
+		ActionName:   "stream-image",
+		TaskName:     "os-installation",
+		ActionStatus: workflow.State_STATE_SUCCESS.String(),
+		Seconds:      42, //nolint:gomnd
+	}
+
+	running := tinkv1alpha1.Event{
+		ActionName:   "kexec",
+		TaskName:     "os-installation",
+		ActionStatus: workflow.State_STATE_RUNNING.String(),
+	}
+
+	failed := tinkv1alpha1.Event{
+		ActionName:   "kexec",
+		TaskName:     "os-installation",
+		ActionStatus: workflow.State_STATE_FAILED.String(),
+		Message:      "exit status 1",
+	}
+
+	tests := []struct {
+		name       string
+		previous   []tinkv1alpha1.Event
+		current    []tinkv1alpha1.Event
+		wantEvents []string
+	}{
+		{
+			name:     "records completed action",
+			previous: nil,
+			current:  []tinkv1alpha1.Event{succeeded},
+			wantEvents: []string{
+				`Normal ActionCompleted Action "stream-image" of task "os-installation" completed in 42s`,
+			},
+		},
+		{
+			name:     "records only new actions",
+			previous: []tinkv1alpha1.Event{succeeded, running},
+			current:  []tinkv1alpha1.Event{succeeded, running, failed},
+			wantEvents: []string{
+				`Warning ActionFailed Action "kexec" of task "os-installation" finished with state STATE_FAILED: exit status 1`,
+			},
+		},
+		{
+			name:       "ignores running actions",
+			previous:   []tinkv1alpha1.Event{succeeded},
+			current:    []tinkv1alpha1.Event{succeeded, running},
+			wantEvents: []string{},
+		},
+		{
+			name:       "ignores unchanged events",
+			previous:   []tinkv1alpha1.Event{succeeded},
+			current:    []tinkv1alpha1.Event{succeeded},
+			wantEvents: []string{},
+		},
+	}
+
+	for i := range tests {
+		tt := tests[i]
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			recorder := record.NewFakeRecorder(10) //nolint:gomnd
+			r := &Reconciler{Recorder: recorder}
+
+			w := &tinkv1alpha1.Workflow{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Status:     tinkv1alpha1.WorkflowStatus{Events: tt.previous},
+			}
+
+			r.recordActionEvents(w, tt.current)
+
+			g.Expect(receivedEvents(recorder)).To(Equal(tt.wantEvents))
+		})
+	}
+}
+
+func TestWorkflowReconciler_recordStateEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := map[workflow.State]string{
+		workflow.State_STATE_PENDING: "",
+		workflow.State_STATE_RUNNING: "Normal WorkflowStarted Workflow started",
+		workflow.State_STATE_SUCCESS: "Normal WorkflowSucceeded Workflow finished successfully",
+		workflow.State_STATE_FAILED:  "Warning WorkflowFailed Workflow finished with state STATE_FAILED",
+		workflow.State_STATE_TIMEOUT: "Warning WorkflowFailed Workflow finished with state STATE_TIMEOUT",
+	}
+
+	for state, want := range tests {
+		state, want := state, want
+		t.Run(state.String(), func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			recorder := record.NewFakeRecorder(1)
+			r := &Reconciler{Recorder: recorder}
+
+			r.recordStateEvent(&tinkv1alpha1.Workflow{}, state)
+
+			if want == "" {
+				g.Expect(recorder.Events).To(BeEmpty())
+
+				return
+			}
+
+			g.Expect(receivedEvents(recorder)).To(ConsistOf(want))
+		})
+	}
+}